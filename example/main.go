@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/michalCapo/g-sui/example/pages"
 	r "github.com/michalCapo/g-sui/ui"
@@ -45,6 +46,9 @@ func main() {
 	app.Title = "g-sui Component Showcase"
 	app.Description = "A server-rendered Go UI framework with live WebSocket updates, Tailwind CSS, and interactive components."
 
+	// Show failed rebuilds of ./run on open pages.
+	app.WatchBuildLog(os.Getenv("GSUI_BUILD_LOG"))
+
 	app.Listen(":1424")
 }
 
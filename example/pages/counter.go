@@ -10,14 +10,21 @@ func counterWidget(id string, count int) *r.Node {
 	return r.Div("flex gap-2 items-center bg-purple-500 rounded text-white p-px").ID(id).Render(
 		r.Button("rounded-l px-5 cursor-pointer hover:bg-purple-600").
 			Text("-").
-			OnClick(&r.Action{Name: "counter.dec", Data: map[string]any{"id": id, "count": count}}),
+			OnClick((&r.Action{Name: "counter.dec", Data: map[string]any{"id": id, "count": count}}).Optimistic(bumpJS(-1))),
 		r.Div("text-2xl px-3").Text(fmt.Sprintf("%d", count)),
 		r.Button("rounded-r px-5 cursor-pointer hover:bg-purple-600").
 			Text("+").
-			OnClick(&r.Action{Name: "counter.inc", Data: map[string]any{"id": id, "count": count}}),
+			OnClick((&r.Action{Name: "counter.inc", Data: map[string]any{"id": id, "count": count}}).Optimistic(bumpJS(1))),
 	)
 }
 
+// bumpJS shows the new count before the server answers; the reply renders
+// the real widget over it, and a failed call restores the old number.
+func bumpJS(delta int) string {
+	return fmt.Sprintf("var v=this.parentNode.children[1],n=+v.textContent;"+
+		"v.textContent=Math.max(n+(%d),0);return function(){v.textContent=n}", delta)
+}
+
 func Counter(ctx *r.Context) *r.Node {
 	id1 := r.Target()
 	id2 := r.Target()
@@ -100,7 +100,9 @@ func formPageContent(result1 string, data1 FormData, result2 string, data2 FormD
 
 func HandleFormSubmit(ctx *r.Context) string {
 	var data FormData
-	ctx.Body(&data)
+	if err := ctx.Body(&data); err != nil {
+		return r.Notify("error", "Invalid form data: "+err.Error())
+	}
 
 	result := fmt.Sprintf(
 		"Action=%s  Title=%s  GenderNext=%s  Gender=%s  Country=%s  Some=%s  Number=%s  Agree=%v",
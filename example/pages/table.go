@@ -368,7 +368,7 @@ func handleTableData(ctx *r.Context) string {
 
 	// Handle export (CSV)
 	if req.Operation == "export" {
-		return exportProductsCSV(filtered)
+		return exportProductsCSV(ctx, filtered)
 	}
 
 	// Handle PDF export
@@ -608,16 +608,25 @@ func exportProductsPDF(products []*Product) string {
 	return r.Download("products.pdf", "application/pdf", b64)
 }
 
-func exportProductsCSV(products []*Product) string {
-	var buf bytes.Buffer
-	buf.WriteString("ID,Name,Price,Stock,Created,Category,Status,ReleaseMonth\n")
+func exportProductsCSV(ctx *r.Context, products []*Product) string {
+	headers := []string{"ID", "Name", "Price", "Stock", "Created", "Category", "Status", "ReleaseMonth"}
+	rows := make([][]string, 0, len(products))
 	for _, p := range products {
-		name := strings.ReplaceAll(p.Name, "\"", "\"\"")
-		buf.WriteString(fmt.Sprintf("%d,\"%s\",%.2f,%d,%s,%s,%s,%s\n",
-			p.ID, name, p.Price, p.Stock, p.CreatedAt, p.Category, p.Status, p.ReleaseMonth))
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", p.ID),
+			p.Name,
+			fmt.Sprintf("%.2f", p.Price),
+			fmt.Sprintf("%d", p.Stock),
+			p.CreatedAt,
+			p.Category,
+			p.Status,
+			p.ReleaseMonth,
+		})
 	}
-	b64 := base64.StdEncoding.EncodeToString(buf.Bytes())
-	return r.Download("products.csv", "text/csv", b64)
+	if err := ctx.DownloadCSV("products.csv", headers, rows, r.CSVOpt{BOM: true}); err != nil {
+		return r.Notify("error", "Export failed")
+	}
+	return ""
 }
 
 func RegisterTable(app *r.App, layout func(*r.Context, *r.Node) *r.Node) {
@@ -3,8 +3,14 @@ module github.com/michalCapo/g-sui
 go 1.26.0
 
 require (
+	github.com/coder/websocket v1.8.15
 	github.com/yuin/goldmark v1.8.2
-	golang.org/x/net v0.57.0
+	golang.org/x/net v0.57.0 // indirect
 )
 
 require github.com/go-pdf/fpdf v0.9.0
+
+require (
+	golang.org/x/crypto v0.56.0
+	golang.org/x/text v0.41.0 // indirect
+)
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Access log: one line per request and per action call
+// ---------------------------------------------------------------------------
+
+// AccessLog toggles request logging (off by default). Each HTTP request is
+// logged with method, path, status, duration, session and request ID; each
+// WebSocket action call with its name, outcome and duration:
+//
+//	gsui: GET /users 200 1.84ms sid=3f9a0c1e req=9b2e41d07c5a8f36
+//	gsui: ACT users.save ok 12.3ms sid=3f9a0c1e req=51c0a7e2d94b13f8
+//
+// The session is shown as a short hash of its ID, enough to follow one
+// visitor through the log without writing usable session IDs to it.
+// WebSocket connections are logged with status 101 when they close.
+func (app *App) AccessLog(enabled bool) {
+	app.mu.Lock()
+	app.accessLog = enabled
+	app.mu.Unlock()
+}
+
+func (app *App) accessLogging() bool {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	return app.accessLog
+}
+
+// logRequests wraps next so that every request is logged once it finishes.
+func (app *App) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.accessLogging() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		app.log().Infof("%s %s %d %s sid=%s req=%s", r.Method, r.URL.RequestURI(), sw.status,
+			time.Since(start).Round(10*time.Microsecond), sessionTag(requestSessionID(r)), requestIDOf(r))
+	})
+}
+
+// logAction writes the access log line of one action call and counts it
+// in the app's metrics.
+func (app *App) logAction(ctx *Context, act, outcome string, start time.Time) {
+	d := time.Since(start)
+	if app.accessLogging() {
+		app.log().Infof("ACT %s %s %s sid=%s req=%s", act, outcome,
+			d.Round(10*time.Microsecond), sessionTag(ctx.sessionID), ctx.requestID)
+	}
+	if m := app.collecting(); m != nil {
+		m.action(outcome, d)
+	}
+}
+
+// sessionTag shortens a session ID to a hash prefix for logs; "-" if none.
+func sessionTag(sid string) string {
+	if sid == "" {
+		return "-"
+	}
+	sum := sha256.Sum256([]byte(sid))
+	return hex.EncodeToString(sum[:4])
+}
+
+// statusWriter records the status code a handler sends.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if sw.status == 0 {
+		sw.status = code
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming handlers (SSE) working behind the logger.
+func (sw *statusWriter) Flush() {
+	http.NewResponseController(sw.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection to the WebSocket server; the request is
+// then logged as 101 Switching Protocols.
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	sw.status = http.StatusSwitchingProtocols
+	return http.NewResponseController(sw.ResponseWriter).Hijack()
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,404 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ---------------------------------------------------------------------------
+// Body binding: gsui struct tag options
+// ---------------------------------------------------------------------------
+
+// bindOpts are the options of one struct field's gsui tag.
+type bindOpts struct {
+	trim     bool // strip surrounding whitespace and control characters
+	collapse bool // trim, then squeeze inner whitespace runs to one space
+	lower    bool
+	upper    bool
+	number   bool     // integer and float fields: read numbers sent as text
+	layout   string   // time.Time fields: custom layout, see Body
+	oneof    []string // allowed values; empty allows any
+}
+
+func (o bindOpts) empty() bool {
+	return !o.trim && !o.collapse && !o.lower && !o.upper && !o.number && o.layout == "" && o.oneof == nil
+}
+
+// bindField links a payload key to the options of the field it fills.
+type bindField struct {
+	key    string
+	opts   bindOpts
+	isTime bool
+	isNum  bool
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeLayouts are tried, in order, for time.Time fields without a layout
+// option: RFC 3339 plus what date, datetime-local, month and time inputs
+// send. Week inputs are read by parseISOWeek.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02", "2006-01", "15:04:05", "15:04"}
+
+// parseISOWeek reads the value of a week input, "2006-W02", as midnight
+// UTC on the Monday starting that ISO 8601 week.
+func parseISOWeek(s string) (time.Time, bool) {
+	year, week, ok := strings.Cut(s, "-W")
+	if !ok || len(year) < 4 || len(week) != 2 {
+		return time.Time{}, false
+	}
+	y, err1 := strconv.Atoi(year)
+	w, err2 := strconv.Atoi(week)
+	if err1 != nil || err2 != nil || y < 1 || w < 1 || w > 53 {
+		return time.Time{}, false
+	}
+	// January 4th is always in week 1; step back to its Monday.
+	jan4 := time.Date(y, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7+(w-1)*7)
+	if _, got := monday.ISOWeek(); got != w {
+		return time.Time{}, false // week 53 of a 52-week year
+	}
+	return monday, true
+}
+
+// zonedLayout reports whether values of layout carry a date and a time of
+// day without a zone, and so are read in the user's time zone. Dates and
+// clock times alone are calendar values and stay in UTC.
+func zonedLayout(layout string) bool {
+	if strings.Contains(layout, "Z07") || strings.Contains(layout, "-07") || strings.Contains(layout, "MST") {
+		return false
+	}
+	hasClock := strings.Contains(layout, "15") || strings.Contains(layout, "3")
+	hasDate := strings.Contains(layout, "2006") || strings.Contains(layout, "06") || strings.Contains(layout, "Jan") || strings.Contains(layout, "01")
+	return hasClock && hasDate
+}
+
+// isNumber reports whether t, or what it points to, is an integer or
+// floating-point type.
+func isNumber(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+var bindCache sync.Map // reflect.Type -> []bindField
+
+// bindFields returns the tagged fields of struct type t, including those
+// promoted from embedded structs. Keys follow encoding/json naming.
+func bindFields(t reflect.Type) []bindField {
+	if v, ok := bindCache.Load(t); ok {
+		return v.([]bindField)
+	}
+	var out []bindField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, jsonOpts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			et := sf.Type
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				out = append(out, bindFields(et)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		o := parseBindTag(sf.Tag.Get("gsui"))
+		isTime := sf.Type == timeType || sf.Type == reflect.PointerTo(timeType)
+		// Fields with the ",string" option read quoted numbers themselves.
+		isNum := o.number && isNumber(sf.Type) && !slices.Contains(strings.Split(jsonOpts, ","), "string")
+		if !o.empty() || isTime {
+			out = append(out, bindField{key: name, opts: o, isTime: isTime, isNum: isNum})
+		}
+	}
+	bindCache.Store(t, out)
+	return out
+}
+
+// parseBindTag reads a gsui tag such as "trim,lower". A layout option
+// takes the rest of the tag, so layouts may contain commas.
+func parseBindTag(tag string) bindOpts {
+	var o bindOpts
+	if i := strings.Index(tag, "layout="); i >= 0 {
+		o.layout = tag[i+len("layout="):]
+		tag = tag[:i]
+	}
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if list, ok := strings.CutPrefix(part, "oneof="); ok {
+			o.oneof = strings.Split(list, "|")
+			continue
+		}
+		switch part {
+		case "trim":
+			o.trim = true
+		case "collapse":
+			o.collapse = true
+		case "lower":
+			o.lower = true
+		case "upper":
+			o.upper = true
+		case "number":
+			o.number = true
+		}
+	}
+	return o
+}
+
+// normalizeBody returns data with the gsui options of target's fields
+// applied and time values rewritten to RFC 3339, date-times read in loc
+// and converted to UTC. A value outside a oneof
+// set is an error, or with strict off is dropped so the field keeps its
+// zero value. The caller's map is never modified; when nothing applies it
+// is returned as is.
+func normalizeBody(data map[string]any, target any, strict bool, loc *time.Location, lg Logger) (map[string]any, error) {
+	t := reflect.TypeOf(target)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return data, nil
+	}
+	fields := bindFields(t)
+	if len(fields) == 0 {
+		return data, nil
+	}
+	out := make(map[string]any, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+	for _, f := range fields {
+		key, ok := payloadKey(out, f.key)
+		if !ok {
+			continue
+		}
+		if f.opts.oneof != nil {
+			if err := f.opts.allowed(out[key]); err != nil {
+				if strict {
+					return nil, fmt.Errorf("gsui: field %s: %w", f.key, err)
+				}
+				lg.Warnf("field %s: %v; ignored", f.key, err)
+				delete(out, key)
+				continue
+			}
+		}
+		s, ok := out[key].(string)
+		if !ok {
+			continue
+		}
+		s = f.opts.apply(s)
+		out[key] = s
+		if f.isTime {
+			v, err := parseBindTime(s, f.opts.layout, loc)
+			if err != nil {
+				return nil, fmt.Errorf("gsui: field %s: %w", f.key, err)
+			}
+			out[key] = v
+		}
+		if f.isNum {
+			v, err := parseBindNumber(s)
+			if err != nil {
+				return nil, fmt.Errorf("gsui: field %s: %w", f.key, err)
+			}
+			out[key] = v
+		}
+	}
+	return out, nil
+}
+
+// parseBindNumber converts a number submitted as text to a JSON number,
+// or null for an empty value. Digit grouping and the decimal separator are
+// read as ParseDecimal reads them, so "1.234,56", "1,234.56" and
+// "1 234,56" all give 1234.56.
+func parseBindNumber(s string) (any, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	d, err := ParseDecimal(s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %q as a number", s)
+	}
+	return json.Number(d.String()), nil
+}
+
+// parseBindTime converts a submitted time to the form encoding/json
+// expects: RFC 3339, or null for an empty value (the zero time, or nil for
+// *time.Time). Date-times without a zone are read in loc and converted to
+// UTC; dates and clock times alone are read as UTC.
+func parseBindTime(s, layout string, loc *time.Location) (any, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	parse := func(l string) (time.Time, error) {
+		if zonedLayout(l) {
+			v, err := time.ParseInLocation(l, s, loc)
+			return v.UTC(), err
+		}
+		return time.Parse(l, s)
+	}
+	if layout != "" {
+		v, err := parse(layout)
+		if err != nil {
+			return nil, fmt.Errorf("%q does not match layout %q", s, layout)
+		}
+		return v.Format(time.RFC3339Nano), nil
+	}
+	for _, l := range timeLayouts {
+		if v, err := parse(l); err == nil {
+			return v.Format(time.RFC3339Nano), nil
+		}
+	}
+	if v, ok := parseISOWeek(s); ok {
+		return v.Format(time.RFC3339Nano), nil
+	}
+	return nil, fmt.Errorf("cannot parse %q as a time", s)
+}
+
+// allowed checks v against the oneof set after the string options ran.
+// Empty values pass; mark the field Required in the form to forbid them.
+func (o bindOpts) allowed(v any) error {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		s = o.apply(v)
+	case []any, map[string]any:
+		return fmt.Errorf("value must be one of %s", strings.Join(o.oneof, ", "))
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || slices.Contains(o.oneof, s) {
+		return nil
+	}
+	return fmt.Errorf("value %q is not one of %s", s, strings.Join(o.oneof, ", "))
+}
+
+// StrictBinding controls what Body does with a value outside a field's
+// oneof set: fail with an error (the default) or, when strict is false,
+// log it and leave the field at its zero value.
+func (app *App) StrictBinding(strict bool) {
+	app.mu.Lock()
+	app.lenient = !strict
+	app.mu.Unlock()
+}
+
+// payloadKey finds the key encoding/json would decode into field key:
+// an exact match first, then a case-insensitive one.
+func payloadKey(data map[string]any, key string) (string, bool) {
+	if _, ok := data[key]; ok {
+		return key, true
+	}
+	for k := range data {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// apply normalizes a single string value.
+func (o bindOpts) apply(s string) string {
+	if o.trim || o.collapse {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && !unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, s)
+		s = strings.TrimSpace(s)
+	}
+	if o.collapse {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	if o.lower {
+		s = strings.ToLower(s)
+	}
+	if o.upper {
+		s = strings.ToUpper(s)
+	}
+	return s
+}
+
+// Body decodes the action payload into target, usually a pointer to a
+// struct. Fields match payload keys as in encoding/json. String fields may
+// carry a gsui tag to clean up what users type or paste:
+//
+//	trim      strip surrounding whitespace and control characters
+//	collapse  trim, then squeeze inner whitespace runs to a single space
+//	lower     lower-case the value
+//	upper     upper-case the value
+//	oneof=a|b only accept these values (checked after the options above)
+//
+// A value outside a oneof set makes Body fail, so a crafted request cannot
+// store an option the form never offered; see App.StrictBinding.
+//
+// time.Time and *time.Time fields accept RFC 3339 and the values of date,
+// datetime-local, month, week and time inputs; a month binds its first
+// day and a week its Monday; an empty value leaves the zero time (nil).
+// Date-times without a zone, as datetime-local inputs send them, are read
+// in ctx.TimeZone and bound in UTC; dates and clock times alone are bound
+// as UTC, unshifted.
+// Other formats need a layout option, which must come last in the tag:
+//
+//	Born time.Time `json:"Born" gsui:"layout=02.01.2006"`
+//
+// Integer and float fields tagged gsui:"number" accept numbers sent as
+// text, as inputs send them, with grouping and the decimal separator read
+// as ParseDecimal reads them ("1.234,56", "12 000"); an empty value leaves
+// zero (nil). The reading is ambiguous for a lone separator ("1.500" is
+// 1.5, "0,125" is 125), so tag only fields whose inputs send one notation,
+// such as NumberFormat inputs, which submit the normalized number. Fields
+// with the json ",string" option are left to encoding/json:
+//
+//	Weight float64 `json:"Weight" gsui:"number"`
+//
+//	var in struct {
+//		Email string `json:"Email" gsui:"trim,lower"`
+//		Name  string `json:"Name" gsui:"collapse"`
+//	}
+//	if err := ctx.Body(&in); err != nil { ... }
+func (ctx *Context) Body(target any) error {
+	if ctx.wsData == nil {
+		return nil
+	}
+	strict := true
+	if ctx.app != nil {
+		ctx.app.mu.RLock()
+		strict = !ctx.app.lenient
+		ctx.app.mu.RUnlock()
+	}
+	data, err := normalizeBody(ctx.wsData, target, strict, ctx.TimeZone(), ctx.log())
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, target)
+}
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBodyNormalizesTaggedStrings(t *testing.T) {
+	type Contact struct {
+		Phone string `gsui:"trim"`
+	}
+	var in struct {
+		Contact
+		Email string `json:"email" gsui:"trim,lower"`
+		Name  string `json:"Name" gsui:"collapse"`
+		Code  string `gsui:"trim,upper"`
+		Raw   string
+	}
+	data := map[string]any{
+		"Email": " John@Example.COM\t",
+		"Name":  "  Ada \n  Lovelace ",
+		"code":  "sk\x00 ",
+		"Phone": "\x07 555 ",
+		"Raw":   " keep ",
+	}
+	ctx := &Context{wsData: data}
+	if err := ctx.Body(&in); err != nil {
+		t.Fatal(err)
+	}
+	if in.Email != "john@example.com" || in.Name != "Ada Lovelace" || in.Code != "SK" || in.Phone != "555" || in.Raw != " keep " {
+		t.Errorf("bound %+v", in)
+	}
+	if data["Name"] != "  Ada \n  Lovelace " {
+		t.Error("Body must not modify the payload map")
+	}
+}
+
+func TestBodyParsesGroupedNumbers(t *testing.T) {
+	var in struct {
+		Weight float64 `json:"Weight" gsui:"number"`
+		Count  int     `json:"Count" gsui:"number"`
+		Limit  *int64  `json:"Limit" gsui:"number"`
+		Ratio  float32 `json:"Ratio" gsui:"number"`
+		Plain  float64 `json:"Plain" gsui:"number"`
+		ID     int64   `json:"ID,string" gsui:"number"`
+		Amount float64 `json:"Amount"`
+	}
+	ctx := &Context{wsData: map[string]any{
+		"Weight": "1.234,56", "Count": " 12 000 ", "Limit": "", "Ratio": "0,5", "Plain": 2.5, "ID": "42",
+	}}
+	if err := ctx.Body(&in); err != nil {
+		t.Fatal(err)
+	}
+	if in.Weight != 1234.56 || in.Count != 12000 || in.Limit != nil || in.Ratio != 0.5 || in.Plain != 2.5 || in.ID != 42 {
+		t.Errorf("bound %+v", in)
+	}
+	// Untagged fields are left to encoding/json, which wants a number.
+	ctx.wsData = map[string]any{"Amount": "1.500"}
+	if err := ctx.Body(&in); err == nil {
+		t.Fatalf("untagged field read text: %+v", in)
+	}
+	ctx.wsData = map[string]any{"Count": "twelve"}
+	if err := ctx.Body(&in); err == nil || !strings.Contains(err.Error(), "Count") {
+		t.Fatalf("unparsable number: %v", err)
+	}
+}
+
+func TestBodyKeepsSecondsOfTimeInputs(t *testing.T) {
+	var in struct {
+		At   time.Time `json:"At"`
+		Full time.Time `json:"Full"`
+		Frac time.Time `json:"Frac"`
+	}
+	ctx := &Context{wsData: map[string]any{"At": "09:30:15", "Full": "2024-03-15T09:30:15", "Frac": "09:30:15.250"}}
+	if err := ctx.Body(&in); err != nil {
+		t.Fatal(err)
+	}
+	if in.At.Format("15:04:05") != "09:30:15" || in.Full.Format(time.DateTime) != "2024-03-15 09:30:15" || in.Frac.Nanosecond() != 250e6 {
+		t.Errorf("bound %+v", in)
+	}
+}
+
+func TestBodyParsesMonthAndWeekInputs(t *testing.T) {
+	var in struct {
+		Month time.Time  `json:"Month"`
+		Week  time.Time  `json:"Week"`
+		First *time.Time `json:"First"`
+	}
+	ctx := &Context{wsData: map[string]any{"Month": "2024-03", "Week": "2024-W02", "First": "2020-W53"}}
+	if err := ctx.Body(&in); err != nil {
+		t.Fatal(err)
+	}
+	if !in.Month.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Month = %v", in.Month)
+	}
+	if !in.Week.Equal(time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Week = %v", in.Week)
+	}
+	if in.First == nil || !in.First.Equal(time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("First = %v", in.First)
+	}
+	if v := IWeek().TimeValue(in.Week).ToJS(); !strings.Contains(v, "'2024-W02'") {
+		t.Errorf("week does not round-trip: %s", v)
+	}
+
+	for _, bad := range []string{"2021-W53", "2024-W00", "2024-W2"} {
+		ctx.wsData = map[string]any{"Week": bad}
+		if err := ctx.Body(&in); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestBodyParsesTimeLayouts(t *testing.T) {
+	var in struct {
+		Day    time.Time  `json:"Day"`
+		At     time.Time  `json:"At"`
+		Born   time.Time  `json:"Born" gsui:"trim,layout=02.01.2006"`
+		Until  *time.Time `json:"Until"`
+		Signed time.Time  `json:"Signed" gsui:"layout=Jan 2, 2006"`
+	}
+	ctx := &Context{wsData: map[string]any{
+		"Day": "2024-03-15", "At": "2024-03-15T09:30", "Born": " 05.11.1990 ", "Until": "", "Signed": "Feb 1, 2020",
+	}}
+	if err := ctx.Body(&in); err != nil {
+		t.Fatal(err)
+	}
+	if got := in.Born.Format("02.01.2006"); got != "05.11.1990" {
+		t.Errorf("Born round trip = %s", got)
+	}
+	if in.Day.Format(time.DateOnly) != "2024-03-15" || in.At.Format("15:04") != "09:30" || in.Until != nil || in.Signed.Month() != time.February {
+		t.Errorf("bound %+v", in)
+	}
+	ctx.wsData = map[string]any{"Born": "1990-11-05"}
+	if err := ctx.Body(&in); err == nil || !strings.Contains(err.Error(), "Born") {
+		t.Fatalf("layout mismatch error = %v", err)
+	}
+}
+
+func TestBodyRejectsValuesOutsideOneof(t *testing.T) {
+	type Order struct {
+		Status string `json:"Status" gsui:"trim,lower,oneof=new|paid|shipped"`
+		Qty    int    `json:"Qty" gsui:"oneof=1|2|3"`
+	}
+	app := NewApp()
+	ctx := &Context{app: app, wsData: map[string]any{"Status": " PAID ", "Qty": float64(2)}}
+	var in Order
+	if err := ctx.Body(&in); err != nil || in.Status != "paid" || in.Qty != 2 {
+		t.Fatalf("allowed values: %+v, %v", in, err)
+	}
+
+	ctx.wsData = map[string]any{"Status": "refunded", "Qty": float64(2)}
+	in = Order{}
+	if err := ctx.Body(&in); err == nil || !strings.Contains(err.Error(), "Status") {
+		t.Fatalf("strict mode error = %v", err)
+	}
+
+	app.StrictBinding(false)
+	in = Order{}
+	if err := ctx.Body(&in); err != nil || in.Status != "" || in.Qty != 2 {
+		t.Fatalf("lenient mode: %+v, %v", in, err)
+	}
+}
+
+func TestFormValidateRejectsUnknownOption(t *testing.T) {
+	f := NewForm("f").SelectField("Country", "country").Opts(":Pick", "sk:Slovakia").Render()
+	if errs := f.Validate(map[string]any{"country": "xx"}); errs.Get("country") != "Country format is invalid" {
+		t.Fatalf("Validate = %v", errs)
+	}
+	if errs := f.Validate(map[string]any{"country": "sk"}); errs.HasErrors() {
+		t.Fatalf("Validate = %v", errs)
+	}
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Body limit: caps the size of HTTP request bodies
+// ---------------------------------------------------------------------------
+
+// DefaultMaxBodySize is the request body limit of an app that never calls
+// MaxBodySize: 10 MB.
+const DefaultMaxBodySize int64 = 10 << 20
+
+// MaxBodySize limits HTTP request bodies to n bytes; n <= 0 lifts the
+// limit. Without routes it sets the default for every route; with routes
+// it overrides the default for those only. A route is written as it was
+// registered, with or without its method:
+//
+//	app.MaxBodySize(64 << 10)                    // 64 KB for most endpoints
+//	app.MaxBodySize(200<<20, "POST /api/upload") // 200 MB for uploads
+//	app.MaxBodySize(1<<20, "/api/items/{id}")    // any method
+//
+// A request announcing a larger body is refused with 413 Request Entity
+// Too Large before anything reads it, the CSRF check included. A body that
+// turns out larger while it is read (chunked uploads) fails the read with
+// *http.MaxBytesError, and the connection is closed after the response.
+// The limit of "/__ws" caps each WebSocket message instead: a larger
+// action call closes its connection with status 1009 (message too big).
+func (app *App) MaxBodySize(n int64, routes ...string) {
+	if n <= 0 {
+		n = -1
+	}
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if len(routes) == 0 {
+		app.maxBody = n
+		return
+	}
+	if app.bodyLimits == nil {
+		app.bodyLimits = make(map[string]int64)
+	}
+	for _, route := range routes {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok {
+			method, path = "", route
+		}
+		key := muxPattern(strings.TrimSpace(path))
+		if method != "" {
+			key = strings.ToUpper(method) + " " + key
+		}
+		app.bodyLimits[key] = n
+	}
+}
+
+// bodyLimit returns the limit for a request matched to the mux pattern,
+// -1 for none.
+func (app *App) bodyLimit(pattern string) int64 {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	if n, ok := app.bodyLimits[pattern]; ok {
+		return n
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		if n, ok := app.bodyLimits[path]; ok {
+			return n
+		}
+	}
+	if app.maxBody == 0 {
+		return DefaultMaxBodySize
+	}
+	return app.maxBody
+}
+
+// limitBody wraps next so that request bodies are capped at the limit of
+// the route they are for.
+func (app *App) limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+		_, pattern := app.mux.Handler(r)
+		n := app.bodyLimit(pattern)
+		if n < 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.ContentLength > n {
+			w.Header().Set("Connection", "close")
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, n)
+		next.ServeHTTP(w, r)
+	})
+}
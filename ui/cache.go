@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Cache: memoized subtrees shared by every request
+// ---------------------------------------------------------------------------
+
+// nodeCache holds the subtrees built by App.Cache.
+type nodeCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	done    chan struct{} // closed once node is built
+	node    *Node
+	expires time.Time // zero: until invalidated
+}
+
+// Cache returns the subtree built by fn, building it at most once per key
+// and ttl (ttl <= 0 keeps it until Invalidate). Concurrent requests for a
+// missing key wait for a single build. Use it for sections that are the
+// same for every visitor and costly to build: navigation from a database,
+// a rendered markdown page, a footer with statistics.
+//
+//	app.Layout(func(ctx *ui.Context) *ui.Node {
+//		return ui.Div().Render(
+//			app.Cache("nav", 5*time.Minute, buildNav),
+//			ui.Main().ID("__content__"),
+//		)
+//	})
+//
+// The subtree is shared, so fn must not depend on the request, and the
+// returned node must be treated as read-only; it is wrapped in a Fragment,
+// so calls on it (Class, Render, ...) do not reach the cached tree. Lazy
+// nodes inside still run on every compile. If fn panics nothing is cached.
+func (app *App) Cache(key string, ttl time.Duration, fn func() *Node) *Node {
+	c := &app.cache
+	c.mu.Lock()
+	e := c.entries[key]
+	if e != nil && e.expired() {
+		e = nil
+	}
+	if e != nil {
+		c.mu.Unlock()
+		<-e.done
+		return Fragment(e.node)
+	}
+	e = &cacheEntry{done: make(chan struct{})}
+	if c.entries == nil {
+		c.entries = make(map[string]*cacheEntry)
+	}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	built := false
+	defer func() {
+		if !built {
+			c.mu.Lock()
+			if c.entries[key] == e {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+		}
+		close(e.done)
+	}()
+	e.node = fn()
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	built = true
+	return Fragment(e.node)
+}
+
+// expired reports whether a built entry has outlived its ttl. Entries still
+// being built never expire.
+func (e *cacheEntry) expired() bool {
+	select {
+	case <-e.done:
+		return !e.expires.IsZero() && time.Now().After(e.expires)
+	default:
+		return false
+	}
+}
+
+// Invalidate drops the cached subtrees of keys, or all of them when called
+// without keys, so the next Cache call rebuilds them.
+//
+//	app.Invalidate("nav") // after editing the menu
+func (app *App) Invalidate(keys ...string) {
+	c := &app.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(keys) == 0 {
+		c.entries = nil
+		return
+	}
+	for _, k := range keys {
+		delete(c.entries, k)
+	}
+}
@@ -2,8 +2,15 @@ package ui
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/yuin/goldmark"
 )
@@ -143,38 +150,41 @@ func SkeletonForm() *Node {
 // 2. Markdown Rendering
 // ---------------------------------------------------------------------------
 
-// Markdown converts a markdown string to HTML and renders it inside a Div.
-// Since the framework produces only document.createElement JS (no innerHTML
-// setter on nodes), this uses .JS() to set innerHTML after the node mounts.
-// Goldmark's default safe renderer omits raw HTML and unsafe links such as
-// javascript: URLs; do not enable unsafe markdown rendering for untrusted input.
-// The class parameter is applied to the container div.
+// Markdown converts a markdown string to HTML and renders it inside a Div
+// via HTML. Goldmark's default safe renderer omits raw HTML and unsafe links
+// such as javascript: URLs; do not enable unsafe markdown rendering for
+// untrusted input. The class parameter is applied to the container div.
 func Markdown(class, content string) *Node {
 	var buf bytes.Buffer
 	if err := goldmark.Convert([]byte(content), &buf); err != nil {
 		return Div(class).Text(content)
 	}
-
-	id := Target()
-	html := buf.String()
-
-	return Div(class).ID(id).JS(
-		fmt.Sprintf("document.getElementById('%s').innerHTML='%s';", escJS(id), escJS(html)),
-	)
+	return Div(class).HTML(buf.String())
 }
 
 // ---------------------------------------------------------------------------
-// 3. Captcha V3 (client-side only)
+// 3. Captcha V3
 // ---------------------------------------------------------------------------
 
 // CaptchaV3Builder configures a reCAPTCHA v3 widget that loads the Google
 // script, executes the challenge, and stores the token in a hidden input.
+// With a Secret, the same builder verifies the submitted token.
 type CaptchaV3Builder struct {
 	siteKey    string
+	secret     string
 	formAction string
 	tokenField string
+	minScore   float64
 }
 
+// captchaVerifyURL is Google's siteverify endpoint; tests replace it.
+var captchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+
+// ErrCaptcha is returned by CaptchaV3Builder.Verify when the token is
+// missing, invalid, expired, issued for another action or scored below the
+// threshold.
+var ErrCaptcha = errors.New("gsui: captcha verification failed")
+
 // NewCaptchaV3 creates a new reCAPTCHA v3 builder with the given site key.
 func NewCaptchaV3(siteKey string) *CaptchaV3Builder {
 	return &CaptchaV3Builder{
@@ -196,6 +206,88 @@ func (c *CaptchaV3Builder) TokenField(name string) *CaptchaV3Builder {
 	return c
 }
 
+// Secret sets the reCAPTCHA secret key Verify checks tokens with. It never
+// reaches the page.
+func (c *CaptchaV3Builder) Secret(key string) *CaptchaV3Builder {
+	c.secret = key
+	return c
+}
+
+// MinScore sets the lowest score Verify accepts, from 0 (likely a bot) to
+// 1 (likely a human). Defaults to 0.5.
+func (c *CaptchaV3Builder) MinScore(score float64) *CaptchaV3Builder {
+	c.minScore = score
+	return c
+}
+
+// Verify checks the token the widget submitted with the action's form
+// against Google's siteverify endpoint. It returns ErrCaptcha when the
+// token is rejected or scores below MinScore, and another error when
+// Google cannot be reached. Build the widget and verify with the same
+// builder, so the action names match:
+//
+//	var signupCaptcha = ui.NewCaptchaV3(siteKey).Secret(secret).FormAction("signup")
+//
+//	// page: signupCaptcha.Build() inside the form
+//	// action:
+//	if err := signupCaptcha.Verify(ctx); err != nil {
+//	    return ui.Notify("error", "Please try again")
+//	}
+func (c *CaptchaV3Builder) Verify(ctx *Context) error {
+	if c.secret == "" {
+		return errors.New("gsui: captcha: no secret key set")
+	}
+	token, _ := ctx.wsData[c.tokenField].(string)
+	if token == "" && ctx.Request != nil && ctx.wsConn == nil {
+		token = ctx.Request.PostFormValue(c.tokenField)
+	}
+	if token == "" {
+		return ErrCaptcha
+	}
+
+	form := url.Values{"secret": {c.secret}, "response": {token}}
+	req, err := http.NewRequestWithContext(ctx.Ctx(), http.MethodPost, captchaVerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("gsui: captcha: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("gsui: captcha: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gsui: captcha: siteverify answered %s", resp.Status)
+	}
+	var res struct {
+		Success bool     `json:"success"`
+		Score   float64  `json:"score"`
+		Action  string   `json:"action"`
+		Errors  []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("gsui: captcha: %w", err)
+	}
+
+	minScore := c.minScore
+	if minScore == 0 {
+		minScore = 0.5
+	}
+	action := c.formAction
+	if action == "" {
+		action = "submit"
+	}
+	switch {
+	case !res.Success:
+		return fmt.Errorf("%w: %s", ErrCaptcha, strings.Join(res.Errors, ", "))
+	case res.Action != action:
+		return fmt.Errorf("%w: token for action %q, want %q", ErrCaptcha, res.Action, action)
+	case res.Score < minScore:
+		return fmt.Errorf("%w: score %.1f below %.1f", ErrCaptcha, res.Score, minScore)
+	}
+	return nil
+}
+
 // Build produces the Node tree: a container with a hidden input for the
 // token and JS that loads the reCAPTCHA v3 script, executes the challenge,
 // and auto-refreshes the token every 110 seconds.
@@ -208,25 +300,29 @@ func (c *CaptchaV3Builder) Build() *Node {
 		action = "submit"
 	}
 
+	// The reCAPTCHA script is loaded once per document; later renders
+	// (after a navigation or swap) reuse it.
 	js := fmt.Sprintf(
 		`(function(){`+
-			`var s=document.createElement('script');`+
-			`s.src='https://www.google.com/recaptcha/api.js?render=%s';`+
-			`s.onload=function(){`+
+			`function run(){`+
 			`grecaptcha.ready(function(){`+
-			`function exec(){grecaptcha.execute('%s',{action:'%s'}).then(function(token){`+
-			`var el=document.getElementById('%s');if(el)el.value=token;`+
-			`})}`+
+			`var t;function exec(){var el=document.getElementById('%s');if(!el){clearInterval(t);return}`+
+			`grecaptcha.execute('%s',{action:'%s'}).then(function(token){el.value=token})}`+
 			`exec();`+
-			`setInterval(exec,110000);`+
+			`t=setInterval(exec,110000);`+
 			`})`+
-			`};`+
+			`}`+
+			`var s=document.getElementById('gsui-recaptcha');`+
+			`if(s){if(window.grecaptcha&&grecaptcha.execute)run();else s.addEventListener('load',run);return}`+
+			`s=document.createElement('script');s.id='gsui-recaptcha';`+
+			`s.src='https://www.google.com/recaptcha/api.js?render=%s';`+
+			`s.addEventListener('load',run);`+
 			`document.head.appendChild(s);`+
 			`})();`,
-		escJS(c.siteKey),
+		escJS(inputID),
 		escJS(c.siteKey),
 		escJS(action),
-		escJS(inputID),
+		escJS(c.siteKey),
 	)
 
 	return Div().ID(containerID).Render(
@@ -270,14 +366,18 @@ type accordionItem struct {
 	title   string
 	content *Node
 	open    bool
+	lazy    *Action
 }
 
 // AccordionBuilder builds an accordion component using native <details>/<summary>.
+// Items expand with a height transition (skipped under prefers-reduced-motion)
+// and keep aria-expanded on the summary in sync.
 type AccordionBuilder struct {
 	items    []accordionItem
 	multiple bool
 	variant  string
 	class    string
+	id       string
 }
 
 // NewAccordion creates a new AccordionBuilder with default "bordered" variant.
@@ -296,6 +396,25 @@ func (a *AccordionBuilder) Item(title string, content *Node, open ...bool) *Acco
 	return a
 }
 
+// ItemLazy adds an item whose body is loaded from the server the first time
+// it opens. Like TabsBuilder.TabLazy, the action receives "Panel" (the body's
+// DOM ID) and "Item" (the index) in its data and returns a swap into Panel.
+func (a *AccordionBuilder) ItemLazy(title string, action *Action, open ...bool) *AccordionBuilder {
+	isOpen := len(open) > 0 && open[0]
+	a.items = append(a.items, accordionItem{title: title, lazy: action, open: isOpen})
+	return a
+}
+
+// AccordionID sets the wrapper ID; item bodies then get stable IDs (see
+// AccordionPanelID).
+func (a *AccordionBuilder) AccordionID(id string) *AccordionBuilder { a.id = id; return a }
+
+// AccordionPanelID returns the DOM ID of item index's body in the accordion
+// built with AccordionID(accordionID).
+func AccordionPanelID(accordionID string, index int) string {
+	return fmt.Sprintf("%s-panel-%d", accordionID, index)
+}
+
 // Multiple controls whether multiple items can be open simultaneously.
 // When false (default), opening one item closes others.
 func (a *AccordionBuilder) Multiple(m bool) *AccordionBuilder {
@@ -317,7 +436,10 @@ func (a *AccordionBuilder) AccordionClass(cls string) *AccordionBuilder {
 
 // Build produces the complete accordion Node tree.
 func (a *AccordionBuilder) Build() *Node {
-	groupID := Target()
+	groupID := a.id
+	if groupID == "" {
+		groupID = Target()
+	}
 
 	var wrapperClass, itemClass, summaryClass, contentClass string
 	switch a.variant {
@@ -347,6 +469,7 @@ func (a *AccordionBuilder) Build() *Node {
 	)
 
 	items := make([]*Node, len(a.items))
+	lazy := make([]string, len(a.items))
 	for i, item := range a.items {
 		details := Details(itemClass)
 		if item.open {
@@ -355,32 +478,51 @@ func (a *AccordionBuilder) Build() *Node {
 
 		chevron := Icon("expand_more", "text-sm text-gray-400 dark:text-gray-500 transition-transform chevron")
 
-		summary := Summary(summaryClass).Render(
-			Span("dark:text-gray-200").Text(item.title),
-			chevron,
-		)
+		bodyID := Target()
+		if a.id != "" {
+			bodyID = AccordionPanelID(a.id, i)
+		}
+		summary := Summary(summaryClass).
+			Attr("aria-expanded", fmt.Sprintf("%t", item.open)).
+			Attr("aria-controls", bodyID).
+			Render(
+				Span("dark:text-gray-200").Text(item.title),
+				chevron,
+			)
 
-		details.Render(summary, Div(contentClass).Render(item.content))
+		body := Div(contentClass).ID(bodyID).Attr("data-acc-body", "")
+		if item.content != nil {
+			body.Render(item.content)
+		} else if item.lazy != nil {
+			body.Render(SkeletonList())
+		}
+		details.Render(summary, body)
 		items[i] = details
+		lazy[i] = lazyLoadJS(item.lazy, bodyID, "Item", i)
 	}
 
 	wrapper := Div(wrapperClass).ID(groupID).Render(styleNode)
 	wrapper.Render(items...)
 
-	if !a.multiple {
-		js := fmt.Sprintf(
-			`(function(){`+
-				`var g=document.getElementById('%s');if(!g)return;`+
-				`var ds=g.querySelectorAll('details');`+
-				`ds.forEach(function(d){`+
-				`d.addEventListener('toggle',function(){`+
-				`if(d.open){ds.forEach(function(o){if(o!==d)o.removeAttribute('open')})}`+
-				`})});`+
-				`})();`,
-			escJS(groupID),
-		)
-		wrapper.JS(js)
-	}
+	wrapper.JS(fmt.Sprintf(
+		`var g=this,single=%t,lazy=[%s];`+
+			`var ds=Array.prototype.filter.call(g.querySelectorAll('details'),function(d){return d.parentNode===g});`+
+			`var still=window.matchMedia&&matchMedia('(prefers-reduced-motion: reduce)').matches;`+
+			`var body=function(d){return d.querySelector(':scope>[data-acc-body]')};`+
+			`var slide=function(d,open){var b=body(d);if(!b||still){d.open=open;return}`+
+			`var from=open?0:b.scrollHeight;if(open)d.open=true;var to=open?b.scrollHeight:0;`+
+			`b.style.overflow='hidden';b.style.height=from+'px';b.offsetHeight;`+
+			`b.style.transition='height 200ms ease';b.style.height=to+'px';`+
+			`var done=function(){b.removeEventListener('transitionend',done);clearTimeout(t);b.style.transition='';b.style.height='';b.style.overflow='';if(!open)d.open=false};`+
+			`var t=setTimeout(done,260);b.addEventListener('transitionend',done)};`+
+			`ds.forEach(function(d,i){var s=d.querySelector(':scope>summary');`+
+			`if(s)s.addEventListener('click',function(e){e.preventDefault();slide(d,!d.open)});`+
+			`var sync=function(){if(s)s.setAttribute('aria-expanded',String(d.open));if(!d.open)return;`+
+			`var b=body(d);if(lazy[i]&&b&&!b.dataset.loaded){b.dataset.loaded='1';lazy[i]()}`+
+			`if(single)ds.forEach(function(o){if(o!==d&&o.open)slide(o,false)})};`+
+			`d.addEventListener('toggle',sync);if(d.open)sync()});`,
+		!a.multiple, strings.Join(lazy, ","),
+	))
 
 	return wrapper
 }
@@ -508,6 +650,19 @@ func (a *AlertBuilder) Build() *Node {
 	return container
 }
 
+// Alert is a shortcut for a status banner: variant is "info", "success",
+// "warning" or "error", the variants Notify uses for toasts.
+//
+//	ui.Alert("warning", "Heads up", "Your trial ends in 3 days.")
+func Alert(variant, title, body string) *Node {
+	return NewAlert().Variant(variant).Title(title).Message(body).Build()
+}
+
+// DismissibleAlert is Alert with a close button.
+func DismissibleAlert(variant, title, body string) *Node {
+	return NewAlert().Variant(variant).Title(title).Message(body).Dismissible(true).Build()
+}
+
 // ---------------------------------------------------------------------------
 // 7. Badge Builder
 // ---------------------------------------------------------------------------
@@ -639,6 +794,23 @@ func (b *BadgeBuilder) Build() *Node {
 	return node
 }
 
+// badgeVariants maps the status variants shared with Alert and Notify to
+// badge colors.
+var badgeVariants = map[string]string{
+	"info": "blue", "success": "green", "warning": "yellow", "error": "red",
+}
+
+// Badge is a shortcut for a status pill: variant is "info", "success",
+// "warning" or "error", or any color accepted by BadgeBuilder.Color.
+//
+//	ui.Badge("Active", "success")
+func Badge(text, variant string) *Node {
+	if c, ok := badgeVariants[variant]; ok {
+		variant = c
+	}
+	return NewBadge(text).Color(variant).Build()
+}
+
 // ---------------------------------------------------------------------------
 // 8. Button (High-Level) Builder
 // ---------------------------------------------------------------------------
@@ -960,7 +1132,7 @@ func ConfirmDialog(title, message string, confirmAction *Action, opts ...Confirm
 	)
 
 	overlay.Render(inner)
-	overlay.JS(fmt.Sprintf(`(function(){var o=document.getElementById('%s'),c=document.getElementById('%s');if(!o)return;if(c)c.focus();o.addEventListener('keydown',function(e){if(e.key==='Escape'){o.remove();return}if(e.key==='Tab'){var f=o.querySelectorAll('button,[href],input,select,textarea,[tabindex]:not([tabindex="-1"])');if(!f.length)return;var a=f[0],z=f[f.length-1];if(e.shiftKey&&document.activeElement===a){e.preventDefault();z.focus()}else if(!e.shiftKey&&document.activeElement===z){e.preventDefault();a.focus()}}})})();`, escJS(overlayID), escJS(cancelID)))
+	overlay.JS(focusTrapJS + `var o=this;__gsuiTrap(o);o.addEventListener('keydown',function(e){if(e.key==='Escape')o.remove()});`)
 	return overlay
 }
 
@@ -1103,16 +1275,21 @@ func (d *DropdownBuilder) Build() *Node {
 
 	wrapper.Render(menu)
 
+	// The document listeners remove themselves once the dropdown has been
+	// swapped out, so repeated renders do not stack them.
 	wrapper.JS(fmt.Sprintf(
 		`(function(){`+
 			`var w=document.getElementById('%s');`+
 			`var m=document.getElementById('%s');`+
-			`document.addEventListener('click',function(e){`+
-			`if(!w.contains(e.target)){m.classList.add('hidden')}`+
-			`},true);`+
-			`document.addEventListener('keydown',function(e){`+
-			`if(e.key==='Escape'){m.classList.add('hidden')}`+
-			`});`+
+			`var gone=function(){if(w.isConnected)return false;document.removeEventListener('click',onClick,true);document.removeEventListener('keydown',onKey);return true};`+
+			`var onClick=function(e){`+
+			`if(gone())return;if(!w.contains(e.target)){m.classList.add('hidden')}`+
+			`};`+
+			`var onKey=function(e){`+
+			`if(gone())return;if(e.key==='Escape'){m.classList.add('hidden')}`+
+			`};`+
+			`document.addEventListener('click',onClick,true);`+
+			`document.addEventListener('keydown',onKey);`+
 			`})();`,
 		escJS(wrapperID), escJS(menuID),
 	))
@@ -1136,6 +1313,7 @@ type ProgressBuilder struct {
 	label         string
 	labelPos      string
 	class         string
+	id            string
 }
 
 // NewProgress creates a new ProgressBuilder with default settings.
@@ -1185,6 +1363,44 @@ func (p *ProgressBuilder) LabelPosition(pos string) *ProgressBuilder { p.labelPo
 // ProgressClass appends additional CSS classes to the outer wrapper.
 func (p *ProgressBuilder) ProgressClass(cls string) *ProgressBuilder { p.class = cls; return p }
 
+// ProgressID sets the wrapper ID so the bar can be updated with SetProgress
+// or Context.PatchProgress.
+func (p *ProgressBuilder) ProgressID(id string) *ProgressBuilder { p.id = id; return p }
+
+// progressPercent converts value out of total to a whole percentage in 0-100.
+func progressPercent(value, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	return min(100, max(0, value*100/total))
+}
+
+// SetProgress returns JS that moves the progress bar with ID id to value
+// out of total. Only the bar's extent, its value label and aria-valuenow
+// change, so striped and animated bars keep their look; an indeterminate
+// bar stops bouncing and becomes determinate.
+func SetProgress(id string, value, total int) string {
+	pct := progressPercent(value, total)
+	return fmt.Sprintf("(function(){var w=document.getElementById('%s');if(!w)return;"+
+		"w.setAttribute('aria-valuenow','%d');"+
+		"var b=w.querySelector('[data-progress-bar]');if(b){if(b.hasAttribute('data-progress-indeterminate')){b.removeAttribute('data-progress-indeterminate');b.style.animation=''}b.style.transform='scaleX(%.4f)'}"+
+		"var v=w.querySelector('[data-progress-value]');if(v)v.textContent='%d%%'})();",
+		escJS(id), pct, float64(pct)/100, pct)
+}
+
+// PatchProgress pushes a progress update to the bar with ID id, for long
+// tasks that report as they go:
+//
+//	for i, row := range rows {
+//	    importRow(row)
+//	    if err := ctx.PatchProgress("import", i+1, len(rows)); err != nil {
+//	        return "" // client left
+//	    }
+//	}
+func (ctx *Context) PatchProgress(id string, value, total int) error {
+	return ctx.Push(SetProgress(id, value, total))
+}
+
 func progressHeight(size string) string {
 	switch size {
 	case "xs":
@@ -1208,13 +1424,23 @@ func (p *ProgressBuilder) Build() *Node {
 	if p.class != "" {
 		wrapCls += " " + p.class
 	}
-	wrapper := Div(wrapCls)
+	wrapper := Div(wrapCls).Attr("role", "progressbar").
+		Attr("aria-valuemin", "0").Attr("aria-valuemax", "100")
+	if p.id != "" {
+		wrapper.ID(p.id)
+	}
+	if !p.indeterminate {
+		wrapper.Attr("aria-valuenow", fmt.Sprintf("%d", p.value))
+	}
+	if p.label != "" {
+		wrapper.Attr("aria-label", p.label)
+	}
 
 	if p.label != "" && p.labelPos == "outside" {
 		wrapper.Render(
 			Div("flex justify-between items-center mb-1").Render(
 				Span("text-sm font-medium text-gray-700 dark:text-gray-300").Text(p.label),
-				Span("text-sm font-medium text-gray-700 dark:text-gray-300").Text(fmt.Sprintf("%d%%", p.value)),
+				Span("text-sm font-medium text-gray-700 dark:text-gray-300").Attr("data-progress-value", "").Text(fmt.Sprintf("%d%%", p.value)),
 			),
 		)
 	}
@@ -1232,7 +1458,7 @@ func (p *ProgressBuilder) Build() *Node {
 		barCls += " " + p.color
 	}
 
-	bar := Div(barCls).Style("transform", "scaleX("+barScale+")")
+	bar := Div(barCls).Attr("data-progress-bar", "").Style("transform", "scaleX("+barScale+")")
 
 	if len(p.gradient) > 0 {
 		bar.Style("background", "linear-gradient(90deg, "+strings.Join(p.gradient, ", ")+")")
@@ -1261,7 +1487,7 @@ func (p *ProgressBuilder) Build() *Node {
 		animID := Target()
 		css := fmt.Sprintf("@keyframes %s{0%%{transform:translateX(-100%%) scaleX(0.33)}50%%{transform:translateX(200%%) scaleX(0.33)}100%%{transform:translateX(-100%%) scaleX(0.33)}}", animID)
 		wrapper.Render(El("style").Text(css))
-		bar.Style("animation", animID+" 1.5s ease-in-out infinite")
+		bar.Attr("data-progress-indeterminate", "").Style("animation", animID+" 1.5s ease-in-out infinite")
 	}
 
 	wrapper.Render(container)
@@ -1372,6 +1598,7 @@ type tabItem struct {
 	label   string
 	content *Node
 	icon    string
+	lazy    *Action
 }
 
 // TabsBuilder constructs a tabbed interface with multiple panels.
@@ -1380,6 +1607,8 @@ type TabsBuilder struct {
 	active   int
 	tabStyle string
 	class    string
+	id       string
+	remember string
 }
 
 // NewTabs creates a new TabsBuilder.
@@ -1397,6 +1626,39 @@ func (t *TabsBuilder) Tab(label string, content *Node, icon ...string) *TabsBuil
 	return t
 }
 
+// TabLazy adds a tab whose panel is loaded from the server the first time
+// it is shown. The action is called with its Data plus "Panel" (the panel's
+// DOM ID) and "Tab" (the tab index); the handler returns a swap into that
+// panel, typically content.ToJSInner(panel). A skeleton shows until then.
+//
+//	app.Action("tabs.orders", func(ctx *ui.Context) string {
+//	    var in struct{ Panel string }
+//	    ctx.Body(&in)
+//	    return ordersView().ToJSInner(in.Panel)
+//	})
+func (t *TabsBuilder) TabLazy(label string, action *Action, icon ...string) *TabsBuilder {
+	it := tabItem{label: label, lazy: action}
+	if len(icon) > 0 {
+		it.icon = icon[0]
+	}
+	t.tabs = append(t.tabs, it)
+	return t
+}
+
+// TabsID sets the container ID. Panels then get stable IDs (see
+// TabPanelID) so actions can target them directly.
+func (t *TabsBuilder) TabsID(id string) *TabsBuilder { t.id = id; return t }
+
+// TabsRemember keeps the selected tab in localStorage under key and
+// restores it on the next render, overriding Active.
+func (t *TabsBuilder) TabsRemember(key string) *TabsBuilder { t.remember = key; return t }
+
+// TabPanelID returns the DOM ID of panel index of the tabs built with
+// TabsID(tabsID).
+func TabPanelID(tabsID string, index int) string {
+	return fmt.Sprintf("%s-panel-%d", tabsID, index)
+}
+
 // Active sets the initially active tab index (0-based).
 func (t *TabsBuilder) Active(index int) *TabsBuilder { t.active = index; return t }
 
@@ -1408,7 +1670,10 @@ func (t *TabsBuilder) TabsClass(cls string) *TabsBuilder { t.class = cls; return
 
 // Build compiles the tabs into a *Node.
 func (t *TabsBuilder) Build() *Node {
-	containerID := Target()
+	containerID := t.id
+	if containerID == "" {
+		containerID = Target()
+	}
 
 	containerCls := ""
 	if t.tabStyle == "vertical" {
@@ -1441,8 +1706,13 @@ func (t *TabsBuilder) Build() *Node {
 	btnIDs := make([]string, len(t.tabs))
 	panelIDs := make([]string, len(t.tabs))
 	for i := range t.tabs {
-		btnIDs[i] = Target()
-		panelIDs[i] = Target()
+		if t.id != "" {
+			btnIDs[i] = fmt.Sprintf("%s-tab-%d", t.id, i)
+			panelIDs[i] = TabPanelID(t.id, i)
+		} else {
+			btnIDs[i] = Target()
+			panelIDs[i] = Target()
+		}
 	}
 
 	for i, tab := range t.tabs {
@@ -1481,6 +1751,9 @@ func (t *TabsBuilder) Build() *Node {
 			Attr("data-tab-index", fmt.Sprintf("%d", i)).
 			Attr("aria-selected", fmt.Sprintf("%t", isActive)).
 			Attr("aria-controls", panelIDs[i])
+		if !isActive {
+			btn.Attr("tabindex", "-1")
+		}
 
 		if tab.icon != "" {
 			btn.Render(Span("material-icons-round text-base mr-1.5 align-middle").Text(tab.icon))
@@ -1504,9 +1777,12 @@ func (t *TabsBuilder) Build() *Node {
 		}
 		panel := Div(panelCls).ID(panelIDs[i]).
 			Attr("role", "tabpanel").
+			Attr("aria-labelledby", btnIDs[i]).
 			Attr("data-tab-index", fmt.Sprintf("%d", i))
 		if tab.content != nil {
 			panel.Render(tab.content)
+		} else if tab.lazy != nil {
+			panel.Render(SkeletonComponent())
 		}
 		panelWrapper.Render(panel)
 	}
@@ -1532,6 +1808,13 @@ func (t *TabsBuilder) Build() *Node {
 		}
 		fmt.Fprintf(&js, "'%s'", escJS(id))
 	}
+	js.WriteString("];var lazy=[")
+	for i, tab := range t.tabs {
+		if i > 0 {
+			js.WriteString(",")
+		}
+		js.WriteString(lazyLoadJS(tab.lazy, panelIDs[i], "Tab", i))
+	}
 	js.WriteString("];")
 
 	var activeBtn, inactiveBtn string
@@ -1550,10 +1833,15 @@ func (t *TabsBuilder) Build() *Node {
 		inactiveBtn = "text-gray-600 dark:text-gray-400 border-b-2 border-transparent hover:text-gray-900 dark:hover:text-gray-200"
 	}
 
+	if t.remember != "" {
+		fmt.Fprintf(&js, "var key='gsui-tab:%s';", escJS(t.remember))
+	} else {
+		js.WriteString("var key='';")
+	}
 	fmt.Fprintf(&js, "var aCls='%s'.split(' ');", escJS(activeBtn))
 	fmt.Fprintf(&js, "var iCls='%s'.split(' ');", escJS(inactiveBtn))
 
-	js.WriteString("function activate(idx){btns.forEach(function(id,i){var b=document.getElementById(id);if(!b)return;var p=document.getElementById(pans[i]);if(i===idx){iCls.forEach(function(cl){if(cl)b.classList.remove(cl)});aCls.forEach(function(cl){if(cl)b.classList.add(cl)});b.setAttribute('aria-selected','true');if(p)p.classList.remove('hidden')}else{aCls.forEach(function(cl){if(cl)b.classList.remove(cl)});iCls.forEach(function(cl){if(cl)b.classList.add(cl)});b.setAttribute('aria-selected','false');if(p)p.classList.add('hidden')}});c.setAttribute('data-tabs-active',idx)}")
+	js.WriteString("function activate(idx){btns.forEach(function(id,i){var b=document.getElementById(id);if(!b)return;var p=document.getElementById(pans[i]);if(i===idx){iCls.forEach(function(cl){if(cl)b.classList.remove(cl)});aCls.forEach(function(cl){if(cl)b.classList.add(cl)});b.setAttribute('aria-selected','true');b.tabIndex=0;if(p)p.classList.remove('hidden');if(p&&lazy[i]&&!p.dataset.loaded){p.dataset.loaded='1';lazy[i]()}}else{aCls.forEach(function(cl){if(cl)b.classList.remove(cl)});iCls.forEach(function(cl){if(cl)b.classList.add(cl)});b.setAttribute('aria-selected','false');b.tabIndex=-1;if(p)p.classList.add('hidden')}});c.setAttribute('data-tabs-active',idx);if(key)try{localStorage.setItem(key,String(idx))}catch(_){}}")
 	js.WriteString("btns.forEach(function(id,i){var b=document.getElementById(id);if(!b)return;b.addEventListener('click',function(){activate(i)})});")
 
 	if t.tabStyle == "vertical" {
@@ -1562,12 +1850,43 @@ func (t *TabsBuilder) Build() *Node {
 		js.WriteString("c.addEventListener('keydown',function(e){var cur=parseInt(c.getAttribute('data-tabs-active'))||0;if(e.key==='ArrowRight'){e.preventDefault();activate((cur+1)%btns.length);document.getElementById(btns[(cur+1)%btns.length]).focus()}else if(e.key==='ArrowLeft'){e.preventDefault();activate((cur-1+btns.length)%btns.length);document.getElementById(btns[(cur-1+btns.length)%btns.length]).focus()}});")
 	}
 
+	// Restore the remembered tab, and load the initial panel if it is lazy
+	fmt.Fprintf(&js, "var start=%d;if(key){try{var sv=parseInt(localStorage.getItem(key),10);if(sv>=0&&sv<btns.length)start=sv}catch(_){}}activate(start);", t.active)
 	js.WriteString("})();")
 	container.JS(js.String())
 
 	return container
 }
 
+// lazyLoadJS returns the JS function that loads a lazy panel (tab or
+// accordion item) by calling action with the panel ID and the index under
+// indexKey, or "null" when action is nil.
+func lazyLoadJS(action *Action, panelID, indexKey string, index int) string {
+	if action == nil {
+		return "null"
+	}
+	if action.rawJS != "" {
+		return "function(){" + action.rawJS + "}"
+	}
+	data := map[string]any{}
+	for k, v := range action.Data {
+		data[k] = v
+	}
+	data["Panel"] = panelID
+	data[indexKey] = index
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		defaultLogger.Errorf("marshal lazy panel data: %v", err)
+		dataJSON = []byte("{}")
+	}
+	collectJSON, err := json.Marshal(action.Collect)
+	if err != nil {
+		defaultLogger.Errorf("marshal lazy panel collect: %v", err)
+		collectJSON = []byte("[]")
+	}
+	return fmt.Sprintf("function(){__ws.call('%s',%s,%s,{quiet:true})}", escJS(action.Name), dataJSON, collectJSON)
+}
+
 // ---------------------------------------------------------------------------
 // 15. Tooltip Builder
 // ---------------------------------------------------------------------------
@@ -1607,22 +1926,16 @@ func (t *TooltipBuilder) Wrap(trigger *Node) *Node {
 	wrapper := Div("relative inline-block group").ID(wrapperID)
 	wrapper.Render(trigger)
 
-	var posClass string
-	switch t.position {
-	case "bottom":
-		posClass = "top-full left-1/2 -translate-x-1/2 mt-2"
-	case "left":
-		posClass = "right-full top-1/2 -translate-y-1/2 mr-2"
-	case "right":
-		posClass = "left-full top-1/2 -translate-y-1/2 ml-2"
-	default:
-		posClass = "bottom-full left-1/2 -translate-x-1/2 mb-2"
+	side := t.position
+	if _, ok := tooltipPlacement[side]; !ok {
+		side = "top"
 	}
+	posClass := tooltipPlacement[side]
 
 	var variantClass string
 	switch t.variant {
 	case "light":
-		variantClass = "bg-white text-gray-900 border border-gray-200 shadow-sm"
+		variantClass = "bg-white text-gray-900 border border-gray-200 shadow-sm dark:bg-gray-800 dark:text-gray-100 dark:border-gray-700"
 	case "blue":
 		variantClass = "bg-blue-600 text-white"
 	case "green":
@@ -1648,9 +1961,30 @@ func (t *TooltipBuilder) Wrap(trigger *Node) *Node {
 	}
 
 	tooltip := Div(tooltipCls).ID(tooltipID).Attr("role", "tooltip").Text(t.content)
-	tooltip.Render(tooltipArrow(t.position, t.variant))
+	tooltip.Render(tooltipArrow(side))
 	wrapper.Render(tooltip)
 
+	// Flip to the opposite side when the preferred one leaves the viewport,
+	// and nudge top/bottom tooltips back inside horizontally.
+	opp := tooltipOpposite[side]
+	flip := fmt.Sprintf(
+		`(function(){`+
+			`var w=document.getElementById('%s'),tip=document.getElementById('%s'),ar=tip.lastChild;`+
+			`var P={'%s':['%s','%s'],'%s':['%s','%s']},cur='%s';`+
+			`function set(s){tip.classList.remove.apply(tip.classList,P[cur][0].split(' '));tip.classList.add.apply(tip.classList,P[s][0].split(' '));ar.style.cssText=P[s][1];cur=s}`+
+			`function out(s,r){return s==='top'?r.top<0:s==='bottom'?r.bottom>innerHeight:s==='left'?r.left<0:r.right>innerWidth}`+
+			`function place(){set('%s');tip.style.marginLeft='';`+
+			`if(out(cur,tip.getBoundingClientRect())){set('%s');if(out(cur,tip.getBoundingClientRect()))set('%s')}`+
+			`if(cur==='top'||cur==='bottom'){var r=tip.getBoundingClientRect();`+
+			`if(r.left<4)tip.style.marginLeft=(4-r.left)+'px';else if(r.right>innerWidth-4)tip.style.marginLeft=(innerWidth-4-r.right)+'px'}}`+
+			`w.addEventListener('mouseenter',place);w.addEventListener('focusin',place);`+
+			`})();`,
+		escJS(wrapperID), escJS(tooltipID),
+		side, tooltipPlacement[side], escJS(tooltipArrowCSS(side)),
+		opp, tooltipPlacement[opp], escJS(tooltipArrowCSS(opp)),
+		side, side, opp, side,
+	)
+
 	if t.delay > 0 {
 		wrapper.JS(fmt.Sprintf(
 			`(function(){`+
@@ -1670,12 +2004,24 @@ func (t *TooltipBuilder) Wrap(trigger *Node) *Node {
 				`});w.addEventListener('focusout',function(){clearTimeout(timer);tip.classList.add('opacity-0','invisible')});`+
 				`})();`,
 			escJS(wrapperID), escJS(tooltipID), t.delay, t.delay,
-		))
+		) + flip)
+	} else {
+		wrapper.JS(flip)
 	}
 
 	return wrapper
 }
 
+// tooltipPlacement positions a tooltip on each side of its trigger.
+var tooltipPlacement = map[string]string{
+	"top":    "bottom-full left-1/2 -translate-x-1/2 mb-2",
+	"bottom": "top-full left-1/2 -translate-x-1/2 mt-2",
+	"left":   "right-full top-1/2 -translate-y-1/2 mr-2",
+	"right":  "left-full top-1/2 -translate-y-1/2 ml-2",
+}
+
+var tooltipOpposite = map[string]string{"top": "bottom", "bottom": "top", "left": "right", "right": "left"}
+
 // ---------------------------------------------------------------------------
 // 16. Theme Switcher
 // ---------------------------------------------------------------------------
@@ -1716,7 +2062,7 @@ func ThemeSwitcher(opts ...ThemeSwitcherOpt) *Node {
 	}
 
 	btn := Button(baseCls).ID(btnID).Render(
-		I("material-icons-round text-base").ID(btnID+"-icon").Text("brightness_auto"),
+		I("material-icons-round text-base").ID(btnID+"-icon").Style("color", "var(--gsui-primary,currentColor)").Text("brightness_auto"),
 		Span().ID(btnID+"-label").Text(loc.ThemeAuto),
 	)
 
@@ -1744,57 +2090,919 @@ func ThemeSwitcher(opts ...ThemeSwitcherOpt) *Node {
 	return btn.JS(js)
 }
 
-// tooltipArrow builds the CSS triangle arrow for a tooltip.
-func tooltipArrow(position, variant string) *Node {
+// tooltipArrow builds the arrow of a tooltip: a small rotated square that
+// inherits the tooltip's background, so it follows the variant and theme.
+func tooltipArrow(position string) *Node {
 	arrow := Div()
-	arrow.Style("position", "absolute")
-	arrow.Style("width", "0")
-	arrow.Style("height", "0")
+	for _, st := range tooltipArrowStyles(position) {
+		arrow.Style(st[0], st[1])
+	}
+	return arrow
+}
 
-	color := "#111827"
-	switch variant {
-	case "light":
-		color = "white"
-	case "blue":
-		color = "#2563eb"
-	case "green":
-		color = "#16a34a"
-	case "red":
-		color = "#dc2626"
-	case "yellow":
-		color = "#eab308"
+// tooltipArrowCSS is the arrow style as cssText, for flipping on the client.
+func tooltipArrowCSS(position string) string {
+	var b strings.Builder
+	for _, st := range tooltipArrowStyles(position) {
+		for _, r := range st[0] {
+			if r >= 'A' && r <= 'Z' {
+				b.WriteByte('-')
+				r += 'a' - 'A'
+			}
+			b.WriteRune(r)
+		}
+		b.WriteString(":" + st[1] + ";")
 	}
+	return b.String()
+}
 
+// tooltipArrowStyles lists the arrow's style properties for a side.
+func tooltipArrowStyles(position string) [][2]string {
+	st := [][2]string{
+		{"position", "absolute"}, {"width", "8px"}, {"height", "8px"},
+		{"background", "inherit"}, {"zIndex", "-1"},
+	}
 	switch position {
 	case "bottom":
-		arrow.Style("top", "-4px")
-		arrow.Style("left", "50%")
-		arrow.Style("transform", "translateX(-50%)")
-		arrow.Style("borderLeft", "4px solid transparent")
-		arrow.Style("borderRight", "4px solid transparent")
-		arrow.Style("borderBottom", "4px solid "+color)
+		return append(st, [2]string{"top", "-4px"}, [2]string{"left", "50%"}, [2]string{"transform", "translateX(-50%) rotate(45deg)"})
 	case "left":
-		arrow.Style("right", "-4px")
-		arrow.Style("top", "50%")
-		arrow.Style("transform", "translateY(-50%)")
-		arrow.Style("borderTop", "4px solid transparent")
-		arrow.Style("borderBottom", "4px solid transparent")
-		arrow.Style("borderLeft", "4px solid "+color)
+		return append(st, [2]string{"right", "-4px"}, [2]string{"top", "50%"}, [2]string{"transform", "translateY(-50%) rotate(45deg)"})
 	case "right":
-		arrow.Style("left", "-4px")
-		arrow.Style("top", "50%")
-		arrow.Style("transform", "translateY(-50%)")
-		arrow.Style("borderTop", "4px solid transparent")
-		arrow.Style("borderBottom", "4px solid transparent")
-		arrow.Style("borderRight", "4px solid "+color)
+		return append(st, [2]string{"left", "-4px"}, [2]string{"top", "50%"}, [2]string{"transform", "translateY(-50%) rotate(45deg)"})
 	default:
-		arrow.Style("bottom", "-4px")
-		arrow.Style("left", "50%")
-		arrow.Style("transform", "translateX(-50%)")
-		arrow.Style("borderLeft", "4px solid transparent")
-		arrow.Style("borderRight", "4px solid transparent")
-		arrow.Style("borderTop", "4px solid "+color)
+		return append(st, [2]string{"bottom", "-4px"}, [2]string{"left", "50%"}, [2]string{"transform", "translateX(-50%) rotate(45deg)"})
 	}
+}
 
-	return arrow
+// ---------------------------------------------------------------------------
+// 17. Input Mask
+// ---------------------------------------------------------------------------
+
+// Mask formats the input's value client-side as the user types. In the
+// pattern '#' accepts a digit, 'A' a letter and '*' a letter or digit; every
+// other character is a literal inserted automatically, e.g. "(###) ###-####"
+// or "####-####-####-####". Literals are stripped before the value is
+// collected for an action or a FormBuilder submit, so handlers receive only
+// the raw characters ("5551234567").
+//
+// The formatter handles pasted text, keeps the caret after the character
+// that was typed, and lets Backspace step over literals by deleting the
+// preceding raw character.
+func (n *Node) Mask(pattern string) *Node {
+	if pattern == "" {
+		return n
+	}
+	n.Attr("data-mask", pattern)
+	if !strings.ContainsAny(pattern, "A*") {
+		if _, ok := n.attrs["inputmode"]; !ok {
+			n.Attr("inputmode", "numeric")
+		}
+	}
+	if _, ok := n.attrs["maxlength"]; !ok {
+		n.Attr("maxlength", strconv.Itoa(len([]rune(pattern))))
+	}
+	js := maskJS(pattern)
+	if n.rawJS != "" {
+		js = n.rawJS + ";" + js
+	}
+	n.rawJS = js
+	return n
+}
+
+// maskJS returns the post-mount script behind Mask. fmt(v,echo) lays v out
+// over the pattern and returns the formatted text plus the raw characters it
+// consumed; with echo set, literals already present in v are skipped so that
+// re-formatting a formatted value is stable.
+func maskJS(pattern string) string {
+	return fmt.Sprintf(`var el=this,p=Array.from('%s');`+
+		`function tk(c){return c==='#'||c==='A'||c==='*'}`+
+		`function ok(t,c){return t==='#'?/[0-9]/.test(c):t==='A'?/[A-Za-z]/.test(c):/[A-Za-z0-9]/.test(c)}`+
+		`function fmt(v,echo){v=Array.from(v);var out='',r='',end=0,j=0;`+
+		`for(var i=0;i<p.length&&j<v.length;i++){var t=p[i];`+
+		`if(tk(t)){while(j<v.length&&!ok(t,v[j]))j++;if(j<v.length){out+=v[j];r+=v[j];j++;end=out.length}}`+
+		`else{out+=t;if(echo&&v[j]===t)j++}}`+
+		`return{v:out.slice(0,end),r:r}}`+
+		`function at(v,n){if(!n)return 0;var c=0;for(var i=0;i<v.length;i++){if(tk(p[i])&&++c===n)return i+1}return v.length}`+
+		`function apply(raw,n){var f=fmt(raw,false);el.value=f.v;el.dataset.raw=f.r;`+
+		`if(document.activeElement===el){var c=at(f.v,n);try{el.setSelectionRange(c,c)}catch(_){}}}`+
+		`function caret(){return el.selectionStart==null?el.value.length:el.selectionStart}`+
+		`el.addEventListener('input',function(){var n=fmt(el.value.slice(0,caret()),true).r.length;apply(fmt(el.value,true).r,n)});`+
+		`el.addEventListener('keydown',function(e){if(e.key!=='Backspace'||el.selectionStart!==el.selectionEnd)return;`+
+		`var c=caret();if(!c||tk(p[c-1]))return;e.preventDefault();`+
+		`var r=fmt(el.value,true).r,n=fmt(el.value.slice(0,c),true).r.length;if(n)apply(r.slice(0,n-1)+r.slice(n),n-1)});`+
+		`apply(fmt(el.value,true).r,0);`,
+		escJS(pattern))
+}
+
+// ---------------------------------------------------------------------------
+// 18. Pager
+// ---------------------------------------------------------------------------
+
+// Paging is the payload a Pager sends to its action: the requested 1-based
+// page and the page size. Read it with ctx.Body(&paging).
+type Paging struct {
+	Page int
+	Size int
+}
+
+// Offset returns the zero-based index of the first item on the page,
+// suitable for SQL OFFSET or slicing.
+func (p Paging) Offset() int {
+	if p.Page < 1 || p.Size < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.Size
+}
+
+// PagerLocale holds translatable strings for Pager.
+type PagerLocale struct {
+	First   string
+	Prev    string
+	Next    string
+	Last    string
+	PerPage string // label of the page-size selector
+	// Summary formats "1–10 of 42" — receives (from, to, total).
+	Summary func(from, to, total int) string
+}
+
+// PagerBuilder renders first/prev/numbered/next/last page buttons and an
+// optional page-size selector. Every control calls the pager's action with
+// a Paging payload; the handler reloads the data and swaps the table region.
+type PagerBuilder struct {
+	total  int
+	page   int
+	size   int
+	action string
+	sizes  []int
+	window int
+	class  string
+	locale *PagerLocale
+}
+
+// NewPager creates a pager for total items, showing page (1-based) with
+// size items per page.
+func NewPager(total, page, size int) *PagerBuilder {
+	return &PagerBuilder{total: total, page: page, size: size, window: 1}
+}
+
+// PagerAction sets the WS action called with a Paging payload.
+func (p *PagerBuilder) PagerAction(name string) *PagerBuilder { p.action = name; return p }
+
+// PagerSizes enables the page-size selector with the given choices; the
+// current size is added when it is not one of them.
+// Changing the size jumps back to page 1.
+func (p *PagerBuilder) PagerSizes(sizes ...int) *PagerBuilder { p.sizes = sizes; return p }
+
+// PagerWindow sets how many numbered pages are shown on each side of the
+// current one (default 1). Pages outside the window collapse into "…".
+func (p *PagerBuilder) PagerWindow(n int) *PagerBuilder { p.window = max(n, 0); return p }
+
+// PagerClass overrides the wrapper CSS classes.
+func (p *PagerBuilder) PagerClass(cls string) *PagerBuilder { p.class = cls; return p }
+
+// Locale sets a per-instance locale.
+func (p *PagerBuilder) Locale(l *PagerLocale) *PagerBuilder {
+	p.locale = l
+	return p
+}
+
+func (p *PagerBuilder) loc() *PagerLocale {
+	l := PagerLocale{
+		First:   "First",
+		Prev:    "Previous",
+		Next:    "Next",
+		Last:    "Last",
+		PerPage: "Per page",
+		Summary: func(from, to, total int) string { return fmt.Sprintf("%d–%d of %d", from, to, total) },
+	}
+	if p.locale == nil {
+		return &l
+	}
+	if p.locale.First != "" {
+		l.First = p.locale.First
+	}
+	if p.locale.Prev != "" {
+		l.Prev = p.locale.Prev
+	}
+	if p.locale.Next != "" {
+		l.Next = p.locale.Next
+	}
+	if p.locale.Last != "" {
+		l.Last = p.locale.Last
+	}
+	if p.locale.PerPage != "" {
+		l.PerPage = p.locale.PerPage
+	}
+	if p.locale.Summary != nil {
+		l.Summary = p.locale.Summary
+	}
+	return &l
+}
+
+// pagerWindow lists the page numbers to render, with 0 marking an ellipsis.
+// The first and last pages are always present; a gap of a single page is
+// filled with that page instead of an ellipsis.
+func pagerWindow(page, pages, window int) []int {
+	var out []int
+	prev := 0
+	for i := 1; i <= pages; i++ {
+		if i != 1 && i != pages && (i < page-window || i > page+window) {
+			continue
+		}
+		if prev > 0 && i-prev == 2 {
+			out = append(out, i-1)
+		} else if prev > 0 && i-prev > 2 {
+			out = append(out, 0)
+		}
+		out = append(out, i)
+		prev = i
+	}
+	return out
+}
+
+func (p *PagerBuilder) button(label, aria string, target, size int, disabled, current bool) *Node {
+	cls := "min-w-8 h-8 px-2 inline-flex items-center justify-center rounded-md text-sm border " +
+		"border-gray-300 dark:border-gray-600 text-gray-700 dark:text-gray-300 bg-white dark:bg-gray-800 " +
+		"hover:bg-gray-50 dark:hover:bg-gray-700 focus:outline-none focus-visible:ring-2 focus-visible:ring-blue-500 " +
+		"disabled:opacity-40 disabled:cursor-not-allowed disabled:hover:bg-white dark:disabled:hover:bg-gray-800 cursor-pointer"
+	if current {
+		cls = "min-w-8 h-8 px-2 inline-flex items-center justify-center rounded-md text-sm border " +
+			"border-blue-600 bg-blue-600 text-white dark:border-blue-500 dark:bg-blue-500 cursor-default"
+	}
+	btn := Button(cls).Attr("type", "button").Attr("aria-label", aria).Text(label)
+	switch {
+	case current:
+		btn.Attr("aria-current", "page")
+	case disabled:
+		btn.Attr("disabled", "true")
+	case p.action != "":
+		btn.OnClick(&Action{Name: p.action, Data: map[string]any{"Page": target, "Size": size}})
+	}
+	return btn
+}
+
+// Build compiles the pager into a *Node.
+func (p *PagerBuilder) Build() *Node {
+	l := p.loc()
+	size := max(p.size, 1)
+	pages := max((p.total+size-1)/size, 1)
+	page := min(max(p.page, 1), pages)
+
+	cls := "flex flex-wrap items-center justify-between gap-3 text-sm text-gray-600 dark:text-gray-400"
+	if p.class != "" {
+		cls = p.class
+	}
+
+	from, to := 0, 0
+	if p.total > 0 {
+		from = (page-1)*size + 1
+		to = min(page*size, p.total)
+	}
+
+	nav := Nav("flex items-center gap-1").Attr("aria-label", "Pagination")
+	nav.Render(
+		p.button("«", l.First, 1, size, page == 1, false),
+		p.button("‹", l.Prev, page-1, size, page == 1, false),
+	)
+	for _, n := range pagerWindow(page, pages, p.window) {
+		if n == 0 {
+			nav.Render(Span("px-1 select-none text-gray-400 dark:text-gray-500").Attr("aria-hidden", "true").Text("…"))
+			continue
+		}
+		nav.Render(p.button(fmt.Sprintf("%d", n), fmt.Sprintf("%d", n), n, size, false, n == page))
+	}
+	nav.Render(
+		p.button("›", l.Next, page+1, size, page == pages, false),
+		p.button("»", l.Last, pages, size, page == pages, false),
+	)
+
+	right := Div("flex items-center gap-3").Render(Span().Text(l.Summary(from, to, p.total)))
+	if len(p.sizes) > 0 {
+		sel := Select("h-8 rounded-md border border-gray-300 dark:border-gray-600 bg-white dark:bg-gray-800 "+
+			"text-gray-700 dark:text-gray-300 px-2 text-sm focus:outline-none focus-visible:ring-2 focus-visible:ring-blue-500").
+			Attr("aria-label", l.PerPage)
+		// A size outside the choices is listed too, so the select shows
+		// the size the page is using.
+		sizes := p.sizes
+		if !slices.Contains(sizes, size) {
+			sizes = append(slices.Clone(sizes), size)
+			slices.Sort(sizes)
+		}
+		for _, s := range sizes {
+			opt := Option().Attr("value", fmt.Sprintf("%d", s)).Text(fmt.Sprintf("%d", s))
+			if s == size {
+				opt.Attr("selected", "true")
+			}
+			sel.Render(opt)
+		}
+		if p.action != "" {
+			sel.On("change", JS(fmt.Sprintf(
+				"__ws.call('%s',{Page:1,Size:parseInt(event.currentTarget.value,10)})", escJS(p.action))))
+		}
+		right.Render(Label("flex items-center gap-2").Render(Span().Text(l.PerPage), sel))
+	}
+
+	return Div(cls).Render(nav, right)
+}
+
+// ---------------------------------------------------------------------------
+// 19. Polling
+// ---------------------------------------------------------------------------
+
+// Poll calls action every interval while the node is in the DOM, for
+// near-live data without server pushes. The handler typically re-renders
+// the node and returns a swap (e.g. ToJSReplace with the node's ID); the
+// new node starts its own timer and the old one stops once it has left the
+// DOM. Ticks are skipped while the tab is hidden or the WebSocket is
+// offline, so background tabs and reconnects do not pile up calls. Calls do
+// not show the page loader.
+//
+//	ui.Div().ID("stats").Render(statsView()).
+//	    Poll(5*time.Second, &ui.Action{Name: "stats.refresh"})
+func (n *Node) Poll(every time.Duration, action *Action) *Node {
+	if action == nil || every <= 0 {
+		return n
+	}
+	var call string
+	if action.rawJS != "" {
+		call = action.rawJS
+	} else {
+		dataJSON, err := json.Marshal(action.Data)
+		if err != nil {
+			defaultLogger.Errorf("marshal poll data: %v", err)
+			dataJSON = []byte("{}")
+		}
+		collectJSON, err := json.Marshal(action.Collect)
+		if err != nil {
+			defaultLogger.Errorf("marshal poll collect: %v", err)
+			collectJSON = []byte("[]")
+		}
+		call = fmt.Sprintf("__ws.call('%s',%s,%s,{quiet:true})", escJS(action.Name), dataJSON, collectJSON)
+	}
+	js := fmt.Sprintf("var el=this,t=setInterval(function(){"+
+		"if(!el.isConnected){clearInterval(t);return}"+
+		"if(document.hidden||!window.__ws||!__ws.connected||!__ws.connected())return;"+
+		"%s},%d);", call, every.Milliseconds())
+	if n.rawJS != "" {
+		js = n.rawJS + ";" + js
+	}
+	n.rawJS = js
+	return n
 }
+
+// ---------------------------------------------------------------------------
+// 20. Modal
+// ---------------------------------------------------------------------------
+
+// ModalBuilder constructs an accessible modal dialog: a backdrop overlay
+// with a focus-trapped panel that closes on Escape, backdrop click, or the
+// close button. The modal is rendered hidden (unless ModalOpen is set) and
+// toggled with OpenModal and CloseModal. Swap new content into the body
+// from an action with ToJSInner(ModalBodyID(id)).
+type ModalBuilder struct {
+	id          string
+	title       string
+	body        *Node
+	actions     []*Node
+	size        string
+	dismissible bool
+	open        bool
+	class       string
+}
+
+// NewModal creates a ModalBuilder. id identifies the modal for OpenModal,
+// CloseModal and ModalBodyID.
+func NewModal(id string) *ModalBuilder {
+	return &ModalBuilder{id: id, size: "md", dismissible: true}
+}
+
+// ModalTitle sets the heading, also used as the dialog's accessible name.
+func (m *ModalBuilder) ModalTitle(t string) *ModalBuilder { m.title = t; return m }
+
+// ModalBody sets the panel content.
+func (m *ModalBuilder) ModalBody(n *Node) *ModalBuilder { m.body = n; return m }
+
+// ModalActions sets the footer buttons, right-aligned.
+func (m *ModalBuilder) ModalActions(nodes ...*Node) *ModalBuilder { m.actions = nodes; return m }
+
+// ModalSize sets the panel width: "sm", "md" (default), "lg", "xl", "full".
+func (m *ModalBuilder) ModalSize(s string) *ModalBuilder { m.size = s; return m }
+
+// Dismissible controls the close button, Escape and backdrop click
+// (default true). Non-dismissible modals close only via CloseModal.
+func (m *ModalBuilder) Dismissible(d bool) *ModalBuilder { m.dismissible = d; return m }
+
+// ModalOpen renders the modal already open.
+func (m *ModalBuilder) ModalOpen(o bool) *ModalBuilder { m.open = o; return m }
+
+// ModalClass appends additional CSS classes to the panel.
+func (m *ModalBuilder) ModalClass(cls string) *ModalBuilder { m.class = cls; return m }
+
+// ModalBodyID returns the ID of the body container of modal id, the target
+// for swapping in server-rendered content.
+func ModalBodyID(id string) string { return id + "-body" }
+
+// OpenModal returns JS that opens modal id, moving focus into it.
+func OpenModal(id string) string {
+	return fmt.Sprintf("(function(){var m=document.getElementById('%s');if(m&&m.__gsuiOpen)m.__gsuiOpen()})();", escJS(id))
+}
+
+// CloseModal returns JS that closes modal id and returns focus to the
+// element that opened it.
+func CloseModal(id string) string {
+	return fmt.Sprintf("(function(){var m=document.getElementById('%s');if(m&&m.__gsuiClose)m.__gsuiClose()})();", escJS(id))
+}
+
+func modalWidth(size string) string {
+	switch size {
+	case "sm":
+		return "max-w-sm"
+	case "lg":
+		return "max-w-2xl"
+	case "xl":
+		return "max-w-4xl"
+	case "full":
+		return "max-w-[calc(100vw-2rem)] h-[calc(100vh-2rem)]"
+	default:
+		return "max-w-lg"
+	}
+}
+
+// Build compiles the modal into a *Node.
+func (m *ModalBuilder) Build() *Node {
+	titleID := m.id + "-title"
+
+	overlayCls := "fixed inset-0 z-[10000] flex items-center justify-center bg-black/50 dark:bg-black/60 backdrop-blur-sm p-4"
+	if !m.open {
+		overlayCls += " hidden"
+	}
+	overlay := Div(overlayCls).ID(m.id).
+		Attr("role", "dialog").Attr("aria-modal", "true").
+		Attr("aria-hidden", fmt.Sprintf("%t", !m.open))
+	if m.title != "" {
+		overlay.Attr("aria-labelledby", titleID)
+	}
+
+	panelCls := "bg-white dark:bg-gray-900 text-gray-900 dark:text-gray-100 rounded-xl shadow-2xl ring-1 ring-black/5 dark:ring-white/10 w-full flex flex-col max-h-full " + modalWidth(m.size)
+	if m.class != "" {
+		panelCls += " " + m.class
+	}
+	panel := Div(panelCls).Attr("tabindex", "-1").Attr("data-modal-panel", "")
+
+	if m.title != "" || m.dismissible {
+		header := Div("flex items-center justify-between gap-4 px-6 pt-5")
+		header.Render(H3("text-lg font-semibold").ID(titleID).Text(m.title))
+		if m.dismissible {
+			header.Render(
+				Button("p-1 rounded-lg text-gray-500 hover:text-gray-900 hover:bg-gray-100 dark:hover:text-white dark:hover:bg-gray-800 cursor-pointer focus:outline-none focus-visible:ring-2 focus-visible:ring-blue-500").
+					Attr("type", "button").Attr("aria-label", "Close").
+					OnClick(JS(CloseModal(m.id))).
+					Render(Span("material-icons-round text-xl leading-none").Attr("aria-hidden", "true").Text("close")),
+			)
+		}
+		panel.Render(header)
+	}
+
+	body := Div("px-6 py-4 overflow-y-auto text-sm text-gray-700 dark:text-gray-300").ID(ModalBodyID(m.id))
+	if m.body != nil {
+		body.Render(m.body)
+	}
+	panel.Render(body)
+
+	if len(m.actions) > 0 {
+		panel.Render(Div("flex justify-end gap-3 px-6 pb-5 pt-2").Render(m.actions...))
+	}
+	overlay.Render(panel)
+
+	overlay.JS(fmt.Sprintf(focusTrapJS+`var o=this,dismiss=%t,release=null;`+
+		`var onKey=function(e){if(!o.isConnected){document.removeEventListener('keydown',onKey);document.body.style.overflow='';return}`+
+		`if(e.key==='Escape'&&dismiss){e.preventDefault();o.__gsuiClose()}};`+
+		`o.__gsuiOpen=function(){if(!o.classList.contains('hidden'))return;o.classList.remove('hidden');o.setAttribute('aria-hidden','false');`+
+		`document.body.style.overflow='hidden';document.addEventListener('keydown',onKey);`+
+		`release=__gsuiTrap(o,o.querySelector('[data-modal-panel]'))};`+
+		`o.__gsuiClose=function(){if(o.classList.contains('hidden'))return;o.classList.add('hidden');o.setAttribute('aria-hidden','true');`+
+		`document.body.style.overflow='';document.removeEventListener('keydown',onKey);`+
+		`if(release)release();release=null};`+
+		`o.addEventListener('mousedown',function(e){if(dismiss&&e.target===o)o.__gsuiClose()});`+
+		`if(!o.classList.contains('hidden')){o.classList.add('hidden');o.__gsuiOpen()}`, m.dismissible))
+	return overlay
+}
+
+// ---------------------------------------------------------------------------
+// 21. Money Input
+// ---------------------------------------------------------------------------
+
+// Money turns a text-like input into an amount field for currency (an ISO
+// 4217 code such as "EUR"). While focused it shows the number without
+// grouping; on blur it is formatted for the browser's locale ("€1,234.50").
+// Users type the locale's separators, so "1.500" is 1500 in German and 1.5
+// in English. The value collected for actions and FormBuilder submits is
+// the normalized amount ("1234.50", '.' as separator, typed digits kept
+// exactly), ready for a Decimal field in ctx.Body; it follows every
+// keystroke, so Enter submits what is typed. A value set server-side is
+// read in the normalized form.
+//
+//	ui.INumber("border rounded px-2").Attr("name", "Price").Money("EUR")
+func (n *Node) Money(currency string) *Node {
+	n.Attr("type", "text")
+	n.Attr("inputmode", "decimal")
+	n.Attr("data-money", currency)
+	js := moneyJS
+	if n.rawJS != "" {
+		js = n.rawJS + ";" + js
+	}
+	n.rawJS = js
+	return n
+}
+
+// moneyJS is the post-mount script behind Money. Separators are the
+// browser locale's, taken from a formatted sample, so a lone "." or ","
+// is never guessed at; norm returns "" for input it cannot read. The
+// amount is normalized into data-raw on every input, so a submit without
+// blur (Enter, a shortcut) sends what is typed; blur only formats. The
+// initial value is normalized already.
+const moneyJS = `var el=this,f;` +
+	`try{f=new Intl.NumberFormat(undefined,{style:'currency',currency:el.getAttribute('data-money')})}catch(_){f=null}` +
+	`var grp='',dec='.';` +
+	`new Intl.NumberFormat().formatToParts(12345.6).forEach(function(p){if(p.type==='group')grp=p.value;if(p.type==='decimal')dec=p.value});` +
+	`function norm(v){v=String(v).replace(/[\s'_]/g,'');var i=v.indexOf(dec);if(grp&&i>=0&&v.lastIndexOf(grp)>i)return '';` +
+	`if(grp&&!/\s/.test(grp))v=v.split(grp).join('');` +
+	`if(dec!=='.')v=v.split(dec).join('.');if(!/^[+-]?(\d+\.?\d*|\.\d+)$/.test(v))return '';` +
+	`v=v.replace(/^\+/,'').replace(/\.$/,'');return v.replace(/^(-?)\./,function(_,s){return s+'0.'})}` +
+	`function show(){var r=el.dataset.raw||'';if(!r||!f){return}` +
+	`var p=r.split('.')[1]||'',o=f.resolvedOptions();` +
+	`el.value=new Intl.NumberFormat(undefined,{style:'currency',currency:o.currency,minimumFractionDigits:Math.max(p.length,o.minimumFractionDigits),maximumFractionDigits:Math.max(p.length,o.maximumFractionDigits)}).format(Number(r))}` +
+	`el.addEventListener('focus',function(){var r=el.dataset.raw||'';if(r)el.value=r.replace('.',dec)});` +
+	`el.addEventListener('input',function(){el.dataset.raw=norm(el.value)});` +
+	`el.addEventListener('blur',function(){var r=el.dataset.raw||'';el.setAttribute('aria-invalid',el.value&&!r?'true':'false');if(r)show()});` +
+	`el.dataset.raw=/^-?\d+(\.\d+)?$/.test(el.value)?el.value:norm(el.value);show();`
+
+// ---------------------------------------------------------------------------
+// 22. Language Switcher
+// ---------------------------------------------------------------------------
+
+// LanguageSwitcher renders a language picker, the counterpart of
+// ThemeSwitcher. langs are "code:Label" pairs ("sk:Slovenčina"); current
+// is usually ctx.Locale(). Picking a language stores it in the gsui_lang
+// cookie and the session (via Context.SetLocale) and reloads the page.
+//
+//	ui.LanguageSwitcher(ctx.Locale(), "en:English", "sk:Slovenčina")
+func LanguageSwitcher(current string, langs ...string) *Node {
+	current = normalizeLocale(current)
+	sel := Select("px-3 py-1.5 rounded-lg text-sm font-medium cursor-pointer "+
+		"border border-gray-200 bg-white text-gray-700 hover:bg-gray-50 "+
+		"dark:bg-gray-800 dark:text-gray-200 dark:border-gray-600 dark:hover:bg-gray-700 "+
+		"transition-colors").Attr("aria-label", "Language")
+	for _, pair := range langs {
+		code, label, ok := strings.Cut(pair, ":")
+		if !ok {
+			label = code
+		}
+		opt := Option().Attr("value", code).Attr("lang", code).Text(label)
+		if normalizeLocale(code) == current || baseLocale(normalizeLocale(code)) == current {
+			opt.Attr("selected", "true")
+		}
+		sel.Render(opt)
+	}
+	return sel.On("change", JS(fmt.Sprintf(
+		"var l=event.currentTarget.value;document.cookie='%s='+encodeURIComponent(l)+';path=/;max-age=31536000;SameSite=Lax';"+
+			"__ws.call('__locale',{locale:l})", localeCookie)))
+}
+
+// ---------------------------------------------------------------------------
+// 23. File Upload
+// ---------------------------------------------------------------------------
+
+// FileUploadLocale holds translatable strings for FileUpload.
+type FileUploadLocale struct {
+	Prompt string // text inside the zone
+	Remove string // label of a thumbnail's remove button, followed by the file name
+	// Summary of the selection; {n} is replaced by the file count and
+	// {size} by their total size ("3 files, 4.2 MB").
+	Summary string
+}
+
+// FileUploadBuilder renders a file input as a dashed drop zone with the
+// chosen files listed below it. Files can be picked or dropped onto the
+// zone; dropped files are filtered by Accept. Each file has a remove
+// button; removing it takes it out of the input, so a form posting to an
+// HTTP route (multipart) submits only what is left. With Multiple, every
+// pick or drop adds to the list instead of replacing it.
+type FileUploadBuilder struct {
+	name     string
+	accept   string
+	multiple bool
+	required bool
+	preview  bool
+	maxW     int
+	maxH     int
+	quality  float64
+	class    string
+	locale   *FileUploadLocale
+}
+
+// NewFileUpload creates a file upload field submitted under name.
+//
+//	ui.Form("").Attr("method", "post").Attr("action", "/gallery").
+//		Attr("enctype", "multipart/form-data").Render(
+//		ui.NewFileUpload("photos").Accept("image/*").Multiple().Preview().Build(),
+//		ui.Button().Attr("type", "submit").Text("Upload"),
+//	)
+func NewFileUpload(name string) *FileUploadBuilder {
+	return &FileUploadBuilder{name: name}
+}
+
+// Accept restricts the file picker, e.g. "image/*" or ".pdf,.docx".
+func (f *FileUploadBuilder) Accept(types string) *FileUploadBuilder { f.accept = types; return f }
+
+// Multiple allows choosing more than one file.
+func (f *FileUploadBuilder) Multiple() *FileUploadBuilder { f.multiple = true; return f }
+
+// Required makes choosing a file required: the form will not submit
+// without one, and screen readers announce the field as required.
+func (f *FileUploadBuilder) Required() *FileUploadBuilder { f.required = true; return f }
+
+// Preview shows images as thumbnails in a grid; other files are listed by
+// name.
+func (f *FileUploadBuilder) Preview() *FileUploadBuilder { f.preview = true; return f }
+
+// MaxUpload downsizes JPEG, PNG and WebP images to fit width × height
+// (0 leaves that side unbounded) before they are submitted, re-encoded at
+// quality (0–1; JPEG and WebP only, 0.85 when <= 0). The aspect ratio is
+// kept; smaller images, other files and images the browser cannot decode
+// are submitted as they are. A submit while images are still being
+// processed waits for them.
+//
+//	ui.NewFileUpload("photos").Accept("image/*").Multiple().MaxUpload(1920, 1920, 0.8)
+func (f *FileUploadBuilder) MaxUpload(width, height int, quality float64) *FileUploadBuilder {
+	f.maxW, f.maxH, f.quality = max(width, 0), max(height, 0), quality
+	if f.quality <= 0 || f.quality > 1 {
+		f.quality = 0.85
+	}
+	return f
+}
+
+// FileUploadClass appends additional CSS classes to the wrapper.
+func (f *FileUploadBuilder) FileUploadClass(cls string) *FileUploadBuilder { f.class = cls; return f }
+
+// Locale sets a per-instance locale.
+func (f *FileUploadBuilder) Locale(l *FileUploadLocale) *FileUploadBuilder {
+	f.locale = l
+	return f
+}
+
+func (f *FileUploadBuilder) loc() *FileUploadLocale {
+	l := FileUploadLocale{
+		Prompt:  "Drop a file here or click to browse",
+		Remove:  "Remove",
+		Summary: "{n} selected, {size}",
+	}
+	if f.multiple {
+		l.Prompt = "Drop files here or click to browse"
+	}
+	if f.locale != nil {
+		if f.locale.Prompt != "" {
+			l.Prompt = f.locale.Prompt
+		}
+		if f.locale.Remove != "" {
+			l.Remove = f.locale.Remove
+		}
+		if f.locale.Summary != "" {
+			l.Summary = f.locale.Summary
+		}
+	}
+	return &l
+}
+
+// Build compiles the file upload into a *Node.
+func (f *FileUploadBuilder) Build() *Node {
+	l := f.loc()
+	wrapCls := "w-full"
+	if f.class != "" {
+		wrapCls += " " + f.class
+	}
+	summaryID := Target()
+	input := IFile("sr-only").Attr("name", f.name).Attr("aria-describedby", summaryID)
+	if f.required {
+		input.Attr("required", "true").Attr("aria-required", "true")
+	}
+	if f.accept != "" {
+		input.Attr("accept", f.accept)
+	}
+	if f.multiple {
+		input.Attr("multiple", "true")
+	}
+
+	listCls := "mt-2 flex flex-col gap-1"
+	if f.preview {
+		listCls = "mt-2 grid grid-cols-3 sm:grid-cols-4 gap-2"
+	}
+	wrapper := Div(wrapCls).
+		Attr("data-file-upload", "").
+		Attr("data-remove", l.Remove).
+		Attr("data-summary", l.Summary).
+		Render(
+			Label("flex flex-col items-center justify-center gap-1 w-full p-6 rounded-xl cursor-pointer "+
+				"border-2 border-dashed border-gray-300 dark:border-gray-600 text-sm text-gray-500 dark:text-gray-400 "+
+				"hover:border-blue-400 hover:bg-blue-50/50 dark:hover:bg-blue-950/30 "+
+				"focus-within:ring-2 focus-within:ring-blue-500 transition-colors").Attr("data-drop", "").Render(
+				input,
+				Span("material-icons-round text-3xl").Attr("aria-hidden", "true").Text("upload_file"),
+				Span().Text(l.Prompt),
+			),
+			Div(listCls).Attr("data-files", ""),
+			Div("mt-1 text-xs text-gray-500 dark:text-gray-400").ID(summaryID).Attr("data-summary-text", "").Attr("aria-live", "polite"),
+		)
+	if f.preview {
+		wrapper.Attr("data-preview", "")
+	}
+	if f.maxW > 0 || f.maxH > 0 {
+		wrapper.Attr("data-max-upload", fmt.Sprintf("%d,%d,%g", f.maxW, f.maxH, f.quality))
+	}
+	return wrapper.JS(fileUploadJS)
+}
+
+// fileUploadJS is the post-mount script behind FileUpload. input.files is
+// read-only, so the chosen files live in list and are written back to the
+// input through a DataTransfer after every change. w.__gsuiAdd is the one
+// way files enter the list; shrink applies MaxUpload on the way in, and a
+// submit arriving meanwhile is replayed once pending reaches zero.
+const fileUploadJS = `var w=this,inp=w.querySelector('input[type=file]'),box=w.querySelector('[data-files]'),` +
+	`sum=w.querySelector('[data-summary-text]'),list=[];` +
+	`function size(b){var u=['B','KB','MB','GB'],i=0;while(b>=1024&&i<u.length-1){b/=1024;i++}return (i?b.toFixed(1):b)+' '+u[i]}` +
+	`function sync(){try{var dt=new DataTransfer();list.forEach(function(f){dt.items.add(f)});inp.files=dt.files}catch(_){}render()}` +
+	`function render(){box.textContent='';var total=0;list.forEach(function(f,i){total+=f.size;` +
+	`var c=document.createElement('div'),x=document.createElement('button');` +
+	`if(w.hasAttribute('data-preview')&&/^image\//.test(f.type)){` +
+	`c.className='relative';var img=document.createElement('img');img.src=URL.createObjectURL(f);img.alt=f.name;` +
+	`img.className='w-full h-24 object-cover rounded-lg border border-gray-200 dark:border-gray-700';` +
+	`img.onload=function(){URL.revokeObjectURL(img.src)};c.appendChild(img);` +
+	`x.className='absolute top-1 right-1 w-6 h-6 rounded-full bg-black/60 text-white text-sm leading-none cursor-pointer hover:bg-black/80'` +
+	`}else{c.className='flex items-center gap-2 px-3 py-1.5 rounded-lg bg-gray-100 dark:bg-gray-800 text-sm';` +
+	`var n=document.createElement('span');n.className='flex-1 truncate';n.textContent=f.name;` +
+	`var s=document.createElement('span');s.className='text-xs text-gray-500';s.textContent=size(f.size);c.appendChild(n);c.appendChild(s);` +
+	`x.className='w-6 h-6 rounded-full text-gray-500 hover:bg-gray-200 dark:hover:bg-gray-700 cursor-pointer'}` +
+	`x.type='button';x.textContent='×';x.setAttribute('aria-label',w.getAttribute('data-remove')+' '+f.name);` +
+	`x.addEventListener('click',function(){list.splice(i,1);sync()});c.appendChild(x);box.appendChild(c)});` +
+	`sum.textContent=list.length?w.getAttribute('data-summary').replace('{n}',list.length).replace('{size}',size(total)):''}` +
+	`function key(f){return f.__gsuiKey||f.name+'|'+f.size+'|'+f.lastModified}` +
+	`function shrink(f,done){var m=(w.getAttribute('data-max-upload')||'').split(','),mw=+m[0]||0,mh=+m[1]||0,q=+m[2]||0.85;` +
+	`if(!(mw||mh)||!/^image\/(jpeg|png|webp)$/.test(f.type))return done(f);` +
+	`var url=URL.createObjectURL(f),img=new Image();` +
+	`img.onerror=function(){URL.revokeObjectURL(url);done(f)};` +
+	`img.onload=function(){URL.revokeObjectURL(url);` +
+	`var r=Math.min(mw?mw/img.naturalWidth:1,mh?mh/img.naturalHeight:1);if(r>=1)return done(f);` +
+	`try{var c=document.createElement('canvas');c.width=Math.max(1,Math.round(img.naturalWidth*r));c.height=Math.max(1,Math.round(img.naturalHeight*r));` +
+	`c.getContext('2d').drawImage(img,0,0,c.width,c.height);` +
+	`c.toBlob(function(b){if(!b)return done(f);var n=new File([b],f.name,{type:b.type,lastModified:f.lastModified});n.__gsuiKey=key(f);done(n)},f.type,q)` +
+	`}catch(_){done(f)}};img.src=url}` +
+	`var pending=0,waiting=null;` +
+	`w.__gsuiAdd=function(files){files=Array.prototype.slice.call(files);` +
+	`if(!inp.multiple){files=files.slice(0,1)}else{files=files.filter(function(f){return !list.some(function(o){return key(o)===key(f)})})}` +
+	`var out=[],left=files.length;pending++;` +
+	`function merge(){if(!inp.multiple){list=out}else{list=list.concat(out)}pending--;sync();` +
+	`if(!pending&&waiting){var s=waiting.s;waiting=null;if(s)inp.form.requestSubmit(s);else inp.form.requestSubmit()}}` +
+	`if(!left)return merge();files.forEach(function(f,i){shrink(f,function(n){out[i]=n;if(--left===0)merge()})})};` +
+	`inp.addEventListener('change',function(){w.__gsuiAdd(inp.files)});` +
+	`var zone=w.querySelector('[data-drop]'),hot=['border-blue-500','bg-blue-50','dark:bg-blue-950/40'],depth=0;` +
+	`function accepts(f){var a=(inp.getAttribute('accept')||'').split(',').map(function(t){return t.trim().toLowerCase()}).filter(Boolean);` +
+	`if(!a.length)return true;var n=f.name.toLowerCase(),t=(f.type||'').toLowerCase();` +
+	`return a.some(function(p){return p.charAt(0)==='.'?n.slice(-p.length)===p:/\/\*$/.test(p)?t.indexOf(p.slice(0,-1))===0:t===p})}` +
+	`function cool(){depth=0;hot.forEach(function(c){zone.classList.remove(c)})}` +
+	`zone.addEventListener('dragenter',function(e){if(!e.dataTransfer||e.dataTransfer.types.indexOf('Files')<0)return;e.preventDefault();depth++;hot.forEach(function(c){zone.classList.add(c)})});` +
+	`zone.addEventListener('dragover',function(e){if(!e.dataTransfer||e.dataTransfer.types.indexOf('Files')<0)return;e.preventDefault();e.dataTransfer.dropEffect=inp.disabled?'none':'copy'});` +
+	`zone.addEventListener('dragleave',function(){if(--depth<=0)cool()});` +
+	`zone.addEventListener('drop',function(e){e.preventDefault();cool();if(inp.disabled||!e.dataTransfer)return;` +
+	`var fs=Array.prototype.filter.call(e.dataTransfer.files,accepts);if(fs.length)w.__gsuiAdd(fs)});` +
+	`if(inp.form)inp.form.addEventListener('submit',function(e){if(pending){e.preventDefault();waiting={s:e.submitter}}});` +
+	`if(inp.form)inp.form.addEventListener('reset',function(){list=[];setTimeout(sync)});`
+
+// ---------------------------------------------------------------------------
+// 24. Copy Button
+// ---------------------------------------------------------------------------
+
+// CopyButton renders a button that copies text to the clipboard and shows
+// "Copied!" for a moment. label is the button text and, optionally, the
+// text shown after copying (defaults "Copy" and "Copied!"):
+//
+//	ui.CopyButton(inviteURL)
+//	ui.CopyButton(apiKey, "Kopírovať", "Skopírované")
+//
+// The text is kept in a data attribute, not in the script, and copied with
+// the Clipboard API; where that is missing or refused (plain HTTP, older
+// browsers) a temporary textarea and execCommand("copy") are used.
+func CopyButton(text string, label ...string) *Node {
+	copyLabel, doneLabel := "Copy", "Copied!"
+	if len(label) > 0 && label[0] != "" {
+		copyLabel = label[0]
+	}
+	if len(label) > 1 && label[1] != "" {
+		doneLabel = label[1]
+	}
+	return Button("inline-flex items-center gap-1.5 px-3 py-1.5 rounded-lg text-sm font-medium cursor-pointer "+
+		"border border-gray-200 bg-white text-gray-700 hover:bg-gray-50 "+
+		"dark:bg-gray-800 dark:text-gray-200 dark:border-gray-600 dark:hover:bg-gray-700 "+
+		"transition-colors").
+		Attr("type", "button").
+		Attr("data-copy", text).
+		Attr("data-copied", doneLabel).
+		Render(
+			Span("material-icons-round text-base").Attr("aria-hidden", "true").Text("content_copy"),
+			Span().Text(copyLabel),
+			Span("sr-only").Attr("aria-live", "polite"),
+		).
+		OnClick(JS(copyJS))
+}
+
+// copyJS is the click handler of CopyButton.
+const copyJS = `var b=event.currentTarget,t=b.getAttribute('data-copy')||'',s=b.children;` +
+	`function fallback(){var a=document.createElement('textarea');a.value=t;a.setAttribute('readonly','');` +
+	`a.style.position='fixed';a.style.top='0';a.style.left='0';a.style.opacity='0';document.body.appendChild(a);a.select();` +
+	`var ok=false;try{ok=document.execCommand('copy')}catch(_){}a.remove();b.focus();return ok}` +
+	`function done(ok){if(!ok)return;clearTimeout(b.__gsuiCopy);` +
+	`if(!b.__gsuiLabel)b.__gsuiLabel=s[1].textContent;s[0].textContent='check';s[1].textContent=b.getAttribute('data-copied');s[2].textContent=b.getAttribute('data-copied');` +
+	`b.__gsuiCopy=setTimeout(function(){s[0].textContent='content_copy';s[1].textContent=b.__gsuiLabel;s[2].textContent='';b.__gsuiLabel=null},1500)}` +
+	`if(navigator.clipboard&&window.isSecureContext){navigator.clipboard.writeText(t).then(function(){done(true)},function(){done(fallback())})}else{done(fallback())}`
+
+// ---------------------------------------------------------------------------
+// 25. Validation Message
+// ---------------------------------------------------------------------------
+
+// ValidationMessage replaces the browser's own message for a failed
+// required, pattern, type or range constraint of the input with msg, so
+// native validation speaks the user's language. Take msg from the app's
+// catalog:
+//
+//	ui.IEmail("border rounded px-2").Attr("name", "email").Attr("required", "true").
+//		ValidationMessage(ctx.Translate("email.invalid"))
+//
+// The message shows when the form is submitted or reportValidity runs,
+// and is cleared as soon as the user edits the value, so the browser
+// checks the constraints afresh. FormBuilder fields validate in script and
+// take their messages from FormLocale instead.
+func (n *Node) ValidationMessage(msg string) *Node {
+	if msg == "" {
+		return n
+	}
+	n.Attr("data-validation-msg", msg)
+	js := validationMsgJS
+	if n.rawJS != "" {
+		js = n.rawJS + ";" + js
+	}
+	n.rawJS = js
+	return n
+}
+
+// validationMsgJS is the post-mount script behind ValidationMessage. The
+// custom message is set only while a built-in constraint fails; setting it
+// would otherwise make the input invalid by itself.
+const validationMsgJS = `var el=this;` +
+	`el.addEventListener('invalid',function(){el.setCustomValidity('');if(!el.validity.valid)el.setCustomValidity(el.getAttribute('data-validation-msg')||'')});` +
+	`var clear=function(){el.setCustomValidity('')};el.addEventListener('input',clear);el.addEventListener('change',clear);`
+
+// ---------------------------------------------------------------------------
+// 26. Number Format
+// ---------------------------------------------------------------------------
+
+// NumberFormat turns a text-like input into a number field shown the way
+// locale (a BCP 47 tag such as "de-DE"; "" for the browser's) writes
+// numbers: "1.234,56" in German, "1 234,56" in French. While focused it
+// shows the number without grouping; on blur with grouping. Users type
+// the locale's decimal separator; its grouping separator is ignored. The
+// value collected for actions and FormBuilder submits is the normalized
+// number ("1234.56"), which ctx.Body reads into Decimal fields and into
+// int and float fields tagged gsui:"number"; it follows every keystroke,
+// so Enter submits what is typed. A value set server-side is read in that
+// normalized form.
+//
+//	ui.IText("border rounded px-2").Attr("name", "Weight").Attr("value", "1234.5").NumberFormat(ctx.Locale())
+func (n *Node) NumberFormat(locale string) *Node {
+	n.Attr("type", "text")
+	n.Attr("inputmode", "decimal")
+	n.Attr("data-number", locale)
+	js := numberFormatJS
+	if n.rawJS != "" {
+		js = n.rawJS + ";" + js
+	}
+	n.rawJS = js
+	return n
+}
+
+// numberFormatJS is the post-mount script behind NumberFormat. norm reads
+// the locale's separators, taken from a formatted sample, and returns ""
+// for input it cannot read; the initial value is normalized already. The
+// number is normalized into data-raw on every input, so a submit without
+// blur (Enter, a shortcut) sends what is typed; blur only formats.
+const numberFormatJS = `var el=this,f;` +
+	`try{f=new Intl.NumberFormat(el.getAttribute('data-number')||undefined)}catch(_){f=new Intl.NumberFormat()}` +
+	`var loc=f.resolvedOptions().locale,grp='',dec='.';` +
+	`f.formatToParts(12345.6).forEach(function(p){if(p.type==='group')grp=p.value;if(p.type==='decimal')dec=p.value});` +
+	`function norm(v){v=String(v).replace(/[\s'_]/g,'');if(grp&&!/\s/.test(grp))v=v.split(grp).join('');` +
+	`if(dec!=='.')v=v.split(dec).join('.');if(!/^[+-]?(\d+\.?\d*|\.\d+)$/.test(v))return '';` +
+	`v=v.replace(/^\+/,'').replace(/\.$/,'');return v.replace(/^(-?)\./,function(_,s){return s+'0.'})}` +
+	`function show(group){var r=el.dataset.raw||'';if(!r)return;var p=r.split('.')[1]||'';` +
+	`el.value=new Intl.NumberFormat(loc,{useGrouping:group,minimumFractionDigits:p.length,maximumFractionDigits:p.length}).format(Number(r))}` +
+	`el.addEventListener('focus',function(){show(false)});` +
+	`el.addEventListener('input',function(){el.dataset.raw=norm(el.value)});` +
+	`el.addEventListener('blur',function(){var r=el.dataset.raw||'';el.setAttribute('aria-invalid',el.value&&!r?'true':'false');if(r)show(true)});` +
+	`el.dataset.raw=/^-?\d+(\.\d+)?$/.test(el.value)?el.value:norm(el.value);show(true);`
@@ -0,0 +1,667 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Input mask tests
+// ---------------------------------------------------------------------------
+
+func TestMaskSetsAttrsAndFormatter(t *testing.T) {
+	js := IText("border").ID("phone").Mask("(###) ###-####").ToJS()
+
+	expect(t, js, "setAttribute('data-mask','(###) ###-####')")
+	expect(t, js, "setAttribute('inputmode','numeric')")
+	expect(t, js, "setAttribute('maxlength','14')")
+	expect(t, js, "el.dataset.raw=f.r")
+	expect(t, js, "addEventListener('keydown'")
+}
+
+func TestMaskKeepsExistingRawJS(t *testing.T) {
+	js := IText().JS("this.focus()").Mask("AA-##").ToJS()
+
+	expect(t, js, "this.focus();var el=this")
+	notExpect(t, js, "inputmode")
+}
+
+func TestFormFieldMaskSubmitsRawValue(t *testing.T) {
+	js := NewForm("f").Action("save").
+		Phone("Phone", "Phone").Mask("(###) ###-####").PatternValidation(`^\d{10}$`).Render().
+		Submit("send", "Send", "").
+		Build().ToJS()
+
+	expect(t, js, "setAttribute('data-mask','(###) ###-####')")
+	expect(t, js, "e.hasAttribute('data-mask')")
+	if strings.Contains(js, "setAttribute('pattern'") {
+		t.Error("masked field should not carry an HTML pattern attribute for the formatted value")
+	}
+}
+
+func TestFormFieldsCarryARIAState(t *testing.T) {
+	js := NewForm("f").Action("save").
+		Email("Email", "email").Required().Help("We never share it").Render().
+		Text("Nick", "nick").Render().
+		Radio("Plan", "plan").Opts("a:A", "b:B").Required().Render().
+		Submit("send", "Send", "").
+		Build().ToJS()
+
+	expect(t, js, "setAttribute('aria-required','true')")
+	expect(t, js, "textContent=' *'")
+	notExpect(t, js, "textContent='Email *'")
+	expect(t, js, "setAttribute('aria-describedby','help-f-email err-f-email')")
+	expect(t, js, ".id='help-f-email';")
+	expect(t, js, "textContent='We never share it'")
+	expect(t, js, "setAttribute('aria-describedby','err-f-nick')")
+	expect(t, js, "setAttribute('role','radiogroup')")
+	expect(t, js, "setAttribute('aria-describedby','err-f-plan')")
+	notExpect(t, js, "textContent='Email is required'") // error stays empty until shown
+	expect(t, js, "inp.setAttribute('aria-invalid','true')")
+	expect(t, js, "else if(!show)e.textContent=''")
+
+	up := NewFileUpload("cv").Required().Build().ToJS()
+	expect(t, up, "setAttribute('required','true')")
+	expect(t, up, "setAttribute('aria-required','true')")
+	expect(t, up, "setAttribute('aria-describedby','t-")
+}
+
+func TestFormValidateRuleMessages(t *testing.T) {
+	f := NewForm("f").
+		Email("Email", "email").Required().Render().
+		Number("Age", "age").Min(18).Max(99).Render().
+		Text("Nick", "nick").Min(3).Render().
+		Text("Code", "code").Max(2).Err("Two letters max").Render()
+
+	errs := f.Validate(map[string]any{"email": "nope", "age": "12", "nick": "ab", "code": "abc"})
+	want := FormErrors{
+		"email": "Email must be a valid email address",
+		"age":   "Age must be at least 18",
+		"nick":  "Nick must be at least 3 characters",
+		"code":  "Two letters max",
+	}
+	for name, msg := range want {
+		if errs.Get(name) != msg {
+			t.Errorf("%s: got %q, want %q", name, errs.Get(name), msg)
+		}
+	}
+	if errs := f.Validate(map[string]any{"email": "a@b.co", "age": "40"}); errs.HasErrors() {
+		t.Fatalf("valid data produced %v", errs)
+	}
+	if errs := f.Validate(map[string]any{}); errs.Get("email") != "Email is required" || len(errs) != 1 {
+		t.Fatalf("empty data produced %v", errs)
+	}
+}
+
+func TestFormLocaleMessagesReachClient(t *testing.T) {
+	f := NewForm("f").Locale(&FormLocale{
+		Required: func(label string) string { return label + " je povinné" },
+	}).Text("Meno", "name").Required().Render().Number("Vek", "age").Max(9).Render().
+		Submit("save", "Save", "")
+
+	js := f.Build().ToJS()
+	expect(t, js, "Meno je povinné")
+	expect(t, js, "Vek must be at most 9")
+	expect(t, js, "setAttribute('max','9')")
+	// A failing rule returns an expression: "return {...}" would parse as
+	// an object literal and break the whole handler.
+	expect(t, js, "return (err('err-f-age',true,'f-age','Vek must be at most 9'),ok=false)")
+	notExpect(t, js, "return {")
+	if errs := f.Validate(map[string]any{"age": "x"}); errs.Get("name") != "Meno je povinné" || errs.Get("age") != "Vek format is invalid" {
+		t.Fatalf("Validate = %v", errs)
+	}
+}
+
+func TestFormErrorSummary(t *testing.T) {
+	f := NewForm("f").Text("Name", "name").Required().Render().Email("Email", "email").Required().Render()
+	// Only forms that use ErrorSummary get the box, added by the reply.
+	notExpect(t, f.Build().ToJS(), "f-summary")
+
+	errs := f.Validate(map[string]any{})
+	js := f.ErrorSummary(errs)
+	expect(t, js, "var s=document.createElement('div');s.id='f-summary';f.prepend(s)")
+	expect(t, js, "There are 2 problems with this form")
+	expect(t, js, "setAttribute('href','#f-name')")
+	expect(t, js, "document.getElementById('f-email')")
+	if strings.Index(js, "Name is required") > strings.Index(js, "Email is required") {
+		t.Error("summary should list fields in form order")
+	}
+
+	js = f.ErrorSummary(fmt.Errorf("save: %w", errors.New("database offline")))
+	expect(t, js, "There is 1 problem with this form")
+	expect(t, js, "save: database offline")
+	notExpect(t, js, "href")
+
+	expect(t, f.ErrorSummary(nil), "if(document.getElementById('f-summary')){")
+	expect(t, f.ErrorSummary(nil), "text-sm hidden'")
+	if errs.Error() != "email: Email is required; name: Name is required" {
+		t.Errorf("FormErrors.Error() = %q", errs.Error())
+	}
+}
+
+func TestFormShowErrorToleratesPlainErrors(t *testing.T) {
+	f := NewForm("f").Text("Name", "name").Required().Render()
+
+	js := f.ShowError(errors.New("json: cannot unmarshal number into Go value of type string"))
+	expect(t, js, "e.textContent='';e.classList.add('hidden')")
+	expect(t, js, "cannot unmarshal number")
+
+	js = f.ShowError(fmt.Errorf("validate: %w", FormErrors{"name": "Name is required"}))
+	expect(t, js, "e.textContent='Name is required';e.classList.remove('hidden')")
+	expect(t, js, "setAttribute('href','#f-name')")
+
+	expect(t, f.ShowError(nil), "e.classList.add('hidden')")
+}
+
+func TestMoneyInputCollectsRawAmount(t *testing.T) {
+	js := INumber().ID("price").Money("EUR").ToJS()
+
+	expect(t, js, "setAttribute('type','text')")
+	expect(t, js, "setAttribute('data-money','EUR')")
+	expect(t, js, "setAttribute('inputmode','decimal')")
+	expect(t, js, "el.addEventListener('input',function(){el.dataset.raw=norm(el.value)})")
+	expect(t, js, "new Intl.NumberFormat().formatToParts(12345.6)")
+	notExpect(t, js, "v.length-c-1!==3")
+}
+
+// ---------------------------------------------------------------------------
+// Pager tests
+// ---------------------------------------------------------------------------
+
+func TestPagerWindowEllipsis(t *testing.T) {
+	cases := []struct {
+		page, pages int
+		want        string
+	}{
+		{1, 1, "[1]"},
+		{1, 5, "[1 2 0 5]"},
+		{3, 5, "[1 2 3 4 5]"},
+		{10, 20, "[1 0 9 10 11 0 20]"},
+		{20, 20, "[1 0 19 20]"},
+	}
+	for _, c := range cases {
+		if got := fmt.Sprint(pagerWindow(c.page, c.pages, 1)); got != c.want {
+			t.Errorf("pagerWindow(%d,%d) = %s, want %s", c.page, c.pages, got, c.want)
+		}
+	}
+}
+
+func TestPagerDisablesEdgesAndSendsPaging(t *testing.T) {
+	js := NewPager(42, 1, 10).PagerAction("items.page").PagerSizes(10, 25).Build().ToJS()
+
+	expect(t, js, "setAttribute('aria-label','First')")
+	expect(t, js, "setAttribute('disabled','true')")
+	expect(t, js, "setAttribute('aria-current','page')")
+	expect(t, js, `__ws.call('items.page',{"Page":5,"Size":10})`)
+	expect(t, js, "__ws.call('items.page',{Page:1,Size:parseInt(event.currentTarget.value,10)})")
+	expect(t, js, "1–10 of 42")
+
+	// A zero size is clamped to 1 in the payload too.
+	expect(t, NewPager(3, 1, 0).PagerAction("items.page").Build().ToJS(), `{"Page":3,"Size":1}`)
+
+	// A size outside the choices is listed, in order, and selected.
+	js = NewPager(100, 1, 15).PagerSizes(10, 20, 50).Build().ToJS()
+	i10, i15, i20 := strings.Index(js, "setAttribute('value','10')"), strings.Index(js, "setAttribute('value','15')"), strings.Index(js, "setAttribute('value','20')")
+	if i10 < 0 || i15 < i10 || i20 < i15 {
+		t.Fatalf("size options out of order: %d %d %d", i10, i15, i20)
+	}
+	expect(t, js[i15:], "setAttribute('selected','true')")
+	notExpect(t, js[i10:i15], "selected")
+}
+
+func TestPagingOffset(t *testing.T) {
+	if got := (Paging{Page: 3, Size: 20}).Offset(); got != 40 {
+		t.Fatalf("Offset() = %d, want 40", got)
+	}
+	if got := (Paging{}).Offset(); got != 0 {
+		t.Fatalf("zero Paging Offset() = %d, want 0", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Polling tests
+// ---------------------------------------------------------------------------
+
+func TestPollStartsGuardedInterval(t *testing.T) {
+	js := Div().ID("stats").Poll(5*time.Second, &Action{Name: "stats.refresh", Data: map[string]any{"range": "day"}}).ToJS()
+
+	expect(t, js, "if(!el.isConnected){clearInterval(t);return}")
+	expect(t, js, "document.hidden")
+	expect(t, js, `__ws.call('stats.refresh',{"range":"day"},null,{quiet:true})},5000);`)
+}
+
+func TestPollIgnoresInvalidInterval(t *testing.T) {
+	js := Div().Poll(0, &Action{Name: "x"}).ToJS()
+	notExpect(t, js, "setInterval")
+}
+
+// ---------------------------------------------------------------------------
+// Shortcut tests
+// ---------------------------------------------------------------------------
+
+func TestShortcutDispatchesActionThroughNode(t *testing.T) {
+	js := Form().ID("edit").Shortcut("Ctrl+S", &Action{Name: "doc.save", Data: map[string]any{"id": 7}}).ToJS()
+
+	expect(t, js, "addEventListener('gsui-shortcut:ctrl+s',function(event){")
+	expect(t, js, `__ws.call('doc.save',{"id":7})`)
+	expect(t, js, "if(!el.isConnected){document.removeEventListener('keydown',h);return}")
+	expect(t, js, "toLowerCase()!=='s'||e.ctrlKey!==true||e.metaKey!==false||e.altKey!==false||e.shiftKey!==false)return;")
+	expect(t, js, "el.dispatchEvent(new CustomEvent('gsui-shortcut:ctrl+s'))")
+	notExpect(t, js, "isContentEditable") // modified combos work while typing
+}
+
+func TestShortcutPlainKeysSkipFields(t *testing.T) {
+	js := Div().Shortcut("/", JS("focus()")).ToJS()
+	expect(t, js, "isContentEditable")
+	notExpect(t, js, "e.shiftKey") // layouts may need shift to type it
+
+	mod := Div().Shortcut("mod+k", JS("")).ToJS()
+	expect(t, mod, "e.ctrlKey!==(!m||false)||e.metaKey!==(m||false)")
+}
+
+func TestShortcutParsesKeys(t *testing.T) {
+	for keys, want := range map[string]string{
+		"esc":         "escape",
+		"ctrl+plus":   "ctrl++",
+		"ctrl++":      "ctrl++",
+		"shift+?":     "shift+?",
+		"cmd+space":   "meta+space",
+		"shift+mod+p": "mod+shift+p",
+	} {
+		s, ok := parseShortcut(keys)
+		if !ok {
+			t.Errorf("parseShortcut(%q) failed", keys)
+			continue
+		}
+		if got := s.String(); got != want {
+			t.Errorf("parseShortcut(%q) = %q, want %q", keys, got, want)
+		}
+	}
+	for _, keys := range []string{"", "ctrl+", "hyper+x"} {
+		if _, ok := parseShortcut(keys); ok {
+			t.Errorf("parseShortcut(%q) accepted", keys)
+		}
+	}
+	notExpect(t, Div().Shortcut("hyper+x", JS("")).ToJS(), "keydown")
+}
+
+// ---------------------------------------------------------------------------
+// Modal tests
+// ---------------------------------------------------------------------------
+
+func TestModalRendersHiddenAccessibleDialog(t *testing.T) {
+	js := NewModal("edit").ModalTitle("Edit user").
+		ModalBody(P().Text("Loading")).
+		ModalActions(Button().Text("Save").OnClick(&Action{Name: "user.save"})).
+		Build().ToJS()
+
+	expect(t, js, "e0.id='edit'")
+	expect(t, js, "setAttribute('role','dialog')")
+	expect(t, js, "setAttribute('aria-modal','true')")
+	expect(t, js, "setAttribute('aria-labelledby','edit-title')")
+	expect(t, js, "hidden")
+	expect(t, js, ".id='edit-body'")
+	expect(t, js, "e.key==='Escape'&&dismiss")
+	expect(t, js, "o.__gsuiOpen=function")
+	expect(t, js, "'user.save'")
+}
+
+func TestModalNonDismissibleHasNoCloseButton(t *testing.T) {
+	js := NewModal("wait").Dismissible(false).Build().ToJS()
+
+	expect(t, js, "var o=this,dismiss=false")
+	notExpect(t, js, "aria-label','Close'")
+}
+
+func TestDocumentListenersDetachWithTheirElement(t *testing.T) {
+	dd := NewDropdown(Button().Text("More")).DropdownItem("Edit", &Action{Name: "edit"}).Build().ToJS()
+	expect(t, dd, "if(w.isConnected)return false;document.removeEventListener('click',onClick,true)")
+
+	expect(t, NewModal("m").Build().ToJS(), "if(!o.isConnected){document.removeEventListener('keydown',onKey)")
+
+	drag := DragToScroll("strip")
+	expect(t, drag, "if(el.__gsuiDrag)return;")
+	expect(t, drag, "if(!el.isConnected){document.removeEventListener('mouseup',up)")
+
+	expect(t, NewCaptchaV3("key").Build().ToJS(), "if(!el){clearInterval(t);return}")
+}
+
+func TestCaptchaV3VerifiesTokenWithSiteverify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("secret") != "s3cret" {
+			t.Errorf("secret = %q", r.PostFormValue("secret"))
+		}
+		switch r.PostFormValue("response") {
+		case "good":
+			w.Write([]byte(`{"success":true,"score":0.9,"action":"signup"}`))
+		case "bot":
+			w.Write([]byte(`{"success":true,"score":0.1,"action":"signup"}`))
+		case "login":
+			w.Write([]byte(`{"success":true,"score":0.9,"action":"login"}`))
+		default:
+			w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+		}
+	}))
+	defer srv.Close()
+	defer func(u string) { captchaVerifyURL = u }(captchaVerifyURL)
+	captchaVerifyURL = srv.URL
+
+	c := NewCaptchaV3("key").Secret("s3cret").FormAction("signup")
+	verify := func(token string) error {
+		return c.Verify(&Context{wsData: map[string]any{"g-recaptcha-response": token}})
+	}
+	if err := verify("good"); err != nil {
+		t.Fatalf("good token: %v", err)
+	}
+	for _, token := range []string{"", "bot", "login", "forged"} {
+		if err := verify(token); !errors.Is(err, ErrCaptcha) {
+			t.Errorf("token %q: %v, want ErrCaptcha", token, err)
+		}
+	}
+	notExpect(t, c.Build().ToJS(), "s3cret")
+}
+
+func TestClientScriptsInitializeOnce(t *testing.T) {
+	if !strings.HasPrefix(wsClientJS, "if(!window.__gsuiWSInit){window.__gsuiWSInit=true;") {
+		t.Fatal("WS client lacks its init guard")
+	}
+	expect(t, sseClientJS("/events"), "window.__gsuiSSEInit=true;")
+}
+
+func TestOverlaysShareFocusTrap(t *testing.T) {
+	modal := NewModal("m").Build().ToJS()
+	expect(t, modal, "window.__gsuiTrap=window.__gsuiTrap||function(o,fallback){")
+	expect(t, modal, "release=__gsuiTrap(o,o.querySelector('[data-modal-panel]'))")
+	expect(t, modal, "if(release)release();")
+	notExpect(t, modal, "e.key==='Tab'&&") // no trap of its own
+
+	confirm := ConfirmDialog("Delete?", "Gone for good.", &Action{Name: "del"}).ToJS()
+	expect(t, confirm, "window.__gsuiTrap=window.__gsuiTrap||")
+	expect(t, confirm, "var o=this;__gsuiTrap(o);")
+
+	expect(t, focusTrapJS, "if(!o.isConnected)release()")
+	expect(t, TrapFocus("f'x"), `getElementById('f\'x');if(o)__gsuiTrap(o)`)
+	expect(t, ReleaseFocus("f"), "o.__gsuiRelease()")
+}
+
+func TestOpenCloseModalJS(t *testing.T) {
+	expect(t, OpenModal("a'b"), `getElementById('a\'b')`)
+	expect(t, OpenModal("m"), "m.__gsuiOpen()")
+	expect(t, CloseModal("m"), "m.__gsuiClose()")
+	if ModalBodyID("m") != "m-body" {
+		t.Fatal("ModalBodyID")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tabs tests
+// ---------------------------------------------------------------------------
+
+func TestTabsLazyPanelCallsActionWithPanelID(t *testing.T) {
+	js := NewTabs().TabsID("acct").
+		Tab("Profile", P().Text("Hi")).
+		TabLazy("Orders", &Action{Name: "tabs.orders", Data: map[string]any{"user": 7}}).
+		Build().ToJS()
+
+	expect(t, js, ".id='acct-panel-1'")
+	expect(t, js, "setAttribute('aria-labelledby','acct-tab-1')")
+	expect(t, js, `__ws.call('tabs.orders',{"Panel":"acct-panel-1","Tab":1,"user":7}`)
+	expect(t, js, "var lazy=[null,function(){")
+	expect(t, js, "!p.dataset.loaded")
+	expect(t, js, "animate-pulse") // skeleton until loaded
+	if TabPanelID("acct", 1) != "acct-panel-1" {
+		t.Fatal("TabPanelID")
+	}
+}
+
+func TestTabsRememberUsesLocalStorage(t *testing.T) {
+	js := NewTabs().TabsRemember("settings").Tab("A", Div()).Tab("B", Div()).Active(1).Build().ToJS()
+
+	expect(t, js, "var key='gsui-tab:settings'")
+	expect(t, js, "var start=1;")
+	expect(t, js, "localStorage.setItem(key,String(idx))")
+
+	plain := NewTabs().Tab("A", Div()).Build().ToJS()
+	expect(t, plain, "var key='';")
+}
+
+// ---------------------------------------------------------------------------
+// Accordion tests
+// ---------------------------------------------------------------------------
+
+func TestAccordionAriaExpandedAndTransition(t *testing.T) {
+	js := NewAccordion().AccordionID("faq").
+		Item("Shipping", P().Text("3 days"), true).
+		Item("Returns", P().Text("30 days")).
+		Build().ToJS()
+
+	expect(t, js, "setAttribute('aria-expanded','true')")
+	expect(t, js, "setAttribute('aria-expanded','false')")
+	expect(t, js, "setAttribute('aria-controls','faq-panel-1')")
+	expect(t, js, "var g=this,single=true,lazy=[null,null]")
+	expect(t, js, "height 200ms ease")
+	expect(t, js, "prefers-reduced-motion")
+}
+
+func TestAccordionLazyItem(t *testing.T) {
+	js := NewAccordion().AccordionID("faq").Multiple(true).
+		ItemLazy("Orders", &Action{Name: "faq.orders"}).
+		Build().ToJS()
+
+	expect(t, js, "single=false")
+	expect(t, js, `__ws.call('faq.orders',{"Item":0,"Panel":"faq-panel-0"}`)
+	expect(t, js, "!b.dataset.loaded")
+	if AccordionPanelID("faq", 2) != "faq-panel-2" {
+		t.Fatal("AccordionPanelID")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Progress tests
+// ---------------------------------------------------------------------------
+
+func TestProgressAriaAndPatchTargets(t *testing.T) {
+	js := NewProgress().ProgressID("import").ProgressValue(40).
+		ProgressLabel("Importing").LabelPosition("outside").Build().ToJS()
+
+	expect(t, js, ".id='import'")
+	expect(t, js, "setAttribute('role','progressbar')")
+	expect(t, js, "setAttribute('aria-valuenow','40')")
+	expect(t, js, "setAttribute('data-progress-bar','')")
+	expect(t, js, "setAttribute('data-progress-value','')")
+
+	ind := NewProgress().Indeterminate(true).Build().ToJS()
+	notExpect(t, ind, "aria-valuenow")
+}
+
+func TestSetProgressClampsPercentage(t *testing.T) {
+	expect(t, SetProgress("import", 1, 3), "aria-valuenow','33'")
+	expect(t, SetProgress("import", 5, 4), "scaleX(1.0000)")
+	expect(t, SetProgress("import", 1, 0), "textContent='0%'")
+	// Only an indeterminate bar loses its animation; stripes keep moving.
+	expect(t, SetProgress("import", 1, 2), "if(b.hasAttribute('data-progress-indeterminate')){b.removeAttribute('data-progress-indeterminate');b.style.animation=''}")
+	expect(t, NewProgress().Indeterminate(true).Build().ToJS(), "setAttribute('data-progress-indeterminate','')")
+	notExpect(t, NewProgress().Animated(true).Build().ToJS(), "data-progress-indeterminate")
+}
+
+func TestPatchProgressNeedsConnection(t *testing.T) {
+	if err := (&Context{}).PatchProgress("import", 1, 2); err == nil {
+		t.Fatal("expected error without a WebSocket connection")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// File upload tests
+// ---------------------------------------------------------------------------
+
+func TestFileUploadRendersZoneAndRemovableList(t *testing.T) {
+	js := NewFileUpload("photos").Accept("image/*").Multiple().Preview().
+		Locale(&FileUploadLocale{Remove: "Odstrániť"}).Build().ToJS()
+
+	expect(t, js, "setAttribute('type','file')")
+	expect(t, js, "setAttribute('name','photos')")
+	expect(t, js, "setAttribute('accept','image/*')")
+	expect(t, js, "setAttribute('multiple','true')")
+	expect(t, js, "setAttribute('data-preview','')")
+	expect(t, js, "setAttribute('data-remove','Odstrániť')")
+	expect(t, js, "setAttribute('data-summary','{n} selected, {size}')")
+	expect(t, js, "new DataTransfer()")
+	expect(t, js, "list.splice(i,1);sync()")
+
+	js = NewFileUpload("cv").Build().ToJS()
+	notExpect(t, js, "'multiple'")
+	notExpect(t, js, "setAttribute('data-preview'")
+	expect(t, js, "Drop a file here or click to browse")
+}
+
+func TestFileUploadMaxUploadResizesBeforeSubmit(t *testing.T) {
+	js := NewFileUpload("photos").MaxUpload(1920, 0, 0).Build().ToJS()
+	expect(t, js, "setAttribute('data-max-upload','1920,0,0.85')")
+	expect(t, js, "c.toBlob(")
+	expect(t, js, "if(pending){e.preventDefault()")
+
+	js = NewFileUpload("photos").MaxUpload(800, 600, 0.7).Build().ToJS()
+	expect(t, js, "setAttribute('data-max-upload','800,600,0.7')")
+
+	notExpect(t, NewFileUpload("photos").Build().ToJS(), "setAttribute('data-max-upload'")
+}
+
+func TestFileUploadAcceptsDroppedFiles(t *testing.T) {
+	js := NewFileUpload("docs").Accept(".pdf").Multiple().Build().ToJS()
+	expect(t, js, "setAttribute('data-drop','')")
+	expect(t, js, "zone.addEventListener('drop'")
+	expect(t, js, "filter.call(e.dataTransfer.files,accepts)")
+	expect(t, js, "Drop files here or click to browse")
+}
+
+// ---------------------------------------------------------------------------
+// Copy button tests
+// ---------------------------------------------------------------------------
+
+func TestCopyButtonKeepsTextOutOfScript(t *testing.T) {
+	payload := `');alert(1);//`
+	js := CopyButton(payload).ToJS()
+	expect(t, js, `setAttribute('data-copy','\');alert(1);//')`)
+	expect(t, js, "setAttribute('data-copied','Copied!')")
+	expect(t, js, "navigator.clipboard.writeText(t)")
+	expect(t, js, "document.execCommand('copy')")
+	if strings.Count(js, "alert(1)") != 1 {
+		t.Fatal("copied text must appear only in the data attribute")
+	}
+
+	js = CopyButton("x", "Kopírovať", "Hotovo").ToJS()
+	expect(t, js, "e2.textContent='Kopírovať'")
+	expect(t, js, "setAttribute('data-copied','Hotovo')")
+}
+
+func TestValidationMessageLocalizesNativeConstraints(t *testing.T) {
+	app := NewApp()
+	app.Translations("sk", map[string]string{"email.invalid": "Zadajte platný e-mail"})
+	ctx := &Context{app: app, Session: map[string]any{localeKey: "sk"}}
+
+	js := IEmail().Attr("required", "true").ValidationMessage(ctx.Translate("email.invalid")).ToJS()
+	expect(t, js, "setAttribute('data-validation-msg','Zadajte platný e-mail')")
+	expect(t, js, "el.addEventListener('invalid',function(){el.setCustomValidity('');if(!el.validity.valid)")
+	expect(t, js, "el.addEventListener('input',clear)")
+
+	notExpect(t, IText().ValidationMessage("").ToJS(), "setCustomValidity")
+}
+
+func TestNumberFormatSubmitsNormalizedValue(t *testing.T) {
+	js := INumber().Attr("value", "1234.5").NumberFormat("de-DE").ToJS()
+	expect(t, js, "setAttribute('type','text')")
+	expect(t, js, "setAttribute('inputmode','decimal')")
+	expect(t, js, "setAttribute('data-number','de-DE')")
+	expect(t, js, "f.formatToParts(12345.6)")
+	expect(t, js, "useGrouping:group")
+	expect(t, js, "el.addEventListener('input',function(){el.dataset.raw=norm(el.value)})")
+
+	expect(t, wsClientJS, "el.hasAttribute('data-number')){")
+	form := NewForm("f").Action("save").Number("Weight", "w").Render().Submit("s", "Save", "").Build().ToJS()
+	expect(t, form, "e.hasAttribute('data-number'))return (e.dataset.raw||'')")
+}
+
+func TestTooltipFlipsToOppositeSide(t *testing.T) {
+	js := NewTooltip("Hint").TooltipPosition("left").Wrap(Button().Text("?")).ToJS()
+	expect(t, js, "setAttribute('aria-describedby',")
+	expect(t, js, "setAttribute('role','tooltip')")
+	expect(t, js, "'left':['right-full top-1/2 -translate-y-1/2 mr-2'")
+	expect(t, js, "'right':['left-full top-1/2 -translate-y-1/2 ml-2'")
+	expect(t, js, "addEventListener('mouseenter',place)")
+	expect(t, js, "addEventListener('focusin',place)")
+
+	js = NewTooltip("Hint").TooltipPosition("sideways").Delay(200).Wrap(Button().Text("?")).ToJS()
+	expect(t, js, "'top':['bottom-full left-1/2 -translate-x-1/2 mb-2'")
+	expect(t, js, "setTimeout(")
+	expect(t, js, "addEventListener('focusin',place)")
+}
+
+func TestAlertAndBadgeShortcuts(t *testing.T) {
+	js := Alert("error", "Failed", "Could not save.").ToJS()
+	expect(t, js, "bg-red-50 dark:bg-red-900/20")
+	expect(t, js, "textContent='Failed'")
+	notExpect(t, js, "aria-label','Dismiss'")
+
+	js = DismissibleAlert("success", "Saved", "All good.").ToJS()
+	expect(t, js, "bg-green-50 dark:bg-green-900/20")
+	expect(t, js, "setAttribute('aria-label','Dismiss')")
+
+	expect(t, Badge("Late", "warning").ToJS(), "bg-yellow-100 text-yellow-800 dark:bg-yellow-900/30")
+	expect(t, Badge("New", "purple").ToJS(), "bg-purple-100 text-purple-800")
+}
+
+func TestWizardValidatesStepBeforeMovingOn(t *testing.T) {
+	app := NewApp()
+	wiz := NewWizard("signup").Action("signup.finish")
+	wiz.Step("Account").Email("Email", "Email").Required().Render()
+	wiz.Step("Profile").Text("Name", "Name").Required().Render()
+	js := wiz.Build(&Context{app: app}).ToJS()
+	expect(t, js, "__ws.call('wizard.signup.step',d)")
+	expect(t, js, "var d={Action:'next',__step:0};d['Email']=val('signup-0-Email');")
+	expect(t, js, "var d={Action:'finish'};d['Email']=val('signup-0-Email');d['Name']=val('signup-1-Name');")
+	expect(t, js, "__ws.call('signup.finish',d)")
+	expect(t, js, "setAttribute('aria-current','step')")
+
+	app.mu.RLock()
+	step := app.actions["wizard.signup.step"]
+	app.mu.RUnlock()
+	if step == nil {
+		t.Fatal("Build must register the step action")
+	}
+	out := step(&Context{wsData: map[string]any{"__step": float64(0), "Email": "nope"}})
+	expect(t, out, "must be a valid email address")
+	notExpect(t, out, "__gsuiStep(1)")
+	out = step(&Context{wsData: map[string]any{"__step": float64(0), "Email": "a@b.co"}})
+	expect(t, out, "__gsuiStep(1)")
+	if out := step(&Context{wsData: map[string]any{"__step": float64(1)}}); out != "" {
+		t.Fatalf("the last step is sent with Finish, got %q", out)
+	}
+
+	errs := wiz.Validate(map[string]any{"Email": "", "Name": ""})
+	if len(errs) != 2 {
+		t.Fatalf("Validate must check every step, got %v", errs)
+	}
+	expect(t, wiz.ShowError(errs), "__gsuiStep(0)")
+	expect(t, wiz.ShowError(FormErrors{"Name": "taken"}), "__gsuiStep(1)")
+}
+
+func TestFormGuardUnsavedTracksEditsAndSaves(t *testing.T) {
+	js := NewForm("profile").Action("profile.save").GuardUnsaved().
+		Locale(&FormLocale{Unsaved: "Neuložené zmeny. Odísť?"}).
+		Text("Name", "Name").Render().Submit("save", "Save", "").Build().ToJS()
+	expect(t, js, "setAttribute('data-guard-unsaved','Neuložené zmeny. Odísť?')")
+	expect(t, js, "f.addEventListener('input',dirty)")
+	expect(t, js, "__ws.call('profile.save',d,null,{done:function(){var f=document.getElementById('profile');if(f&&f.__gsuiSaved)f.__gsuiSaved()}})")
+
+	plain := NewForm("p").Action("p.save").Text("Name", "Name").Render().Submit("save", "Save", "").Build().ToJS()
+	notExpect(t, plain, "data-guard-unsaved")
+	expect(t, plain, "__ws.call('p.save',d);")
+
+	expect(t, wsClientJS, "if(!mayLeave()){history.pushState(null,'',here);return}")
+	expect(t, wsClientJS, "if(dn&&!m.err)")
+}
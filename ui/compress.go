@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// compressMinSize is the smallest body worth compressing; below it the
+// gzip framing costs more than it saves.
+const compressMinSize = 1024
+
+var gzipPool = sync.Pool{New: func() any {
+	gz, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+	return gz
+}}
+
+// Compression toggles gzip compression of responses and permessage-deflate
+// on WebSocket messages (on by default). Turn it off when a reverse proxy
+// or CDN in front of the app already compresses.
+func (app *App) Compression(enabled bool) {
+	app.mu.Lock()
+	app.noCompress = !enabled
+	app.mu.Unlock()
+}
+
+// compress wraps next so that text responses are gzip encoded for clients
+// that accept it. WebSocket upgrades and HEAD requests pass through untouched.
+func (app *App) compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.mu.RLock()
+		off := app.noCompress
+		app.mu.RUnlock()
+		if off || r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) ||
+			strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		cw := &compressWriter{ResponseWriter: w, status: http.StatusOK}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.TrimSpace(name)
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// compressible reports whether a Content-Type benefits from compression.
+// Images, archives, fonts and event streams are left alone.
+func compressible(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mt {
+	case "text/event-stream":
+		return false
+	case "application/json", "application/javascript", "application/xml", "image/svg+xml":
+		return true
+	}
+	return strings.HasPrefix(mt, "text/")
+}
+
+// compressWriter buffers the start of a response until it knows whether
+// the body is large enough and of a type worth compressing.
+type compressWriter struct {
+	http.ResponseWriter
+	gz      *gzip.Writer
+	buf     []byte
+	status  int
+	decided bool
+	wrote   bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wrote {
+		return
+	}
+	cw.wrote = true
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.wrote = true
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < compressMinSize {
+			return len(p), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.gz != nil {
+		return cw.gz.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide picks compressed or plain output, writes the headers and drains
+// the buffer. Responses that set their own Content-Encoding, carry a
+// partial body, or are too small are sent as-is.
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	if len(cw.buf) >= compressMinSize && h.Get("Content-Encoding") == "" &&
+		cw.status == http.StatusOK && compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		// The gzipped bytes differ from the file, so a strong ETag for it
+		// becomes weak; If-None-Match still matches either form.
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", "W/"+etag)
+		}
+		cw.gz = gzipPool.Get().(*gzip.Writer)
+		cw.gz.Reset(cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.gz != nil {
+		_, err = cw.gz.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush sends whatever has been written so far, so streaming handlers keep
+// working behind the middleware.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide()
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Hijack hands the raw connection to handlers that need it. Only valid
+// before anything has been written.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if cw.decided {
+		return nil, nil, errors.New("gsui: hijack after response started")
+	}
+	cw.decided = true
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) close() {
+	if !cw.decided && cw.wrote {
+		cw.decide()
+	}
+	if cw.gz != nil {
+		cw.gz.Close()
+		cw.gz.Reset(nil)
+		gzipPool.Put(cw.gz)
+		cw.gz = nil
+	}
+}
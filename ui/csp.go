@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Content Security Policy: per-request script nonces
+// ---------------------------------------------------------------------------
+
+// StrictCSP toggles a nonce-based Content-Security-Policy (off by default).
+// Every page load then gets a fresh random nonce: all <script> tags of the
+// page shell carry it, as do the scripts added with HTMLHead and
+// ctx.HeadJS, and the response sends
+//
+//	script-src 'nonce-…' 'strict-dynamic' 'unsafe-eval'
+//
+// instead of allowing inline script. Markup injected into the page (an
+// unescaped value passed to HTML, say) cannot run script any more, since it
+// cannot know the nonce. 'strict-dynamic' lets the scripts the page loads
+// itself (Tailwind, Captcha) load theirs. Inline event handler attributes
+// (onclick="...") are blocked, so the shell loads its fonts and styles
+// without them. Use ctx.Nonce for a <script> tag of your own.
+//
+// 'unsafe-eval' stays in the policy, because actions answer with
+// JavaScript that the client runs. That weakens the policy: code built
+// from unescaped input in an action's reply, or passed to eval by a page
+// script, still runs. StrictCSP stops injected markup, not injected
+// JavaScript; keep escaping values that go into JavaScript, as Notify and
+// the node builders do.
+func (app *App) StrictCSP(enabled bool) {
+	app.mu.Lock()
+	app.strictCSP = enabled
+	app.mu.Unlock()
+}
+
+// Nonce returns the CSP nonce of the current page load, or "" when
+// StrictCSP is off or outside a page load:
+//
+//	fmt.Sprintf(`<script nonce="%s" src="/chart.js"></script>`, ctx.Nonce())
+func (ctx *Context) Nonce() string {
+	return ctx.nonce
+}
+
+// newNonce returns 128 random bits, base64 encoded.
+func newNonce() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("gsui: crypto/rand failed: " + err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+// cspHeader is the policy sent with a page whose scripts carry nonce.
+// Styles stay inline: Tailwind injects <style> elements at runtime.
+func cspHeader(nonce string) string {
+	return "default-src 'self'; " +
+		"script-src 'nonce-" + nonce + "' 'strict-dynamic' 'unsafe-eval' https: 'self'; " +
+		"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; " +
+		"font-src 'self' https://fonts.gstatic.com data:; " +
+		"img-src 'self' data: blob: https:; " +
+		"connect-src 'self'; " +
+		"frame-src 'self' https:; " +
+		"object-src 'none'; base-uri 'self'"
+}
+
+// stampNonce adds nonce to the start tag of every <script> element in
+// markup. It reads the markup tag by tag, so "<script" inside a comment,
+// an attribute value or the text of a script or style element is left
+// alone.
+func stampNonce(markup, nonce string) string {
+	if nonce == "" {
+		return markup
+	}
+	var b strings.Builder
+	for i := 0; i < len(markup); {
+		j := strings.IndexByte(markup[i:], '<')
+		if j < 0 {
+			b.WriteString(markup[i:])
+			break
+		}
+		b.WriteString(markup[i : i+j])
+		i += j
+		rest := markup[i:]
+		if strings.HasPrefix(rest, "<!--") {
+			end := strings.Index(rest, "-->")
+			if end < 0 {
+				b.WriteString(rest)
+				break
+			}
+			b.WriteString(rest[:end+3])
+			i += end + 3
+			continue
+		}
+		name := tagName(rest[1:])
+		end := tagEnd(rest)
+		if name == "" || end < 0 {
+			b.WriteByte('<')
+			i++
+			continue
+		}
+		name = strings.ToLower(name)
+		if name == "script" {
+			b.WriteString(rest[:7] + ` nonce="` + nonce + `"` + rest[7:end])
+		} else {
+			b.WriteString(rest[:end])
+		}
+		i += end
+		// The text of script and style elements is not markup.
+		if name == "script" || name == "style" {
+			k := indexFold(markup[i:], "</"+name)
+			if k < 0 {
+				b.WriteString(markup[i:])
+				break
+			}
+			b.WriteString(markup[i : i+k])
+			i += k
+		}
+	}
+	return b.String()
+}
+
+// tagName returns the element name s starts with, "" when s does not
+// start with one.
+func tagName(s string) string {
+	n := 0
+	for n < len(s) && ('a' <= s[n]|0x20 && s[n]|0x20 <= 'z' || n > 0 && '0' <= s[n] && s[n] <= '9') {
+		n++
+	}
+	return s[:n]
+}
+
+// tagEnd returns the index just past the ">" closing the tag s starts
+// with, skipping quoted attribute values, or -1.
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// indexFold is strings.Index ignoring ASCII case in sub.
+func indexFold(s, sub string) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Attributes of the shell's external resources that report their load to
+// bootInitJS. Under StrictCSP they are dropped: inline handlers are blocked
+// there, and bootInitJS also notices loads without them.
+const (
+	styleEngineLoadAttrs = ` onload="this.dataset.gsuiLoaded='true'" onerror="this.dataset.gsuiLoaded='error'"`
+	iconFontLoadAttrs    = ` media="print" onload="this.media='all';this.dataset.gsuiLoaded='true'" onerror="this.dataset.gsuiLoaded='error'"`
+)
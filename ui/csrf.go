@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/coder/websocket"
+)
+
+// csrfHeader and csrfField carry the token on HTTP requests. The WebSocket
+// handshake offers it as the subprotocol csrfProtocol+token, which, unlike
+// the URL, stays out of access logs.
+const (
+	csrfHeader   = "X-CSRF-Token"
+	csrfField    = "csrf_token"
+	csrfProtocol = "csrf."
+)
+
+// wsCloseCSRF closes a WebSocket whose handshake carried no valid token.
+// The client reloads on it, so a tab left open across a key change gets a
+// fresh token instead of staying offline.
+const wsCloseCSRF websocket.StatusCode = 4403
+
+// CSRF enables cross-site request forgery protection. Every page gets the
+// session's token in a csrf-token meta tag; the client sends it when
+// opening the WebSocket and on same-origin fetch calls that change state.
+// POST, PUT, PATCH and DELETE requests without a valid token are rejected
+// with 403, and WebSocket connections without one are closed before any
+// action runs. Other clients send the Context.CSRFToken value in the
+// X-CSRF-Token header or a csrf_token field.
+//
+// Tokens are signed with a key made at startup, so a restart invalidates
+// them and open tabs reload once to get new ones. Set CSRFKey to keep
+// tokens valid across restarts and between instances sharing sessions.
+func (app *App) CSRF(enabled bool) {
+	app.mu.Lock()
+	app.csrf = enabled
+	app.mu.Unlock()
+}
+
+// CSRFKey sets the secret CSRF tokens are signed with: at least 32 random
+// bytes, the same on every instance and kept across deploys, e.g. read
+// from the environment. Shorter keys are refused with an error logged.
+func (app *App) CSRFKey(key []byte) {
+	if len(key) < 32 {
+		app.log().Errorf("CSRFKey: key has %d bytes, want at least 32; keeping the current key", len(key))
+		return
+	}
+	app.mu.Lock()
+	app.csrfKey = append([]byte(nil), key...)
+	app.mu.Unlock()
+}
+
+func (app *App) csrfEnabled() bool {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	return app.csrf
+}
+
+// csrfToken derives the token of session sid. Tokens are an HMAC of the
+// session ID under the app's key, so they need no storage and stay valid
+// for the life of the session, or until the key changes.
+func (app *App) csrfToken(sid string) string {
+	if sid == "" {
+		return ""
+	}
+	app.mu.RLock()
+	key := app.csrfKey
+	app.mu.RUnlock()
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("csrf:" + sid))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validCSRF reports whether token belongs to the session of r.
+func (app *App) validCSRF(r *http.Request, token string) bool {
+	want := app.csrfToken(requestSessionID(r))
+	return want != "" && hmac.Equal([]byte(token), []byte(want))
+}
+
+// CSRFToken returns the CSRF token of the current session. Include it in
+// hand-written forms (as csrf_token) or fetch calls (as X-CSRF-Token) that
+// target routes registered with POST, PUT, PATCH or DELETE.
+func (ctx *Context) CSRFToken() string {
+	if ctx.app == nil {
+		return ""
+	}
+	return ctx.app.csrfToken(ctx.useSession())
+}
+
+// csrfMetaTag returns the <meta> tag the client reads the token from, or
+// "" when protection is off.
+func (app *App) csrfMetaTag(sid string) string {
+	if !app.csrfEnabled() {
+		return ""
+	}
+	return `<meta name="csrf-token" content="` + html.EscapeString(app.csrfToken(sid)) + `">`
+}
+
+// checkCSRF wraps next and rejects state-changing requests that do not
+// carry the session's token. Safe methods pass through.
+func (app *App) checkCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !app.csrfEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token := r.Header.Get(csrfHeader)
+		if token == "" {
+			token = r.FormValue(csrfField)
+		}
+		if !app.validCSRF(r, token) {
+			http.Error(w, "invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wsCSRFProtocol returns the subprotocol carrying the CSRF token among
+// those the WebSocket handshake r offers, or "".
+func wsCSRFProtocol(r *http.Request) string {
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for p := range strings.SplitSeq(v, ",") {
+			if p = strings.TrimSpace(p); strings.HasPrefix(p, csrfProtocol) {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+func newCSRFKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("gsui: crypto/rand failed: " + err.Error())
+	}
+	return key
+}
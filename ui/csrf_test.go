@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+)
+
+func TestCSRFRejectsPostsWithoutToken(t *testing.T) {
+	app := NewApp()
+	app.CSRF(true)
+	app.POST("/save", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("saved"))
+	})
+	h := app.Handler()
+	sid := newSessionID()
+	token := app.csrfToken(sid)
+
+	post := func(body string, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/save", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Cookie", sessionCookie+"="+sid)
+		if header != "" {
+			req.Header.Set(csrfHeader, header)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post("", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("missing token: status %d, want 403", rr.Code)
+	}
+	if rr := post("", app.csrfToken(newSessionID())); rr.Code != http.StatusForbidden {
+		t.Fatalf("token of another session: status %d, want 403", rr.Code)
+	}
+	if rr := post("", token); rr.Code != http.StatusOK {
+		t.Fatalf("header token: status %d, want 200", rr.Code)
+	}
+	if rr := post(csrfField+"="+url.QueryEscape(token), ""); rr.Code != http.StatusOK {
+		t.Fatalf("form token: status %d, want 200", rr.Code)
+	}
+}
+
+func TestCSRFTokenInPageAndWSHandshake(t *testing.T) {
+	app := NewApp()
+	app.CSRF(true)
+	var token string
+	app.Page("/", func(ctx *Context) *Node {
+		token = ctx.CSRFToken()
+		return Div()
+	})
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	var sid string
+	for _, c := range resp.Cookies() {
+		if c.Name == sessionCookie {
+			sid = c.Value
+		}
+	}
+	if token == "" || token != app.csrfToken(sid) {
+		t.Fatalf("CSRFToken() = %q, want token of session %q", token, sid)
+	}
+
+	// The handshake offers the token as a subprotocol; a missing or stale
+	// token closes the connection with the code the client reloads on.
+	dial := func(proto string) error {
+		h := http.Header{"Cookie": {sessionCookie + "=" + sid}}
+		if proto != "" {
+			h.Set("Sec-WebSocket-Protocol", proto)
+		}
+		ws, err := dialWS(server, "/__ws", h)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+		if err := ws.send(`{"act":"__ping"}`); err != nil {
+			return err
+		}
+		var raw string
+		return ws.receive(&raw)
+	}
+	for _, proto := range []string{"", csrfProtocol + app.csrfToken(newSessionID())} {
+		if err := dial(proto); websocket.CloseStatus(err) != wsCloseCSRF {
+			t.Fatalf("handshake with %q: %v, want close %d", proto, err, wsCloseCSRF)
+		}
+	}
+	if err := dial(csrfProtocol + token); err != nil {
+		t.Fatalf("handshake with token: %v", err)
+	}
+}
+
+func TestCSRFKeyKeepsTokensAcrossApps(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	a, b := NewApp(), NewApp()
+	if a.csrfToken("abc") == b.csrfToken("abc") {
+		t.Fatal("apps without CSRFKey must not share tokens")
+	}
+	a.CSRFKey(key)
+	b.CSRFKey(key)
+	if a.csrfToken("abc") != b.csrfToken("abc") {
+		t.Fatal("apps with the same CSRFKey must accept each other's tokens")
+	}
+	b.CSRFKey([]byte("short"))
+	if a.csrfToken("abc") != b.csrfToken("abc") {
+		t.Fatal("a short key must be refused")
+	}
+	expect(t, wsClientJS, "csrf?['csrf.'+csrf]:[]")
+	notExpect(t, wsClientJS, "?csrf_token=")
+}
+
+func TestCSRFMetaTagOnlyWhenEnabled(t *testing.T) {
+	app := NewApp()
+	if tag := app.csrfMetaTag("abc"); tag != "" {
+		t.Fatalf("disabled CSRF emitted %q", tag)
+	}
+	app.CSRF(true)
+	if tag := app.csrfMetaTag("abc"); !strings.Contains(tag, app.csrfToken("abc")) {
+		t.Fatalf("meta tag %q lacks token", tag)
+	}
+}
@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Decimal: exact fixed-point numbers for money and other amounts
+// ---------------------------------------------------------------------------
+
+// maxDecimalScale bounds the digits after the point; 10^18 still fits int64.
+const maxDecimalScale = 18
+
+// Decimal is an exact fixed-point number: Units / 10^Scale. Use it for
+// struct fields bound with ctx.Body when floats would round, e.g. prices.
+// It decodes from JSON strings and numbers without passing through float64,
+// keeps trailing zeros ("12.50" stays at scale 2) and encodes as a JSON
+// string. The zero value is 0.
+type Decimal struct {
+	units int64
+	scale uint8
+}
+
+// NewDecimal returns units / 10^scale, so NewDecimal(1250, 2) is 12.50.
+// It panics if scale is outside 0..18.
+func NewDecimal(units int64, scale int) Decimal {
+	if scale < 0 || scale > maxDecimalScale {
+		panic("gsui: decimal scale out of range")
+	}
+	return Decimal{units: units, scale: uint8(scale)}
+}
+
+// ParseDecimal parses an amount as typed by a user. Spaces, non-breaking
+// spaces, apostrophes and underscores are digit grouping. When both '.' and
+// ',' occur the later one is the decimal separator ("1.234,50",
+// "1,234.50"); a lone '.' is always decimal, and a lone ',' is decimal
+// unless exactly three digits follow it ("1,234" is 1234, "12,5" is 12.5).
+// A leading '-' or '+' sets the sign. Errors report malformed input or more
+// than 18 significant digits.
+func ParseDecimal(s string) (Decimal, error) {
+	in := s
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'', '_':
+			return -1
+		}
+		return r
+	}, s)
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "" {
+		return Decimal{}, fmt.Errorf("gsui: invalid decimal %q", in)
+	}
+
+	sep := byte(0)
+	dot, comma := strings.LastIndexByte(s, '.'), strings.LastIndexByte(s, ',')
+	switch {
+	case dot >= 0 && comma >= 0:
+		sep = '.'
+		if comma > dot {
+			sep = ','
+		}
+	case dot >= 0:
+		if strings.IndexByte(s, '.') == dot {
+			sep = '.'
+		}
+	case comma >= 0:
+		if strings.IndexByte(s, ',') == comma && len(s)-comma-1 != 3 {
+			sep = ','
+		}
+	}
+	intPart, frac := s, ""
+	if sep != 0 {
+		i := strings.LastIndexByte(s, sep)
+		intPart, frac = s[:i], s[i+1:]
+	}
+	intPart = strings.NewReplacer(".", "", ",", "").Replace(intPart)
+	if intPart == "" && frac == "" {
+		return Decimal{}, fmt.Errorf("gsui: invalid decimal %q", in)
+	}
+	if len(frac) > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("gsui: decimal %q has more than %d fractional digits", in, maxDecimalScale)
+	}
+	digits := intPart + frac
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return Decimal{}, fmt.Errorf("gsui: invalid decimal %q", in)
+		}
+	}
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("gsui: decimal %q out of range", in)
+	}
+	if neg {
+		units = -units
+	}
+	return Decimal{units: units, scale: uint8(len(frac))}, nil
+}
+
+// MustDecimal is ParseDecimal for constants; it panics on error.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Units returns the unscaled integer, e.g. 1250 for 12.50.
+func (d Decimal) Units() int64 { return d.units }
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int { return int(d.scale) }
+
+// Sign returns -1, 0 or +1.
+func (d Decimal) Sign() int {
+	switch {
+	case d.units < 0:
+		return -1
+	case d.units > 0:
+		return 1
+	}
+	return 0
+}
+
+// Float64 returns the nearest float64, for display or charts only.
+func (d Decimal) Float64() float64 {
+	return float64(d.units) / math.Pow10(int(d.scale))
+}
+
+// String formats d with '.' as separator and its full scale ("-0.50").
+func (d Decimal) String() string {
+	neg := d.units < 0
+	u := d.units
+	if neg {
+		u = -u
+	}
+	s := strconv.FormatUint(uint64(u), 10)
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(s); pad > 0 {
+			s = strings.Repeat("0", pad) + s
+		}
+		s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalJSON encodes d as a JSON string so no client rounds it.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a JSON string (parsed by ParseDecimal), a JSON
+// number literal, or null. Empty strings and null leave d at zero.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	var s string
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			*d = Decimal{}
+			return nil
+		}
+	} else {
+		s = string(b)
+		if strings.ContainsAny(s, "eE") {
+			return errors.New("gsui: decimal in exponent notation")
+		}
+	}
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"12.50", "12.50"},
+		{"1,234.50", "1234.50"},
+		{"1.234,50", "1234.50"},
+		{"1 234,5", "1234.5"},
+		{"1,234", "1234"},
+		{"12,5", "12.5"},
+		{"1.234.567", "1234567"},
+		{"-0.05", "-0.05"},
+		{"+.5", "0.5"},
+		{"0.100", "0.100"},
+	}
+	for _, c := range cases {
+		d, err := ParseDecimal(c.in)
+		if err != nil || d.String() != c.want {
+			t.Errorf("ParseDecimal(%q) = %s, %v; want %s", c.in, d, err, c.want)
+		}
+	}
+	for _, bad := range []string{"", "-", "1e3", "12a", "99999999999999999999", "0.1234567890123456789"} {
+		if _, err := ParseDecimal(bad); err == nil {
+			t.Errorf("ParseDecimal(%q) should fail", bad)
+		}
+	}
+	if d := NewDecimal(-5, 3); d.String() != "-0.005" || d.Sign() != -1 || d.Units() != -5 || d.Scale() != 3 {
+		t.Errorf("NewDecimal(-5, 3) = %s", d)
+	}
+}
+
+func TestDecimalBindsWithoutFloatRounding(t *testing.T) {
+	var data struct {
+		Price Decimal
+		Tax   Decimal
+		Fee   Decimal
+		None  Decimal
+	}
+	ctx := &Context{wsData: map[string]any{"Price": "19 999 999 999,99", "Tax": json.Number("0.10"), "Fee": "", "None": nil}}
+	if err := ctx.Body(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Price.String() != "19999999999.99" || data.Tax.String() != "0.10" || data.Fee.Sign() != 0 {
+		t.Fatalf("bound %s %s %s", data.Price, data.Tax, data.Fee)
+	}
+	out, _ := json.Marshal(data.Price)
+	if string(out) != `"19999999999.99"` {
+		t.Fatalf("MarshalJSON = %s", out)
+	}
+	ctx.wsData = map[string]any{"Price": "12,34,5x"}
+	if err := ctx.Body(&data); err == nil {
+		t.Fatal("malformed amount must fail binding")
+	}
+}
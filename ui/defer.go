@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Defer: slow sections filled in over the WebSocket
+// ---------------------------------------------------------------------------
+
+// deferTTL is how long a deferred section waits for its page to connect.
+const deferTTL = time.Minute
+
+// deferStore holds the sections registered by Context.Defer until the
+// client asks for them.
+type deferStore struct {
+	mu      sync.Mutex
+	pending map[string]*deferredFill
+}
+
+type deferredFill struct {
+	fn      func(*Context) *Node
+	sid     string
+	expires time.Time
+}
+
+// Defer renders skeleton in place of a slow section and fills it in once
+// the page's WebSocket connects: fn runs in a goroutine and its node
+// replaces the skeleton. The page is served without waiting for fn.
+//
+//	app.Page("/dashboard", func(ctx *ui.Context) *ui.Node {
+//		return ui.Div().Render(
+//			ui.H1().Text("Dashboard"),
+//			ctx.Defer(ui.SkeletonTable(), func(ctx *ui.Context) *ui.Node {
+//				return reportTable(loadReport()) // takes seconds
+//			}),
+//		)
+//	})
+//
+// fn receives the Context of the WebSocket connection, with the session of
+// the page; changes to ctx.Session are not saved. If the visitor navigates
+// away first the result is dropped, and a page that never connects lets
+// the section expire after a minute. A panic in fn is logged and leaves
+// the skeleton in place.
+func (ctx *Context) Defer(skeleton *Node, fn func(ctx *Context) *Node) *Node {
+	id := Target()
+	if ctx.app != nil {
+		ctx.app.deferred.add(id, ctx.useSession(), fn)
+	}
+	return Div().ID(id).Attr("aria-busy", "true").Render(skeleton).
+		JS("__ws.callSilent('__defer',{id:'" + escJS(id) + "'})")
+}
+
+func (s *deferStore) add(id, sid string, fn func(*Context) *Node) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil {
+		s.pending = make(map[string]*deferredFill)
+	}
+	for k, d := range s.pending {
+		if now.After(d.expires) {
+			delete(s.pending, k)
+		}
+	}
+	s.pending[id] = &deferredFill{fn: fn, sid: sid, expires: now.Add(deferTTL)}
+}
+
+// take removes and returns the section id registered for session sid.
+func (s *deferStore) take(id, sid string) *deferredFill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.pending[id]
+	if d == nil || d.sid != sid || time.Now().After(d.expires) {
+		return nil
+	}
+	delete(s.pending, id)
+	return d
+}
+
+// fillDeferred is the built-in __defer action: it starts building the
+// section the client asked for and pushes it when done.
+func (app *App) fillDeferred(ctx *Context) string {
+	var req struct {
+		ID string `json:"id"`
+	}
+	ctx.Body(&req)
+	d := app.deferred.take(req.ID, ctx.sessionID)
+	if d == nil {
+		return ""
+	}
+	parent := ctx.pushCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	go func() {
+		defer app.bindCtx(ctx, parent)()
+		defer func() {
+			if r := recover(); r != nil {
+				ctx.log().Errorf("panic in deferred section: %v\n%s", r, debug.Stack())
+			}
+		}()
+		node := d.fn(ctx)
+		if node == nil {
+			node = Fragment()
+		}
+		// Push fails once the visitor has left the page; the replace
+		// itself reports a missing target through __ws.notfound.
+		ctx.Push(node.ToJSReplace(req.ID))
+	}()
+	return ""
+}
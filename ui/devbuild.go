@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"time"
+)
+
+// buildLogPoll is how often WatchBuildLog looks at the build log.
+const buildLogPoll = 500 * time.Millisecond
+
+// WatchBuildLog reports development rebuilds to every open page. path is
+// a file the rebuild writes its compiler errors to, left empty when a
+// build succeeds; the repository's ./run script passes one to the app as
+// GSUI_BUILD_LOG:
+//
+//	app.WatchBuildLog(os.Getenv("GSUI_BUILD_LOG"))
+//
+// When a build fails the running app keeps serving, and pages get an
+// error toast with the first compiler errors that stays until closed.
+// When the next build succeeds they get a short "reloading" toast; the
+// new process then takes over and the pages reload as their WebSocket
+// reconnects. An empty path does nothing, so the call can stay in
+// production code.
+func (app *App) WatchBuildLog(path string) {
+	if path == "" {
+		return
+	}
+	last, _ := os.ReadFile(path)
+	go app.watchBuildLog(path, last, buildLogPoll, nil)
+}
+
+// watchBuildLog polls path every interval until stop is closed. last is
+// the content already seen.
+func (app *App) watchBuildLog(path string, last []byte, every time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		cur, err := os.ReadFile(path)
+		if err != nil || bytes.Equal(cur, last) {
+			continue
+		}
+		failed := len(bytes.TrimSpace(last)) > 0
+		last = cur
+		if msg := buildErrors(cur); msg != "" {
+			app.log().Warnf("build failed: %s", msg)
+			app.Broadcast(NotifyOpts("error", "Build failed: "+msg, ToastOptions{Duration: -1}))
+		} else if failed {
+			app.Broadcast(Notify("success", "Build fixed, reloading…"))
+		}
+	}
+}
+
+// buildErrors sums up go build output: its first three error lines,
+// without the "# package" headers.
+func buildErrors(out []byte) string {
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if len(lines) == 3 {
+			lines = append(lines, "…")
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "; ")
+}
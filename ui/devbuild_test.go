@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchBuildLogReportsBuilds(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "build.log")
+	if err := os.WriteFile(log, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp()
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+	ws := dialSession(t, server, newSessionID())
+	defer ws.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go app.watchBuildLog(log, nil, 10*time.Millisecond, stop)
+
+	next := func() string {
+		t.Helper()
+		ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var raw string
+		if err := ws.receive(&raw); err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	os.WriteFile(log, []byte("# example\nexample/main.go:12:2: undefined: x\n"), 0o644)
+	js := next()
+	expect(t, js, "Build failed: example/main.go:12:2: undefined: x")
+	expect(t, js, "d:-1")
+
+	os.WriteFile(log, nil, 0o644)
+	expect(t, next(), "Build fixed")
+}
+
+func TestBuildErrorsKeepsFirstLines(t *testing.T) {
+	out := "# a\na.go:1: one\na.go:2: two\n# b\nb.go:3: three\nb.go:4: four\n"
+	if got := buildErrors([]byte(out)); got != "a.go:1: one; a.go:2: two; b.go:3: three; …" {
+		t.Fatalf("buildErrors = %q", got)
+	}
+	if got := buildErrors([]byte("\n")); got != "" {
+		t.Fatalf("buildErrors(empty) = %q", got)
+	}
+}
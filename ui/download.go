@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Downloads
+// ---------------------------------------------------------------------------
+
+// CSVOpt configures CSV and Context.DownloadCSV.
+type CSVOpt struct {
+	// BOM prepends a UTF-8 byte order mark so Excel opens non-ASCII text
+	// with the right encoding.
+	BOM bool
+	// Comma is the field delimiter; defaults to ','. Some Excel locales
+	// expect ';'.
+	Comma rune
+}
+
+// CSV encodes headers followed by rows as CSV. Fields containing the
+// delimiter, quotes or newlines are quoted and inner quotes doubled. A nil
+// or empty headers slice omits the header line.
+func CSV(headers []string, rows [][]string, opts ...CSVOpt) ([]byte, error) {
+	var o CSVOpt
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	var buf bytes.Buffer
+	if o.BOM {
+		buf.WriteString("\ufeff")
+	}
+	w := csv.NewWriter(&buf)
+	if o.Comma != 0 {
+		w.Comma = o.Comma
+	}
+	if len(headers) > 0 {
+		if err := w.Write(headers); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DownloadCSV builds a CSV file from headers and rows and pushes it to this
+// client as a download, reusing the Download script. It must be called from
+// a WS action; the handler can return "" or a toast afterwards.
+//
+// The file travels base64-encoded inside the WS message, which is fine for
+// exports of a few megabytes. Serve larger files from a GET route with
+// ServeDownload instead.
+//
+//	app.Action("orders.export", func(ctx *ui.Context) string {
+//	    if err := ctx.DownloadCSV("orders.csv", headers, rows, ui.CSVOpt{BOM: true}); err != nil {
+//	        return ui.Notify("error", "Export failed")
+//	    }
+//	    return ""
+//	})
+func (ctx *Context) DownloadCSV(filename string, headers []string, rows [][]string, opts ...CSVOpt) error {
+	if err := checkFilename(filename); err != nil {
+		return err
+	}
+	data, err := CSV(headers, rows, opts...)
+	if err != nil {
+		return err
+	}
+	return ctx.Push(Download(filename, "text/csv;charset=utf-8", base64.StdEncoding.EncodeToString(data)))
+}
+
+// DownloadJSON encodes v as indented JSON and pushes it to this client as a
+// download, like DownloadCSV.
+//
+//	app.Action("settings.export", func(ctx *ui.Context) string {
+//	    if err := ctx.DownloadJSON("settings.json", settings); err != nil {
+//	        return ui.Notify("error", "Export failed")
+//	    }
+//	    return ""
+//	})
+func (ctx *Context) DownloadJSON(filename string, v any) error {
+	if err := checkFilename(filename); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return ctx.Push(Download(filename, "application/json;charset=utf-8", base64.StdEncoding.EncodeToString(data)))
+}
+
+// DownloadText pushes content to this client as a plain text download, like
+// DownloadCSV.
+func (ctx *Context) DownloadText(filename, content string) error {
+	if err := checkFilename(filename); err != nil {
+		return err
+	}
+	return ctx.Push(Download(filename, "text/plain;charset=utf-8", base64.StdEncoding.EncodeToString([]byte(content))))
+}
+
+// checkFilename rejects download names that are empty or contain a path.
+// Browsers strip directories themselves; an error here points at a name
+// built from a path by mistake.
+func checkFilename(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("gsui: invalid download filename %q", name)
+	}
+	return nil
+}
+
+// ServeDownload streams src to w as a file attachment. Use it in GET routes
+// registered with App.GET for large or generated files: the bytes are copied
+// straight to the response instead of being base64-encoded into a WS
+// message and the DOM, as Download and DownloadCSV do.
+//
+// An empty contentType defaults to application/octet-stream. src is not
+// closed. It takes the ResponseWriter rather than a Context because
+// actions answer over the WebSocket and have no HTTP response to stream to. An action can start the download with Redirect to the route's
+// URL; the current page stays in place because the response is an
+// attachment.
+//
+//	app.GET("/reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+//	    f, err := os.Open(reportPath(r.PathValue("id")))
+//	    if err != nil {
+//	        http.NotFound(w, r)
+//	        return
+//	    }
+//	    defer f.Close()
+//	    ui.ServeDownload(w, f, "text/csv", "report.csv")
+//	})
+func ServeDownload(w http.ResponseWriter, src io.Reader, contentType, filename string) error {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	if cd := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); cd != "" {
+		h.Set("Content-Disposition", cd)
+	} else {
+		h.Set("Content-Disposition", "attachment")
+	}
+	_, err := io.Copy(w, src)
+	return err
+}
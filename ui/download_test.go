@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Download helper tests
+// ---------------------------------------------------------------------------
+
+func TestCSVQuotesSpecialFields(t *testing.T) {
+	got, err := CSV([]string{"Name", "Note"}, [][]string{
+		{"Doe, John", `says "hi"`},
+		{"multi\nline", "plain"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Name,Note\n\"Doe, John\",\"says \"\"hi\"\"\"\n\"multi\nline\",plain\n"
+	if string(got) != want {
+		t.Fatalf("CSV() = %q, want %q", got, want)
+	}
+}
+
+func TestCSVBOMAndDelimiter(t *testing.T) {
+	got, err := CSV(nil, [][]string{{"a;b", "c"}}, CSVOpt{BOM: true, Comma: ';'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(got), "\ufeff") {
+		t.Fatal("expected UTF-8 BOM prefix")
+	}
+	if want := "\ufeff\"a;b\";c\n"; string(got) != want {
+		t.Fatalf("CSV() = %q, want %q", got, want)
+	}
+}
+
+func TestDownloadCSVRequiresWebSocket(t *testing.T) {
+	ctx := &Context{}
+	if err := ctx.DownloadCSV("x.csv", []string{"a"}, nil); err == nil {
+		t.Fatal("expected error outside a WS action")
+	}
+}
+
+func TestDownloadJSONAndTextPushPrettyFiles(t *testing.T) {
+	app := NewApp()
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("export", func(ctx *Context) string {
+		ctx.DownloadJSON("data.json", map[string]any{"name": "Ana", "tags": []string{"a"}})
+		ctx.DownloadText("notes.txt", "line 1\nčaj")
+		return ""
+	})
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+	ws := dialSession(t, server, newSessionID())
+	defer ws.Close()
+	if err := ws.send(`{"act":"export"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := func(mime, name string) string {
+		t.Helper()
+		var raw string
+		if err := ws.receive(&raw); err != nil {
+			t.Fatal(err)
+		}
+		_, rest, ok := strings.Cut(raw, "a.href='data:"+mime+";base64,")
+		if !ok || !strings.Contains(raw, "a.download='"+name+"'") {
+			t.Fatalf("download frame = %q", raw)
+		}
+		enc, _, _ := strings.Cut(rest, "'")
+		data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(enc, `\u003d`, "="))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if got, want := payload("application/json;charset\\u003dutf-8", "data.json"), "{\n  \"name\": \"Ana\",\n  \"tags\": [\n    \"a\"\n  ]\n}\n"; got != want {
+		t.Fatalf("JSON = %q, want %q", got, want)
+	}
+	if got := payload("text/plain;charset\\u003dutf-8", "notes.txt"); got != "line 1\nčaj" {
+		t.Fatalf("text = %q", got)
+	}
+}
+
+func TestDownloadRejectsPathsInFilenames(t *testing.T) {
+	ctx := &Context{}
+	for _, name := range []string{"", "..", "../etc/passwd", "dir/a.json", `C:\\a.txt`} {
+		if err := ctx.DownloadJSON(name, 1); err == nil || !strings.Contains(err.Error(), "filename") {
+			t.Fatalf("DownloadJSON(%q) err = %v", name, err)
+		}
+		if err := ctx.DownloadText(name, ""); err == nil || !strings.Contains(err.Error(), "filename") {
+			t.Fatalf("DownloadText(%q) err = %v", name, err)
+		}
+	}
+	if err := ctx.DownloadText("ok.txt", "x"); err == nil || strings.Contains(err.Error(), "filename") {
+		t.Fatalf("valid name: err = %v, want the missing-connection error", err)
+	}
+}
+
+func TestServeDownloadStreamsAttachment(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := ServeDownload(rec, strings.NewReader("a,b\n"), "text/csv", "report 2024.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="report 2024.csv"` {
+		t.Fatalf("Content-Disposition = %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/csv" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	if rec.Body.String() != "a,b\n" {
+		t.Fatalf("body = %q", rec.Body.String())
+	}
+}
+
+func TestServeDownloadEncodesNonASCIIFilename(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := ServeDownload(rec, strings.NewReader("x"), "", "výkaz.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, rec.Header().Get("Content-Disposition"), "filename*=utf-8''v%C3%BDkaz.pdf")
+	if got := rec.Header().Get("Content-Type"); got != "application/octet-stream" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+}
+
+func TestServeDownloadFromGETRoute(t *testing.T) {
+	app := NewApp()
+	app.GET("/reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ServeDownload(w, strings.NewReader("report "+r.PathValue("id")), "text/csv", "report.csv")
+	})
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/reports/42", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "report 42" {
+		t.Fatalf("GET /reports/42: %d %q", rec.Code, rec.Body.String())
+	}
+}
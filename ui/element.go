@@ -10,12 +10,17 @@
 package ui
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"html"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // ---------------------------------------------------------------------------
@@ -28,12 +33,15 @@ type Node struct {
 	id       string
 	class    string
 	text     string
+	html     string // trusted markup set as innerHTML, see HTML
 	attrs    map[string]string
 	styles   map[string]string
 	children []*Node
 	events   map[string]*Action
-	rawJS    string // arbitrary JS executed after this node is mounted
-	void     bool   // self-closing element (input, img, br, hr)
+	rawJS    string       // arbitrary JS executed after this node is mounted
+	void     bool         // self-closing element (input, img, br, hr)
+	fragment bool         // children only, no element of its own
+	lazy     func() *Node // built when compiled, see Lazy
 }
 
 // Action describes a server-side handler invoked via WebSocket,
@@ -42,7 +50,12 @@ type Action struct {
 	Name    string         // e.g. "counter.increment"
 	Data    map[string]any // state payload sent with the call
 	Collect []string       // element IDs whose .value to collect before calling
+	Confirm string         // if set, ask the user with confirm() first; cancel aborts
+	Busy    bool           // spinner on the triggering element instead of the page loader
 	rawJS   string         // if set, execute client-side JS instead of WS call
+	undoJS  string         // optimistic update, see Optimistic
+	json    bool           // reply goes to the page as data, see Action.JSON
+	retry   bool           // resent after a reconnect, see Retry
 }
 
 // JS creates a client-side-only Action that executes raw JavaScript
@@ -55,6 +68,41 @@ func JS(code string) *Action {
 	return &Action{rawJS: code}
 }
 
+// Optimistic sets JS that applies the expected result of the call at once,
+// before the server answers. It runs with this set to the element that
+// triggered the action and may return a function that undoes it; that
+// function is called if the call fails: the handler panics, the action is
+// unknown or rate limited, or the connection drops before the reply. On
+// success the reply's JS confirms the change, typically by rendering the
+// real state over it.
+//
+//	ui.Button().Text("+").OnClick(ctx.Action("counter.inc", inc).Optimistic(
+//		"var c=document.getElementById('count'),n=+c.textContent;" +
+//			"c.textContent=n+1;return function(){c.textContent=n}"))
+//
+// Client-side actions (JS) ignore it.
+func (a *Action) Optimistic(js string) *Action {
+	a.undoJS = js
+	return a
+}
+
+// Retry marks the call safe to send twice. When the connection drops
+// before the reply arrives, the client keeps the call, counts it as
+// pending on the offline overlay and sends it again once it reconnects,
+// rather than undoing it as failed. Calls made while offline are kept the
+// same way. The page reloads after the last pending reply, as after any
+// reconnect. Use it for idempotent handlers only, ones that set a value
+// rather than add to it: a call that reached the server before the drop
+// runs again.
+//
+//	ui.Button().Text("Save").OnClick(ctx.Action("settings.save", save).Retry())
+//
+// Client-side actions (JS) ignore it.
+func (a *Action) Retry() *Action {
+	a.retry = true
+	return a
+}
+
 // ---------------------------------------------------------------------------
 // Constructors
 // ---------------------------------------------------------------------------
@@ -185,6 +233,7 @@ func ISearch(class ...string) *Node   { return Input(class...).Attr("type", "sea
 func IUrl(class ...string) *Node      { return Input(class...).Attr("type", "url") }
 func IDate(class ...string) *Node     { return Input(class...).Attr("type", "date") }
 func IMonth(class ...string) *Node    { return Input(class...).Attr("type", "month") }
+func IWeek(class ...string) *Node     { return Input(class...).Attr("type", "week") }
 func ITime(class ...string) *Node     { return Input(class...).Attr("type", "time") }
 func IDatetime(class ...string) *Node { return Input(class...).Attr("type", "datetime-local") }
 func IFile(class ...string) *Node     { return Input(class...).Attr("type", "file") }
@@ -224,9 +273,25 @@ func (n *Node) Class(cls string) *Node {
 	return n
 }
 
-// Text sets the textContent.
+// Text sets the textContent. The value is always shown literally: markup
+// in it is displayed, never parsed, so user input is safe here. The same
+// holds for Attr, Style, ID and Class, which are set through the DOM API.
 func (n *Node) Text(t string) *Node { n.text = t; return n }
 
+// HTML sets the element's content from a markup string (innerHTML). It is
+// the only builder that parses HTML, and it does not escape anything.
+// Children added with Render follow the markup.
+//
+// This is a trusted raw API: never pass untrusted/user-controlled input to
+// it. Escape values you interpolate with EscapeHTML:
+//
+//	Div().HTML("<b>" + ui.EscapeHTML(user.Name) + "</b> joined")
+func (n *Node) HTML(markup string) *Node { n.html = markup; return n }
+
+// EscapeHTML escapes <, >, &, ' and " so s can be placed in markup for HTML,
+// inside element content or a quoted attribute value.
+func EscapeHTML(s string) string { return html.EscapeString(s) }
+
 // Attr sets an arbitrary HTML attribute.
 func (n *Node) Attr(key, val string) *Node {
 	if n.attrs == nil {
@@ -236,6 +301,21 @@ func (n *Node) Attr(key, val string) *Node {
 	return n
 }
 
+// Keep marks the element to survive swaps of the region around it: when a
+// navigation or an Inner or Replace swap brings an element with the same
+// ID that is also marked Keep, the element already on the page stays in
+// its place with everything in it: typed input, scroll position, an open
+// modal. A new page without that ID drops it. The element needs an ID.
+//
+//	ui.Aside().ID("filters").Keep().Render(filterForm)
+func (n *Node) Keep() *Node { return n.Attr("data-gsui-keep", "") }
+
+// Seconds makes a time or datetime-local input show and submit seconds
+// ("15:04:05") by setting step to one second; ctx.Body binds them.
+//
+//	ui.ITime().Attr("name", "LoggedAt").Seconds()
+func (n *Node) Seconds() *Node { return n.Attr("step", "1") }
+
 // Style sets an inline style property.
 func (n *Node) Style(key, val string) *Node {
 	if n.styles == nil {
@@ -316,6 +396,31 @@ func Map[T any](items []T, fn func(T, int) *Node) []*Node {
 	return out
 }
 
+// ---------------------------------------------------------------------------
+// Fragments and lazy nodes
+// ---------------------------------------------------------------------------
+
+// Fragment groups nodes without a wrapper element: its children are
+// inserted where the fragment goes, like a DocumentFragment. Use it for
+// components that return several siblings. Only children apply; class,
+// attributes, events and JS set on a fragment are ignored.
+//
+//	Ul().Render(Fragment(Li().Text("a"), Li().Text("b")), Li().Text("c"))
+func Fragment(children ...*Node) *Node {
+	return (&Node{fragment: true}).Render(children...)
+}
+
+// Lazy returns a node whose content is built by fn only when the tree is
+// compiled, and again on every compile. Combine it with If, Or or a slow
+// data source so the work is skipped for parts of the page that are not
+// shown. A nil result renders nothing. The layout's __content__ slot must
+// not be inside a Lazy node.
+//
+//	Or(ctx.QueryBool("details"), Lazy(func() *Node { return reportTable(db) }), nil)
+func Lazy(fn func() *Node) *Node {
+	return &Node{lazy: fn}
+}
+
 // ---------------------------------------------------------------------------
 // ID generation
 // ---------------------------------------------------------------------------
@@ -334,140 +439,196 @@ func Target() string {
 // JS Compilation
 // ---------------------------------------------------------------------------
 
+// busyJS returns the snippet that marks the triggering element busy until
+// the reply arrives, plus the extra __ws.call options argument. Clicked
+// buttons are always disabled; Action.Busy additionally prepends a spinner,
+// works for any event, and suppresses the page-wide loader for the call.
+// The WS client clears every .gsui-busy element when a reply arrives, so an
+// element removed by the reply's swap simply disappears with its spinner.
+func busyJS(event string, action *Action) (busy, opts string) {
+	if action.Busy {
+		return "var b=event.currentTarget;if(b&&!b.classList.contains('gsui-busy')){" +
+			"if(b.tagName==='BUTTON')b.disabled=true;b.setAttribute('aria-busy','true');" +
+			"b.classList.add('gsui-busy','opacity-60','cursor-wait');" +
+			"var sp=document.createElement('span');sp.className='gsui-spin inline-block w-4 h-4 mr-2 align-middle rounded-full border-2 border-current border-r-transparent animate-spin';" +
+			"sp.setAttribute('aria-hidden','true');b.insertBefore(sp,b.firstChild)}", ",{quiet:true}"
+	}
+	if event == "click" {
+		return "var b=event.currentTarget;if(b&&b.tagName==='BUTTON'&&!b.disabled){b.disabled=true;b.classList.add('gsui-busy','opacity-60','cursor-wait')}", ""
+	}
+	return "", ""
+}
+
+// callOpt adds the key:value pair kv to the __ws.call options argument
+// opts, which is empty or ",{...}".
+func callOpt(opts, kv string) string {
+	if opts == "" {
+		return ",{" + kv + "}"
+	}
+	return strings.TrimSuffix(opts, "}") + "," + kv + "}"
+}
+
 // ToJS compiles the node tree into a self-executing JavaScript function
 // that builds and appends the entire tree to document.body.
 func (n *Node) ToJS() string {
-	var b strings.Builder
-	b.WriteString("(function(){")
-	counter := 0
-	var postJS []string
-	root := n.compile(&b, &counter, &postJS)
-	fmt.Fprintf(&b, "document.body.appendChild(%s);", root)
-	for _, js := range postJS {
-		b.WriteString(js)
-	}
-	b.WriteString("})();")
-	return b.String()
+	return n.toJS("", "document.body.appendChild(", "")
 }
 
 // ToJSReplace compiles JS that replaces an existing DOM element by its ID.
 // The old element is found by ID, the new tree is built, and replaceWith() is called.
 func (n *Node) ToJSReplace(targetID string) string {
-	var b strings.Builder
-	b.WriteString("(function(){")
-	b.WriteString(fmt.Sprintf("var _t=document.getElementById('%s');", escJS(targetID)))
-	b.WriteString(fmt.Sprintf("if(!_t){console.warn('[g-sui] replaceWith: element #%s not found');__ws.notfound('%s');return;}", escJS(targetID), escJS(targetID)))
-	counter := 0
-	var postJS []string
-	root := n.compile(&b, &counter, &postJS)
-	fmt.Fprintf(&b, "_t.replaceWith(%s);", root)
-	for _, js := range postJS {
-		b.WriteString(js)
-	}
-	b.WriteString("})();")
-	return b.String()
+	return n.toJS(targetJS("_t", targetID, "replaceWith")+keepJS("_t"), "_t.replaceWith(", keptJS)
 }
 
 // ToJSAppend compiles JS that appends this node as a child of the target element.
 func (n *Node) ToJSAppend(parentID string) string {
-	var b strings.Builder
-	b.WriteString("(function(){")
-	b.WriteString(fmt.Sprintf("var _p=document.getElementById('%s');", escJS(parentID)))
-	b.WriteString(fmt.Sprintf("if(!_p){console.warn('[g-sui] appendChild: element #%s not found');__ws.notfound('%s');return;}", escJS(parentID), escJS(parentID)))
-	counter := 0
-	var postJS []string
-	root := n.compile(&b, &counter, &postJS)
-	fmt.Fprintf(&b, "_p.appendChild(%s);", root)
-	for _, js := range postJS {
-		b.WriteString(js)
-	}
-	b.WriteString("})();")
-	return b.String()
+	return n.toJS(targetJS("_p", parentID, "appendChild"), "_p.appendChild(", "")
 }
 
 // ToJSPrepend compiles JS that prepends this node as the first child.
 func (n *Node) ToJSPrepend(parentID string) string {
-	var b strings.Builder
-	b.WriteString("(function(){")
-	b.WriteString(fmt.Sprintf("var _p=document.getElementById('%s');", escJS(parentID)))
-	b.WriteString(fmt.Sprintf("if(!_p){console.warn('[g-sui] prepend: element #%s not found');__ws.notfound('%s');return;}", escJS(parentID), escJS(parentID)))
-	counter := 0
-	var postJS []string
-	root := n.compile(&b, &counter, &postJS)
-	fmt.Fprintf(&b, "_p.prepend(%s);", root)
-	for _, js := range postJS {
-		b.WriteString(js)
-	}
-	b.WriteString("})();")
-	return b.String()
+	return n.toJS(targetJS("_p", parentID, "prepend"), "_p.prepend(", "")
 }
 
 // ToJSInner compiles JS that replaces the innerHTML of a target element
 // with this node (sets target's children to just this node).
 func (n *Node) ToJSInner(targetID string) string {
-	var b strings.Builder
+	return n.toJS(targetJS("_t", targetID, "innerHTML")+keepJS("_t")+"_t.innerHTML='';", "_t.appendChild(", keptJS)
+}
+
+// targetJS looks up the element a swap applies to and bails out, telling
+// the server, when it is gone.
+func targetJS(varName, id, op string) string {
+	id = escJS(id)
+	return "var " + varName + "=document.getElementById('" + id + "');" +
+		"if(!" + varName + "){console.warn('[g-sui] " + op + ": element #" + id + " not found');__ws.notfound('" + id + "');return;}"
+}
+
+// keepJS notes the Keep elements inside the element in varName before it
+// is swapped; keptJS, run after the new content is in, puts them back.
+// The WS client provides __gsuiKeep.
+func keepJS(varName string) string {
+	return "var _k=window.__gsuiKeep&&__gsuiKeep(" + varName + ");"
+}
+
+const keptJS = "if(_k)_k();"
+
+// jsBufPool recycles compile buffers; a large page grows its buffer once
+// instead of on every render.
+var jsBufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// maxPooledJSBuf keeps unusually large buffers out of the pool.
+const maxPooledJSBuf = 1 << 20
+
+// toJS wraps the compiled tree in a self-executing function: prelude, the
+// statements building the tree, mount + root variable + ")", then the
+// deferred rawJS snippets.
+func (n *Node) toJS(prelude, mount, epilogue string) string {
+	b := jsBufPool.Get().(*bytes.Buffer)
+	post := jsBufPool.Get().(*bytes.Buffer)
+	b.Reset()
+	post.Reset()
 	b.WriteString("(function(){")
-	b.WriteString(fmt.Sprintf("var _t=document.getElementById('%s');", escJS(targetID)))
-	b.WriteString(fmt.Sprintf("if(!_t){console.warn('[g-sui] innerHTML: element #%s not found');__ws.notfound('%s');return;}", escJS(targetID), escJS(targetID)))
-	b.WriteString("_t.innerHTML='';")
+	b.WriteString(prelude)
 	counter := 0
-	var postJS []string
-	root := n.compile(&b, &counter, &postJS)
-	fmt.Fprintf(&b, "_t.appendChild(%s);", root)
-	for _, js := range postJS {
-		b.WriteString(js)
-	}
+	root := n.compile(b, post, &counter)
+	b.WriteString(mount)
+	b.WriteString(root)
+	b.WriteString(");")
+	b.Write(post.Bytes())
+	b.WriteString(epilogue)
 	b.WriteString("})();")
-	return b.String()
+	js := b.String()
+	for _, buf := range []*bytes.Buffer{b, post} {
+		if buf.Cap() <= maxPooledJSBuf {
+			jsBufPool.Put(buf)
+		}
+	}
+	return js
 }
 
 const svgNS = "http://www.w3.org/2000/svg"
 
-// compile recursively emits JS statements to build a DOM element tree.
-// Returns the variable name assigned to this node.
-// Raw JS blocks (node.rawJS) are collected into postJS and deferred until
-// after the root node is inserted into the DOM so that getElementById works.
-// The inSVG flag propagates SVG namespace context to descendants.
-func (n *Node) compile(b *strings.Builder, counter *int, postJS *[]string, inSVG ...bool) string {
-	varName := fmt.Sprintf("e%d", *counter)
+// compile recursively emits JS statements to build a DOM element tree into
+// b, and the node's deferred rawJS into post. Returns the variable name
+// assigned to this node.
+func (n *Node) compile(b, post *bytes.Buffer, counter *int, inSVG ...bool) string {
+	if n.lazy != nil {
+		if built := n.lazy(); built != nil {
+			return built.compile(b, post, counter, inSVG...)
+		}
+		return Fragment().compile(b, post, counter)
+	}
+
+	varName := "e" + strconv.Itoa(*counter)
 	*counter++
 
 	parentIsSVG := len(inSVG) > 0 && inSVG[0]
+	if n.fragment {
+		b.WriteString("var " + varName + "=document.createDocumentFragment();")
+		for _, child := range n.children {
+			childVar := child.compile(b, post, counter, parentIsSVG)
+			appendChildJS(b, varName, childVar)
+		}
+		return varName
+	}
 	// Only the <svg> root opens the SVG namespace; descendants inherit it via
 	// parentIsSVG. A tag-name lookup would wrongly namespace HTML elements that
 	// share a name with SVG (notably <a>, plus <title>, <text>, <image>,
 	// <switch>) when they are used outside an <svg>.
 	useSVGNS := parentIsSVG || n.tag == "svg"
 
+	b.WriteString("var ")
+	b.WriteString(varName)
 	if useSVGNS {
-		fmt.Fprintf(b, "var %s=document.createElementNS('%s','%s');", varName, svgNS, escJS(n.tag))
+		b.WriteString("=document.createElementNS('" + svgNS + "','")
 	} else {
-		fmt.Fprintf(b, "var %s=document.createElement('%s');", varName, escJS(n.tag))
+		b.WriteString("=document.createElement('")
 	}
+	writeEscJS(b, n.tag)
+	b.WriteString("');")
 
 	if n.id != "" {
-		fmt.Fprintf(b, "%s.id='%s';", varName, escJS(n.id))
+		propJS(b, varName, ".id='", n.id)
+		b.WriteString(";")
 	}
 	if n.class != "" {
 		if useSVGNS {
 			// SVG elements have className as SVGAnimatedString; use setAttribute.
-			fmt.Fprintf(b, "%s.setAttribute('class','%s');", varName, escJS(n.class))
+			propJS(b, varName, ".setAttribute('class','", n.class)
+			b.WriteString(")")
 		} else {
-			fmt.Fprintf(b, "%s.className='%s';", varName, escJS(n.class))
+			propJS(b, varName, ".className='", n.class)
 		}
+		b.WriteString(";")
 	}
 	if n.text != "" {
-		fmt.Fprintf(b, "%s.textContent='%s';", varName, escJS(n.text))
+		propJS(b, varName, ".textContent='", n.text)
+		b.WriteString(";")
+	}
+	if n.html != "" {
+		propJS(b, varName, ".innerHTML='", n.html)
+		b.WriteString(";")
 	}
 
 	// Attributes
 	for k, v := range n.attrs {
-		fmt.Fprintf(b, "%s.setAttribute('%s','%s');", varName, escJS(k), escJS(v))
+		b.WriteString(varName)
+		b.WriteString(".setAttribute('")
+		writeEscJS(b, k)
+		b.WriteString("','")
+		writeEscJS(b, v)
+		b.WriteString("');")
 	}
 
 	// Inline styles
 	for k, v := range n.styles {
-		fmt.Fprintf(b, "%s.style['%s']='%s';", varName, escJS(k), escJS(v))
+		b.WriteString(varName)
+		b.WriteString(".style['")
+		writeEscJS(b, k)
+		b.WriteString("']='")
+		writeEscJS(b, v)
+		b.WriteString("';")
 	}
 
 	// Events
@@ -475,72 +636,99 @@ func (n *Node) compile(b *strings.Builder, counter *int, postJS *[]string, inSVG
 		if action == nil {
 			continue
 		}
+		// Confirm gate: runs before anything else so a cancel neither
+		// marks the button busy nor sends the call.
+		guard := ""
+		if action.Confirm != "" {
+			guard = "if(!confirm('" + escJS(action.Confirm) + "')){event.preventDefault();return}"
+		}
+		b.WriteString(varName)
+		b.WriteString(".addEventListener('")
+		writeEscJS(b, event)
+		b.WriteString("',function(event){")
+		b.WriteString(guard)
 		if action.rawJS != "" {
 			// Client-side only: raw JS, no WS call
-			fmt.Fprintf(b,
-				"%s.addEventListener('%s',function(event){%s});",
-				varName, escJS(event), action.rawJS,
-			)
-		} else if len(action.Collect) > 0 {
+			b.WriteString(action.rawJS)
+			b.WriteString("});")
+			continue
+		}
+		dataJSON, err := json.Marshal(action.Data)
+		if err != nil {
+			defaultLogger.Errorf("marshal action data: %v", err)
+			dataJSON = []byte("{}")
+		}
+		if event == "click" || event == "submit" {
+			b.WriteString("event.preventDefault();")
+		}
+		busy, opts := busyJS(event, action)
+		b.WriteString(busy)
+		if action.undoJS != "" {
+			b.WriteString("var u=(function(){")
+			b.WriteString(action.undoJS)
+			b.WriteString("\n}).call(event.currentTarget);")
+			opts = callOpt(opts, "undo:u")
+		}
+		if action.json {
+			opts = callOpt(opts, "json:event.currentTarget")
+		}
+		if action.retry {
+			opts = callOpt(opts, "retry:true")
+		}
+		b.WriteString("__ws.call('")
+		writeEscJS(b, action.Name)
+		b.WriteString("',")
+		b.Write(dataJSON)
+		if len(action.Collect) > 0 {
 			collectJSON, err := json.Marshal(action.Collect)
 			if err != nil {
-				log.Printf("gsui: marshal action collect: %v", err)
+				defaultLogger.Errorf("marshal action collect: %v", err)
 				collectJSON = []byte("[]")
 			}
-			dataJSON, err := json.Marshal(action.Data)
-			if err != nil {
-				log.Printf("gsui: marshal action data: %v", err)
-				dataJSON = []byte("{}")
-			}
-			prevent := ""
-			busy := ""
-			if event == "click" || event == "submit" {
-				prevent = "event.preventDefault();"
-			}
-			if event == "click" {
-				busy = "var b=event.currentTarget;if(b&&b.tagName==='BUTTON'&&!b.disabled){b.disabled=true;b.classList.add('gsui-busy','opacity-60','cursor-wait')}"
-			}
-			fmt.Fprintf(b,
-				"%s.addEventListener('%s',function(event){%s%s__ws.call('%s',%s,%s)});",
-				varName, escJS(event), prevent, busy, escJS(action.Name), string(dataJSON), string(collectJSON),
-			)
-		} else {
-			dataJSON, err := json.Marshal(action.Data)
-			if err != nil {
-				log.Printf("gsui: marshal action data: %v", err)
-				dataJSON = []byte("{}")
-			}
-			prevent := ""
-			busy := ""
-			if event == "click" || event == "submit" {
-				prevent = "event.preventDefault();"
-			}
-			if event == "click" {
-				busy = "var b=event.currentTarget;if(b&&b.tagName==='BUTTON'&&!b.disabled){b.disabled=true;b.classList.add('gsui-busy','opacity-60','cursor-wait')}"
-			}
-			fmt.Fprintf(b,
-				"%s.addEventListener('%s',function(event){%s%s__ws.call('%s',%s)});",
-				varName, escJS(event), prevent, busy, escJS(action.Name), string(dataJSON),
-			)
+			b.WriteString(",")
+			b.Write(collectJSON)
+		} else if opts != "" {
+			b.WriteString(",null")
 		}
+		b.WriteString(opts)
+		b.WriteString(")});")
 	}
 
 	// Children
 	for _, child := range n.children {
-		childVar := child.compile(b, counter, postJS, useSVGNS)
-		fmt.Fprintf(b, "%s.appendChild(%s);", varName, childVar)
+		childVar := child.compile(b, post, counter, useSVGNS)
+		appendChildJS(b, varName, childVar)
 	}
 
 	// Collect raw JS for deferred execution (after DOM insertion).
 	// The snippet is wrapped in .call(eN) so that `this` refers to
 	// the DOM element — no manual ID bookkeeping needed.
 	if n.rawJS != "" {
-		*postJS = append(*postJS, fmt.Sprintf("(function(){%s}).call(%s);", n.rawJS, varName))
+		post.WriteString("(function(){")
+		post.WriteString(n.rawJS)
+		post.WriteString("}).call(")
+		post.WriteString(varName)
+		post.WriteString(");")
 	}
 
 	return varName
 }
 
+// propJS writes varName + prop + the escaped value + the closing quote.
+func propJS(b *bytes.Buffer, varName, prop, value string) {
+	b.WriteString(varName)
+	b.WriteString(prop)
+	writeEscJS(b, value)
+	b.WriteString("'")
+}
+
+func appendChildJS(b *bytes.Buffer, parent, child string) {
+	b.WriteString(parent)
+	b.WriteString(".appendChild(")
+	b.WriteString(child)
+	b.WriteString(");")
+}
+
 // ---------------------------------------------------------------------------
 // JS Helper Functions (return JS strings for common DOM operations)
 // ---------------------------------------------------------------------------
@@ -548,40 +736,92 @@ func (n *Node) compile(b *strings.Builder, counter *int, postJS *[]string, inSVG
 // Notify returns JS that shows a toast notification styled with a left
 // accent border, colored dot, and auto-dismiss. Supports "success", "error",
 // "error-reload" (persistent with Reload button), and "info" (default) variants.
+// Position, duration and the close button follow App.ToastDefaults.
 func Notify(variant, message string) string {
+	return notifyJS(variant, message, "null")
+}
+
+// ToastOptions controls where a toast appears and how it leaves. Fields
+// left at their zero value keep the default: App.ToastDefaults, then the
+// built-in top-right, 5s toast with a close button.
+type ToastOptions struct {
+	// Position is "top-right" (default), "top-left", "top-center",
+	// "bottom-right", "bottom-left" or "bottom-center".
+	Position string
+	// Duration before auto-dismiss; 0 keeps the default of 5s and a
+	// negative value keeps the toast until it is closed.
+	Duration time.Duration
+	// NoClose removes the close button.
+	NoClose bool
+}
+
+// js renders the set options as the object literal read by notifyJS,
+// which fills the others from the app defaults and built-ins.
+func (o ToastOptions) js() string {
+	var fields []string
+	if o.Position != "" {
+		fields = append(fields, "p:'"+escJS(o.Position)+"'")
+	}
+	if o.Duration < 0 {
+		fields = append(fields, "d:-1")
+	} else if o.Duration > 0 {
+		// Under a millisecond still means "set": d:0 would close at once.
+		fields = append(fields, fmt.Sprintf("d:%d", max(1, o.Duration.Milliseconds())))
+	}
+	if o.NoClose {
+		fields = append(fields, "x:false")
+	}
+	return "{" + strings.Join(fields, ",") + "}"
+}
+
+// NotifyOpts is Notify with explicit position, duration and close button,
+// overriding App.ToastDefaults for this toast.
+//
+//	ui.NotifyOpts("success", "Saved", ui.ToastOptions{Position: "bottom-center", Duration: 10 * time.Second})
+func NotifyOpts(variant, message string, opts ToastOptions) string {
+	return notifyJS(variant, message, opts.js())
+}
+
+func notifyJS(variant, message, opts string) string {
 	return fmt.Sprintf(
 		`(function(){`+
-			// Ensure __messages__ container exists
-			`var box=document.getElementById('__messages__');`+
-			`if(!box){box=document.createElement('div');box.id='__messages__';`+
-			`box.style.cssText='position:fixed;top:0;right:0;padding:8px;z-index:9999;pointer-events:none';`+
+			// Resolve options: call-site, then app defaults, then built-ins
+			`var o=Object.assign({p:'top-right',d:5000,x:true},window.__gsuiToast||{},%s||{});`+
+			`var bottom=o.p.indexOf('bottom')===0,left=/-left$/.test(o.p),center=/-center$/.test(o.p);`+
+			`var off=center?(bottom?'translateY(20px)':'translateY(-20px)'):(left?'translateX(-20px)':'translateX(20px)');`+
+			// Ensure the container for this position exists
+			`var bid=o.p==='top-right'?'__messages__':'__messages_'+o.p+'__';`+
+			`var box=document.getElementById(bid);`+
+			`if(!box){box=document.createElement('div');box.id=bid;`+
+			`box.style.cssText='position:fixed;padding:8px;z-index:9999;pointer-events:none;display:flex;flex-direction:'+(bottom?'column-reverse':'column')+';'+(bottom?'bottom:0;':'top:0;')+(center?'left:50%%;transform:translateX(-50%%);align-items:center':(left?'left:0':'right:0'));`+
 			`document.body.appendChild(box);}`+
 			// Create notification element
-			`var n=document.createElement('div');`+
-			`n.style.cssText='display:flex;align-items:center;gap:10px;padding:12px 16px;margin:8px;border-radius:12px;min-height:44px;width:calc(100vw - 32px);max-width:380px;box-shadow:0 6px 18px rgba(0,0,0,0.08);border:1px solid;font-weight:600;font-family:inherit;font-size:14px;opacity:0;transform:translateX(20px);transition:opacity 200ms,transform 200ms;pointer-events:auto';`+
+			`var n=document.createElement('div');n.setAttribute('role','alert');`+
+			`n.style.cssText='display:flex;align-items:center;gap:10px;padding:12px 16px;margin:8px;border-radius:12px;min-height:44px;width:calc(100vw - 32px);max-width:380px;box-shadow:0 6px 18px rgba(0,0,0,0.08);border:1px solid;font-weight:600;font-family:inherit;font-size:14px;opacity:0;transition:opacity 200ms,transform 200ms;pointer-events:auto';n.style.transform=off;`+
 			// Variant-specific colors (dark-mode aware)
-			`var v='%s',accent='#4f46e5',timeout=5000,dk=document.documentElement.classList.contains('dark');`+
+			`var v='%s',accent='var(--gsui-primary,#4f46e5)',timeout=o.d,dk=document.documentElement.classList.contains('dark');`+
 			`if(v==='success'){accent='#16a34a';if(dk){n.style.background='#052e16';n.style.color='#86efac';n.style.borderColor='#14532d'}else{n.style.background='#dcfce7';n.style.color='#166534';n.style.borderColor='#bbf7d0'}}`+
 			`else if(v==='error'||v==='error-reload'){accent='#dc2626';if(dk){n.style.background='#450a0a';n.style.color='#fca5a5';n.style.borderColor='#7f1d1d'}else{n.style.background='#fee2e2';n.style.color='#991b1b';n.style.borderColor='#fecaca'};if(v==='error-reload')timeout=88000}`+
-			`else{if(dk){n.style.background='#1e1b4b';n.style.color='#a5b4fc';n.style.borderColor='#312e81'}else{n.style.background='#eef2ff';n.style.color='#3730a3';n.style.borderColor='#e0e7ff'}}`+
+			`else{if(dk){n.style.background='var(--gsui-info-bg-dark,#1e1b4b)';n.style.color='var(--gsui-info-fg-dark,#a5b4fc)';n.style.borderColor='var(--gsui-info-border-dark,#312e81)'}else{n.style.background='var(--gsui-info-bg,#eef2ff)';n.style.color='var(--gsui-info-fg,#3730a3)';n.style.borderColor='var(--gsui-info-border,#e0e7ff)'}}`+
 			`n.style.borderLeft='4px solid '+accent;`+
 			// Dot indicator
 			`var dot=document.createElement('span');dot.style.cssText='width:10px;height:10px;border-radius:9999px;flex-shrink:0;background:'+accent;`+
 			// Message text
 			`var t=document.createElement('span');t.style.flex='1';t.textContent='%s';`+
-			`n.appendChild(dot);n.appendChild(t);var close=document.createElement('button');close.textContent='×';close.setAttribute('aria-label','Dismiss notification');close.style.cssText='border:0;background:transparent;color:inherit;font-size:20px;line-height:1;cursor:pointer;border-radius:6px';close.className='focus:outline-none focus-visible:ring-2 focus-visible:ring-current';close.onclick=function(){if(n.parentNode)n.parentNode.removeChild(n)};n.appendChild(close);`+
+			`n.appendChild(dot);n.appendChild(t);`+
+			`if(o.x){var close=document.createElement('button');close.textContent='×';close.setAttribute('aria-label','Dismiss notification');close.style.cssText='border:0;background:transparent;color:inherit;font-size:20px;line-height:1;cursor:pointer;border-radius:6px';close.className='focus:outline-none focus-visible:ring-2 focus-visible:ring-current';close.onclick=function(){if(n.parentNode)n.parentNode.removeChild(n)};n.appendChild(close)}`+
 			// Reload button for error-reload
 			`if(v==='error-reload'){var btn=document.createElement('button');btn.textContent='Reload';`+
 			`btn.style.cssText='background:#991b1b;color:#fff;border:none;padding:6px 10px;border-radius:8px;cursor:pointer;font-weight:700;font-size:13px';`+
 			`btn.onclick=function(){try{location.reload()}catch(_){}};n.appendChild(btn)}`+
 			// Mount and animate in
 			`box.appendChild(n);`+
-			`requestAnimationFrame(function(){n.style.opacity='1';n.style.transform='translateX(0)'});`+
-			// Auto-dismiss with fade out
-			`setTimeout(function(){try{n.style.opacity='0';n.style.transform='translateX(20px)';`+
+			`requestAnimationFrame(function(){n.style.opacity='1';n.style.transform='none'});`+
+			// Auto-dismiss with fade out (negative timeout keeps the toast)
+			`if(timeout>=0)setTimeout(function(){try{n.style.opacity='0';n.style.transform=off;`+
 			`setTimeout(function(){try{if(n&&n.parentNode)n.parentNode.removeChild(n)}catch(_){}},200)}catch(_){}},timeout)`+
 			`})();`,
-		escJS(variant), escJS(message),
+		opts, escJS(variant), escJS(message),
 	)
 }
 
@@ -593,9 +833,10 @@ func Redirect(url string) string {
 
 // SetLocation returns JS that updates the browser URL without a page reload
 // using history.pushState. Use this in WS actions to keep the address bar
-// in sync with the visible content.
+// in sync with the visible content. Once the reply has run, focus moves to
+// the main content and the title is announced, as after back/forward.
 func SetLocation(url string) string {
-	return fmt.Sprintf("history.pushState(null,'','%s');", escJS(url))
+	return fmt.Sprintf("history.pushState(null,'','%s');window.__ws&&__ws.moved&&__ws.moved();", escJS(url))
 }
 
 // Back returns JS that navigates back in browser history (history.back()).
@@ -609,6 +850,28 @@ func SetTitle(title string) string {
 	return fmt.Sprintf("document.title='%s';", escJS(title))
 }
 
+// Batch joins several JS updates into one, so they travel in a single
+// WebSocket frame and the browser applies them together, without painting
+// in between. Each update runs even if an earlier one throws.
+//
+//	ctx.Push(ui.Batch(
+//		total.ToJSReplace("cart-total"),
+//		row.ToJSAppend("cart-items"),
+//		ui.SetText("cart-count", "3"),
+//	))
+func Batch(js ...string) string {
+	var b strings.Builder
+	for _, s := range js {
+		if s == "" {
+			continue
+		}
+		b.WriteString("try{")
+		b.WriteString(s)
+		b.WriteString("\n}catch(e){console.error('gsui: batch update failed:',e)}")
+	}
+	return b.String()
+}
+
 // RemoveEl returns JS that removes an element by ID.
 func RemoveEl(id string) string {
 	return fmt.Sprintf("(function(){var e=document.getElementById('%s');if(!e){console.warn('[g-sui] remove: element #%s not found');__ws.notfound('%s');return;}e.remove()})();", escJS(id), escJS(id), escJS(id))
@@ -665,16 +928,20 @@ func DragToScroll(id string) string {
 	return fmt.Sprintf(
 		"(function(){"+
 			"var el=document.getElementById('%s');if(!el){console.warn('[g-sui] dragToScroll: element #%s not found');__ws.notfound('%s');return;}"+
+			"if(el.__gsuiDrag)return;el.__gsuiDrag=true;"+
 			"var down=false,sx=0,sl=0;"+
 			"el.addEventListener('mousedown',function(e){"+
 			"if(e.target.closest('input,select,button,a,.dt-filter-dropdown'))return;"+
 			"down=true;sx=e.pageX-el.offsetLeft;sl=el.scrollLeft;"+
 			"el.style.cursor='grabbing';el.style.userSelect='none';});"+
-			"document.addEventListener('mouseup',function(){"+
-			"if(!down)return;down=false;el.style.cursor='grab';el.style.removeProperty('user-select');});"+
-			"document.addEventListener('mousemove',function(e){"+
+			"var up=function(){"+
+			"if(!el.isConnected){document.removeEventListener('mouseup',up);document.removeEventListener('mousemove',move);return}"+
+			"if(!down)return;down=false;el.style.cursor='grab';el.style.removeProperty('user-select');};"+
+			"var move=function(e){"+
 			"if(!down)return;e.preventDefault();"+
-			"el.scrollLeft=sl-(e.pageX-el.offsetLeft-sx);});"+
+			"el.scrollLeft=sl-(e.pageX-el.offsetLeft-sx);};"+
+			"document.addEventListener('mouseup',up);"+
+			"document.addEventListener('mousemove',move);"+
 			"})();",
 		escJS(id), escJS(id), escJS(id),
 	)
@@ -687,9 +954,38 @@ func DragToScroll(id string) string {
 // escJS escapes a string for safe embedding inside JS single-quoted strings
 // that may themselves be embedded in an HTML <script> tag.
 func escJS(s string) string {
-	var b strings.Builder
-	b.Grow(len(s))
+	if !needsEscJS(s) {
+		return s
+	}
+	var b bytes.Buffer
+	b.Grow(len(s) + 8)
+	writeEscJS(&b, s)
+	return b.String()
+}
 
+// needsEscJS reports whether escJS would change s.
+func needsEscJS(s string) bool {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c < 0x20, c == '\\', c == '\'', c == '<', c == '>', c == '&', c == '=':
+			return true
+		case c == 0xe2 && (strings.HasPrefix(s[i:], "\u2028") || strings.HasPrefix(s[i:], "\u2029")):
+			return true
+		case c >= 0x80:
+			ascii = false
+		}
+	}
+	// Invalid UTF-8 is rewritten to U+FFFD so WebSocket text frames stay valid.
+	return !ascii && !utf8.ValidString(s)
+}
+
+// writeEscJS writes escJS(s) to b without building an intermediate string.
+func writeEscJS(b *bytes.Buffer, s string) {
+	if !needsEscJS(s) {
+		b.WriteString(s)
+		return
+	}
 	for _, r := range s {
 		switch r {
 		case '\\':
@@ -716,12 +1012,10 @@ func escJS(s string) string {
 			b.WriteString(`\u2029`)
 		default:
 			if r < 0x20 {
-				fmt.Fprintf(&b, `\u%04x`, r)
+				fmt.Fprintf(b, `\u%04x`, r)
 			} else {
 				b.WriteRune(r)
 			}
 		}
 	}
-
-	return b.String()
 }
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"runtime/debug"
+)
+
+// ---------------------------------------------------------------------------
+// Error page: what a page load shows when its handler panics
+// ---------------------------------------------------------------------------
+
+// OnError sets the page shown when a page handler or the layout panics.
+// The panic and its stack trace are logged; fn receives it as err and
+// returns the content of a 500 response. The page is served without the
+// WebSocket client, so it never reloads itself; links and plain HTML work,
+// actions do not. Without OnError a minimal built-in page is used. A panic
+// inside fn falls back to that page too.
+//
+//	app.OnError(func(ctx *ui.Context, err error) *ui.Node {
+//		return ui.Div().Class("p-8").Text("Something went wrong. Please try again later.")
+//	})
+//
+// Panics in actions are separate: they show an error toast.
+func (app *App) OnError(fn func(ctx *Context, err error) *Node) {
+	app.mu.Lock()
+	app.onError = fn
+	app.mu.Unlock()
+}
+
+// recoverPage, deferred by renderPage, turns a panic into a 500 page.
+func (app *App) recoverPage(w http.ResponseWriter, ctx *Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	path := ""
+	if ctx.Request != nil {
+		path = ctx.Request.URL.Path
+	}
+	ctx.log().Errorf("panic in page %q: %v\n%s", path, r, debug.Stack())
+
+	app.mu.RLock()
+	fn := app.onError
+	app.mu.RUnlock()
+	body := ""
+	if fn != nil {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					ctx.log().Errorf("panic in OnError: %v\n%s", r, debug.Stack())
+					body = ""
+				}
+			}()
+			if node := fn(ctx, fmt.Errorf("panic: %v", r)); node != nil {
+				body = node.ToJS()
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusInternalServerError)
+	if body == "" {
+		ref := ""
+		if ctx.requestID != "" {
+			ref = `<p class="ref">Reference: ` + html.EscapeString(ctx.requestID) + `</p>`
+		}
+		fmt.Fprintf(w, defaultErrorPage, ref)
+		return
+	}
+	fmt.Fprintf(w, stampNonce(errorPageShell, ctx.nonce), html.EscapeString(app.safeLocale(ctx)), themeInitJS, app.themeCSS(), darkOverrideCSS, wsStubJS, body)
+}
+
+// errorPageShell is the HTML around an OnError page.
+const errorPageShell = `<!DOCTYPE html>
+<html lang="%s">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width,initial-scale=1">
+<meta name="color-scheme" content="light dark">
+<title>Server error</title>
+<script>%s</script>
+<style>%s%s</style>
+<script src="https://cdn.jsdelivr.net/npm/@tailwindcss/browser@4"></script>
+<style type="text/tailwindcss">
+@custom-variant dark (&:where(.dark, .dark *));
+</style>
+<script>%s</script>
+</head>
+<body>
+<script>
+%s
+</script>
+</body>
+</html>`
+
+// safeLocale is ctx.Locale for the error page, which must render even if
+// the locale lookup is what failed.
+func (app *App) safeLocale(ctx *Context) (l string) {
+	defer func() {
+		if recover() != nil {
+			l = defaultLocale
+		}
+	}()
+	return ctx.Locale()
+}
+
+// defaultErrorPage is a static 500 page: no scripts, no external resources.
+const defaultErrorPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width,initial-scale=1">
+<meta name="color-scheme" content="light dark">
+<title>Server error</title>
+<style>body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;font-family:ui-sans-serif,system-ui,-apple-system,'Segoe UI',sans-serif;color:#374151;background:#f9fafb}@media(prefers-color-scheme:dark){body{color:#e5e7eb;background:#0b1120}}main{text-align:center;padding:2rem}h1{font-size:1.5rem;margin:0 0 .5rem}p{margin:0;opacity:.75}a{color:inherit}.ref{margin-top:1rem;font-size:.8rem;font-family:ui-monospace,monospace}</style>
+</head>
+<body>
+<main>
+<h1>Something went wrong</h1>
+<p>The server could not show this page. Please try again later, or go back to the <a href="/">home page</a>.</p>
+%s
+</main>
+</body>
+</html>`
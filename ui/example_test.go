@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 // ---------------------------------------------------------------------------
@@ -92,6 +93,53 @@ func TestElWithCollect(t *testing.T) {
 	expect(t, js, `["f-name","f-email"]`)
 }
 
+func TestElWithConfirm(t *testing.T) {
+	js := Button().Text("Delete").OnClick(&Action{
+		Name:    "item.delete",
+		Confirm: "Delete 'this' item?",
+	}).ToJS()
+
+	expect(t, js, `if(!confirm('Delete \'this\' item?')){event.preventDefault();return}event.preventDefault();`)
+	if strings.Index(js, "confirm(") > strings.Index(js, "gsui-busy") {
+		t.Error("confirm gate must run before the button is marked busy")
+	}
+
+	raw := JS("doIt()")
+	raw.Confirm = "Sure?"
+	expect(t, Button().OnClick(raw).ToJS(), "if(!confirm('Sure?')){event.preventDefault();return}doIt()")
+}
+
+func TestElWithBusy(t *testing.T) {
+	js := Button().Text("Save").OnClick(&Action{Name: "doc.save", Busy: true}).ToJS()
+
+	expect(t, js, "sp.className='gsui-spin")
+	expect(t, js, "setAttribute('aria-busy','true')")
+	expect(t, js, "__ws.call('doc.save',null,null,{quiet:true})")
+
+	js = Button().OnClick(&Action{Name: "doc.save", Collect: []string{"title"}, Busy: true}).ToJS()
+	expect(t, js, `__ws.call('doc.save',null,["title"],{quiet:true})`)
+}
+
+func TestElWithOptimisticAction(t *testing.T) {
+	js := Button().OnClick((&Action{Name: "like"}).Optimistic("this.textContent='♥';return function(){}")).ToJS()
+	expect(t, js, "var u=(function(){this.textContent='♥';return function(){}\n}).call(event.currentTarget);__ws.call('like',null,null,{undo:u})")
+
+	js = Button().OnClick((&Action{Name: "like", Busy: true}).Optimistic("x()")).ToJS()
+	expect(t, js, "{quiet:true,undo:u})")
+
+	notExpect(t, Button().OnClick(JS("y()").Optimistic("x()")).ToJS(), "x()")
+}
+
+func TestElWithRetryAction(t *testing.T) {
+	js := Button().OnClick((&Action{Name: "settings.save"}).Retry()).ToJS()
+	expect(t, js, "__ws.call('settings.save',null,null,{retry:true})")
+
+	js = Button().OnClick((&Action{Name: "settings.save", Busy: true}).Optimistic("x()").Retry()).ToJS()
+	expect(t, js, "{quiet:true,undo:u,retry:true})")
+
+	notExpect(t, Button().OnClick(JS("y()").Retry()).ToJS(), "retry")
+}
+
 func TestNilChildrenSkipped(t *testing.T) {
 	n := Div().Render(
 		Span().Text("visible"),
@@ -147,6 +195,42 @@ func TestMapHelper(t *testing.T) {
 	expect(t, js, "item-2")
 }
 
+func TestFragmentHelper(t *testing.T) {
+	js := Ul().ID("list").Render(
+		Fragment(Li().Text("a"), nil, Li().Text("b")),
+		Li().Text("c"),
+	).ToJS()
+
+	expect(t, js, "var e1=document.createDocumentFragment();")
+	expect(t, js, "e1.appendChild(e3);e0.appendChild(e1);")
+	expect(t, js, "'c'")
+	notExpect(t, js, "createElement('')")
+}
+
+func TestLazyHelper(t *testing.T) {
+	calls := 0
+	report := Lazy(func() *Node {
+		calls++
+		return Table().ID("report")
+	})
+	page := Div().Render(Or(false, report, Span().Text("summary")))
+	page.ToJS()
+	if calls != 0 {
+		t.Fatal("Lazy content built although it is not in the tree")
+	}
+
+	page = Div().Render(report)
+	js := page.ToJS()
+	expect(t, js, "'report'")
+	page.ToJS()
+	if calls != 2 {
+		t.Fatalf("Lazy built %d times over two compiles", calls)
+	}
+
+	empty := Div().Render(Lazy(func() *Node { return nil })).ToJS()
+	expect(t, empty, "document.createDocumentFragment()")
+}
+
 // ---------------------------------------------------------------------------
 // Swap strategy tests
 // ---------------------------------------------------------------------------
@@ -184,6 +268,17 @@ func TestToJSInner(t *testing.T) {
 	expect(t, js, "_t.appendChild(")
 }
 
+func TestKeepCarriesRegionsAcrossSwaps(t *testing.T) {
+	n := Div().Render(Aside().ID("filters").Keep().Text("new"))
+	expect(t, n.ToJS(), "data-gsui-keep")
+	for _, js := range []string{n.ToJSInner("main"), n.ToJSReplace("main")} {
+		expect(t, js, "var _k=window.__gsuiKeep&&__gsuiKeep(_t);")
+		expect(t, js, "if(_k)_k();})();")
+	}
+	notExpect(t, n.ToJSAppend("main"), "__gsuiKeep")
+	expect(t, wsClientJS, "window.__gsuiKeep=function(scope)")
+}
+
 // ---------------------------------------------------------------------------
 // Helper function tests
 // ---------------------------------------------------------------------------
@@ -197,6 +292,22 @@ func TestNotify(t *testing.T) {
 	expect(t, js, "setTimeout")
 }
 
+func TestNotifyOpts(t *testing.T) {
+	js := NotifyOpts("info", "Later", ToastOptions{Position: "bottom-center", Duration: 10 * time.Second})
+	expect(t, js, "{p:'bottom-center',d:10000}")
+	expect(t, js, "role','alert'")
+	expect(t, NotifyOpts("info", "Sticky", ToastOptions{Duration: -1, NoClose: true}), "{d:-1,x:false}")
+	expect(t, NotifyOpts("info", "Brief", ToastOptions{Duration: time.Microsecond}), "{d:1}")
+
+	app := NewApp()
+	if app.toastConfigJS() != "" {
+		t.Fatal("no defaults expected before ToastDefaults")
+	}
+	// Unset fields keep the built-ins, so the close button stays.
+	app.ToastDefaults(ToastOptions{Position: "bottom-left"})
+	expect(t, app.toastConfigJS(), "window.__gsuiToast={p:'bottom-left'};")
+}
+
 func TestRedirect(t *testing.T) {
 	js := Redirect("/dashboard")
 	expect(t, js, "window.location.href='/dashboard'")
@@ -207,6 +318,18 @@ func TestSetTitle(t *testing.T) {
 	expect(t, js, "document.title='New Title'")
 }
 
+func TestBatchIsolatesUpdates(t *testing.T) {
+	js := Batch(SetTitle("A"), "", "x=1 // trailing comment")
+	if got := strings.Count(js, "try{"); got != 2 {
+		t.Fatalf("Batch wrapped %d updates, want 2: %s", got, js)
+	}
+	expect(t, js, "try{document.title='A';\n}catch(e){")
+	expect(t, js, "try{x=1 // trailing comment\n}catch(e){")
+	if Batch() != "" {
+		t.Fatal("empty Batch must be empty")
+	}
+}
+
 func TestRemoveEl(t *testing.T) {
 	js := RemoveEl("old-item")
 	expect(t, js, "getElementById('old-item')")
@@ -501,3 +624,42 @@ func truncate(s string, max int) string {
 	}
 	return s
 }
+
+// ---------------------------------------------------------------------------
+// Benchmarks
+// ---------------------------------------------------------------------------
+
+// benchTablePage builds a page shaped like a data table: a header, 200 rows
+// of styled cells with a row action, and a footer.
+func benchTablePage() *Node {
+	rows := make([]int, 200)
+	return Div("max-w-6xl mx-auto p-6").Render(
+		H1("text-2xl font-bold").Text("Invoices"),
+		Table("w-full text-sm").Render(
+			Thead().Render(Tr().Render(
+				Th("text-left").Text("#"), Th("text-left").Text("Customer"),
+				Th("text-right").Text("Amount"), Th().Text("Status"), Th(),
+			)),
+			Tbody().Render(Map(rows, func(_ int, i int) *Node {
+				return Tr("border-b hover:bg-gray-50").ID(fmt.Sprintf("row-%d", i)).Render(
+					Td("py-2").Text(fmt.Sprintf("%d", i)),
+					Td("py-2").Text("Customer <O'Brien & Sons>"),
+					Td("py-2 text-right").Attr("data-value", fmt.Sprintf("%d.50", i)).Text(fmt.Sprintf("%d.50 EUR", i)),
+					Td("py-2").Render(Span("px-2 rounded bg-green-100 text-green-800").Text("paid")),
+					Td("py-2").Render(Button("text-blue-600").Text("Open").OnClick(&Action{
+						Name: "invoice.open", Data: map[string]any{"id": i},
+					})),
+				)
+			})...),
+		),
+		P("text-gray-500").Text("200 invoices"),
+	)
+}
+
+func BenchmarkTablePageToJS(b *testing.B) {
+	page := benchTablePage()
+	b.ReportAllocs()
+	for b.Loop() {
+		page.ToJS()
+	}
+}
@@ -0,0 +1,42 @@
+package ui
+
+import "fmt"
+
+// ---------------------------------------------------------------------------
+// Focus trap: keep Tab inside an open overlay, then give focus back
+// ---------------------------------------------------------------------------
+
+// TrapFocus returns JS that keeps keyboard focus inside element id, for
+// overlays built by hand: Tab and Shift+Tab cycle through its focusable
+// elements, and the first of them gets focus. ReleaseFocus, or the element
+// leaving the DOM, ends the trap and returns focus to the element that had
+// it before. Modal and ConfirmDialog trap focus themselves.
+//
+//	ui.Button().Text("Filters").OnClick(ui.JS(ui.Show("filters") + ui.TrapFocus("filters")))
+func TrapFocus(id string) string {
+	return fmt.Sprintf("(function(){%svar o=document.getElementById('%s');if(o)__gsuiTrap(o)})();", focusTrapJS, escJS(id))
+}
+
+// ReleaseFocus returns JS that ends the TrapFocus trap on element id and
+// returns focus to where it was.
+func ReleaseFocus(id string) string {
+	return fmt.Sprintf("(function(){var o=document.getElementById('%s');if(o&&o.__gsuiRelease)o.__gsuiRelease()})();", escJS(id))
+}
+
+// focusTrapJS defines __gsuiTrap(o, fallback) once per page. It focuses the
+// first focusable element in o (fallback, then o, when there is none),
+// keeps Tab inside o and returns a function ending the trap, also stored
+// as o.__gsuiRelease. The trap ends by itself when o leaves the DOM. Focus
+// goes back to the element that had it only while it is still in o or
+// lost, so a click elsewhere is not undone. Overlay scripts start with it.
+const focusTrapJS = `window.__gsuiTrap=window.__gsuiTrap||function(o,fallback){` +
+	`if(o.__gsuiRelease)return o.__gsuiRelease;var ret=document.activeElement;` +
+	`var items=function(){return Array.prototype.filter.call(o.querySelectorAll('a[href],button,input,select,textarea,[contenteditable="true"],[tabindex]:not([tabindex="-1"])'),function(e){return !e.disabled&&e.offsetParent!==null})};` +
+	`var onKey=function(e){if(e.key!=='Tab')return;var f=items();if(!f.length){e.preventDefault();return}var a=f[0],z=f[f.length-1],c=document.activeElement;` +
+	`if(e.shiftKey&&(c===a||!o.contains(c))){e.preventDefault();z.focus()}` +
+	`else if(!e.shiftKey&&(c===z||!o.contains(c))){e.preventDefault();a.focus()}};` +
+	`var mo=new MutationObserver(function(){if(!o.isConnected)release()});` +
+	`var release=function(){document.removeEventListener('keydown',onKey);mo.disconnect();o.__gsuiRelease=null;` +
+	`var c=document.activeElement;if(ret&&ret.isConnected&&ret.focus&&(!c||c===document.body||o.contains(c)))ret.focus();ret=null};` +
+	`document.addEventListener('keydown',onKey);mo.observe(document.body,{childList:true,subtree:true});o.__gsuiRelease=release;` +
+	`(items()[0]||fallback||o).focus();return release};`
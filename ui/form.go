@@ -2,11 +2,15 @@ package ui
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"maps"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"unicode/utf8"
 )
 
 // ---------------------------------------------------------------------------
@@ -60,8 +64,12 @@ type Field struct {
 	Value       string
 	Checked     bool // only for FieldCheckbox
 	Required    bool
-	Pattern     string // regex pattern for client+server validation
-	ErrMsg      string // custom error message (defaults to "<Label> is required")
+	Pattern     string   // regex pattern for client+server validation
+	Min         *float64 // lower bound: value for FieldNumber, length otherwise
+	Max         *float64 // upper bound: value for FieldNumber, length otherwise
+	Mask        string   // input mask, see Node.Mask (text-like inputs only)
+	Help        string   // hint shown under the control and read with it
+	ErrMsg      string   // custom error message for every rule (defaults per rule, see FormLocale)
 	Options     []FieldOption
 	Class       string // override input class
 	WrapClass   string // override wrapper class
@@ -76,6 +84,23 @@ type FormBuilder struct {
 	class      string // wrapper div class
 	fieldClass string // default input class
 	errClass   string // error text class
+	locale     *FormLocale
+	guard      bool // warn before leaving with unsaved edits
+}
+
+// FormLocale holds the translatable validation messages of a form. Each
+// function receives the field label; nil entries keep the English default.
+type FormLocale struct {
+	Required func(label string) string
+	Invalid  func(label string) string // pattern mismatch or unparsable number
+	Email    func(label string) string
+	Min      func(label string, min float64) string // FieldNumber below Min
+	Max      func(label string, max float64) string // FieldNumber above Max
+	MinLen   func(label string, n int) string       // text shorter than Min
+	MaxLen   func(label string, n int) string       // text longer than Max
+	Summary  func(count int) string                 // ErrorSummary heading
+	Dismiss  string                                 // ErrorSummary close button label
+	Unsaved  string                                 // GuardUnsaved prompt
 }
 
 type formButton struct {
@@ -120,6 +145,78 @@ func (f *FormBuilder) ErrClass(cls string) *FormBuilder {
 	return f
 }
 
+// Locale sets the validation messages used by Build (client-side) and
+// Validate (server-side).
+func (f *FormBuilder) Locale(l *FormLocale) *FormBuilder {
+	f.locale = l
+	return f
+}
+
+func (f *FormBuilder) loc() *FormLocale {
+	l := FormLocale{
+		Required: func(label string) string { return label + " is required" },
+		Invalid:  func(label string) string { return label + " format is invalid" },
+		Email:    func(label string) string { return label + " must be a valid email address" },
+		Min:      func(label string, min float64) string { return fmt.Sprintf("%s must be at least %g", label, min) },
+		Max:      func(label string, max float64) string { return fmt.Sprintf("%s must be at most %g", label, max) },
+		MinLen:   func(label string, n int) string { return fmt.Sprintf("%s must be at least %d characters", label, n) },
+		MaxLen:   func(label string, n int) string { return fmt.Sprintf("%s must be at most %d characters", label, n) },
+		Summary: func(count int) string {
+			if count == 1 {
+				return "There is 1 problem with this form"
+			}
+			return fmt.Sprintf("There are %d problems with this form", count)
+		},
+		Dismiss: "Dismiss",
+		Unsaved: "You have unsaved changes. Leave anyway?",
+	}
+	if f.locale == nil {
+		return &l
+	}
+	if f.locale.Required != nil {
+		l.Required = f.locale.Required
+	}
+	if f.locale.Invalid != nil {
+		l.Invalid = f.locale.Invalid
+	}
+	if f.locale.Email != nil {
+		l.Email = f.locale.Email
+	}
+	if f.locale.Min != nil {
+		l.Min = f.locale.Min
+	}
+	if f.locale.Max != nil {
+		l.Max = f.locale.Max
+	}
+	if f.locale.MinLen != nil {
+		l.MinLen = f.locale.MinLen
+	}
+	if f.locale.MaxLen != nil {
+		l.MaxLen = f.locale.MaxLen
+	}
+	if f.locale.Summary != nil {
+		l.Summary = f.locale.Summary
+	}
+	if f.locale.Dismiss != "" {
+		l.Dismiss = f.locale.Dismiss
+	}
+	if f.locale.Unsaved != "" {
+		l.Unsaved = f.locale.Unsaved
+	}
+	return &l
+}
+
+// GuardUnsaved warns before the user leaves the page with edits that were
+// not submitted: closing the tab or following a link, back/forward, or an
+// action link that swaps the page content. The form counts as saved again
+// once a submit's reply leaves no field marked invalid and no error
+// summary open. The prompt comes from FormLocale.Unsaved; browsers show
+// their own text for closing the tab.
+func (f *FormBuilder) GuardUnsaved() *FormBuilder {
+	f.guard = true
+	return f
+}
+
 // Action sets the WS action name that the form submits to.
 func (f *FormBuilder) Action(name string) *FormBuilder {
 	f.actionName = name
@@ -183,6 +280,35 @@ func (fb *FieldBuilder) PatternValidation(p string) *FieldBuilder {
 	return fb
 }
 
+// Min sets a lower bound: the value for Number fields, the length in
+// characters for text fields and textareas.
+func (fb *FieldBuilder) Min(n float64) *FieldBuilder {
+	fb.field().Min = &n
+	return fb
+}
+
+// Max sets an upper bound: the value for Number fields, the length in
+// characters for text fields and textareas.
+func (fb *FieldBuilder) Max(n float64) *FieldBuilder {
+	fb.field().Max = &n
+	return fb
+}
+
+// Mask applies an input mask such as "(###) ###-####" to a text-like field.
+// The raw characters, without literals, are what gets submitted and
+// validated, so PatternValidation should describe the raw value.
+func (fb *FieldBuilder) Mask(pattern string) *FieldBuilder {
+	fb.field().Mask = pattern
+	return fb
+}
+
+// Help sets a hint shown under the field, such as the expected format.
+// Screen readers read it along with the field.
+func (fb *FieldBuilder) Help(text string) *FieldBuilder {
+	fb.field().Help = text
+	return fb
+}
+
 // Class overrides the input element's CSS class.
 func (fb *FieldBuilder) Class(cls string) *FieldBuilder {
 	fb.field().Class = cls
@@ -313,11 +439,26 @@ func (f *FormBuilder) Build() *Node {
 		children = append(children, Div("flex gap-2").Render(btns...))
 	}
 
-	return Form(f.class).ID(f.id).
+	form := Form(f.class).ID(f.id).
 		OnSubmit(JS("event.preventDefault()")).
 		Render(children...)
+	if f.guard {
+		form.Attr("data-guard-unsaved", f.loc().Unsaved).JS(fmt.Sprintf(guardUnsavedJS, escJS(f.id), escJS(f.summaryID())))
+	}
+	return form
 }
 
+// guardUnsavedJS marks a GuardUnsaved form dirty on every edit and gives
+// it __gsuiSaved, which a submit's reply calls to clear the mark unless
+// the reply reported errors. The WS client reads the mark.
+const guardUnsavedJS = `(function(f){` +
+	`function dirty(){f.__gsuiDirty=true}` +
+	`f.addEventListener('input',dirty);f.addEventListener('change',dirty);` +
+	`f.__gsuiSaved=function(){var s=document.getElementById('%[2]s');` +
+	`if(document.querySelector('[form="%[1]s"][aria-invalid=true]')||s&&!s.classList.contains('hidden'))return;` +
+	`f.__gsuiDirty=false}` +
+	`})(this)`
+
 // ---------------------------------------------------------------------------
 // Field rendering
 // ---------------------------------------------------------------------------
@@ -338,6 +479,36 @@ func (f *FormBuilder) errID(fld *Field) string {
 	return "err-" + f.id + "-" + fld.Name
 }
 
+func (f *FormBuilder) helpID(fld *Field) string {
+	return "help-" + f.id + "-" + fld.Name
+}
+
+// hints returns the field's help text, if any, and the hidden per-field
+// error element that client- and server-side validation fill in, linking
+// both to control (an input, or the group of a radio field) for screen
+// readers. The error element stays empty until a rule fails, so a hidden
+// message is never read as the description.
+func (f *FormBuilder) hints(fld *Field, control *Node) *Node {
+	var help *Node
+	describedBy := f.errID(fld)
+	if fld.Help != "" {
+		help = Div("text-xs text-gray-500 dark:text-gray-400").ID(f.helpID(fld)).Text(fld.Help)
+		describedBy = f.helpID(fld) + " " + describedBy
+	}
+	control.Attr("aria-describedby", describedBy)
+	return Fragment(help, Div(f.errClass).ID(f.errID(fld)).Attr("role", "alert"))
+}
+
+// label returns the label text of fld, with the required asterisk hidden
+// from screen readers, which announce aria-required instead.
+func (f *FormBuilder) label(fld *Field, class string) *Node {
+	l := Label(class).Text(fld.Label)
+	if fld.Required {
+		l.Render(Span().Attr("aria-hidden", "true").Text(" *"))
+	}
+	return l
+}
+
 func (f *FormBuilder) inputClass(fld *Field) string {
 	if fld.Class != "" {
 		return fld.Class
@@ -415,28 +586,31 @@ func (f *FormBuilder) renderInput(fld *Field) *Node {
 	if fld.Value != "" {
 		input.Attr("value", fld.Value)
 	}
-	if fld.Pattern != "" {
+	if fld.Pattern != "" && fld.Mask == "" {
 		input.Attr("pattern", fld.Pattern)
 	}
+	if fld.Type == FieldNumber {
+		if fld.Min != nil {
+			input.Attr("min", strconv.FormatFloat(*fld.Min, 'g', -1, 64))
+		}
+		if fld.Max != nil {
+			input.Attr("max", strconv.FormatFloat(*fld.Max, 'g', -1, 64))
+		}
+	} else if fld.Max != nil && fld.Mask == "" {
+		input.Attr("maxlength", strconv.Itoa(int(*fld.Max)))
+	}
+	input.Mask(fld.Mask)
 
 	wrapCls := fld.WrapClass
 	if wrapCls == "" {
 		wrapCls = "flex flex-col gap-1"
 	}
 
-	errMsg := fld.ErrMsg
-	if errMsg == "" && fld.Required {
-		errMsg = fld.Label + " is required"
-	}
-
 	children := []*Node{
-		Label("text-sm font-medium text-gray-700 dark:text-gray-300").Attr("for", f.fieldID(fld)).Text(fld.Label + f.reqSuffix(fld)),
+		f.label(fld, "text-sm font-medium text-gray-700 dark:text-gray-300").Attr("for", f.fieldID(fld)),
 		input,
 	}
-	if errMsg != "" {
-		input.Attr("aria-describedby", f.errID(fld))
-		children = append(children, Div(f.errClass).ID(f.errID(fld)).Attr("role", "alert").Text(errMsg))
-	}
+	children = append(children, f.hints(fld, input))
 	return Div(wrapCls).Render(children...)
 }
 
@@ -459,19 +633,11 @@ func (f *FormBuilder) renderTextarea(fld *Field) *Node {
 		wrapCls = "flex flex-col gap-1"
 	}
 
-	errMsg := fld.ErrMsg
-	if errMsg == "" && fld.Required {
-		errMsg = fld.Label + " is required"
-	}
-
 	children := []*Node{
-		Label("text-sm font-medium text-gray-700 dark:text-gray-300").Attr("for", f.fieldID(fld)).Text(fld.Label + f.reqSuffix(fld)),
+		f.label(fld, "text-sm font-medium text-gray-700 dark:text-gray-300").Attr("for", f.fieldID(fld)),
 		ta,
 	}
-	if errMsg != "" {
-		ta.Attr("aria-describedby", f.errID(fld))
-		children = append(children, Div(f.errClass).ID(f.errID(fld)).Attr("role", "alert").Text(errMsg))
-	}
+	children = append(children, f.hints(fld, ta))
 	return Div(wrapCls).Render(children...)
 }
 
@@ -484,15 +650,8 @@ func (f *FormBuilder) renderCheckbox(fld *Field) *Node {
 	if fld.Required {
 		cb.Attr("aria-required", "true")
 	}
-	errMsg := fld.ErrMsg
-	if errMsg == "" && fld.Required {
-		errMsg = fld.Label + " is required"
-	}
 	children := []*Node{Label("flex items-center gap-2 text-sm cursor-pointer").Attr("for", f.fieldID(fld)).Render(cb, Span().Text(fld.Label))}
-	if errMsg != "" {
-		cb.Attr("aria-describedby", f.errID(fld))
-		children = append(children, Div(f.errClass).ID(f.errID(fld)).Attr("role", "alert").Text(errMsg))
-	}
+	children = append(children, f.hints(fld, cb))
 	return Div("flex flex-col gap-1").Render(children...)
 }
 
@@ -517,22 +676,24 @@ func (f *FormBuilder) renderSelect(fld *Field) *Node {
 		wrapCls = "flex flex-col gap-1"
 	}
 
-	errMsg := fld.ErrMsg
-	if errMsg == "" && fld.Required {
-		errMsg = fld.Label + " is required"
-	}
-
 	children := []*Node{
-		Label("text-sm font-medium text-gray-700 dark:text-gray-300").Attr("for", f.fieldID(fld)).Text(fld.Label + f.reqSuffix(fld)),
+		f.label(fld, "text-sm font-medium text-gray-700 dark:text-gray-300").Attr("for", f.fieldID(fld)),
 		sel,
 	}
-	if errMsg != "" {
-		sel.Attr("aria-describedby", f.errID(fld))
-		children = append(children, Div(f.errClass).ID(f.errID(fld)).Attr("role", "alert").Text(errMsg))
-	}
+	children = append(children, f.hints(fld, sel))
 	return Div(wrapCls).Render(children...)
 }
 
+// radioGroup returns the radiogroup wrapping the radios of fld, labelled
+// by labelID; it carries aria-required, as radios themselves cannot.
+func (f *FormBuilder) radioGroup(fld *Field, class, labelID string) *Node {
+	group := Div(class).Attr("role", "radiogroup").Attr("aria-labelledby", labelID)
+	if fld.Required {
+		group.Attr("aria-required", "true")
+	}
+	return group
+}
+
 // renderRadioInline creates simple inline radio buttons: <label><input radio> text</label>
 // Radio names are scoped with the form ID to prevent cross-form collisions.
 func (f *FormBuilder) renderRadioInline(fld *Field) *Node {
@@ -543,9 +704,6 @@ func (f *FormBuilder) renderRadioInline(fld *Field) *Node {
 		radio := f.formAttr(IRadio("w-4 h-4").Attr("name", rName).Attr("value", o.Value))
 		if i == 0 {
 			radio.ID(f.fieldID(fld))
-			if fld.Required {
-				radio.Attr("aria-required", "true")
-			}
 		}
 		if fld.Value == o.Value {
 			radio.Attr("checked", "true")
@@ -561,19 +719,12 @@ func (f *FormBuilder) renderRadioInline(fld *Field) *Node {
 		wrapCls = "flex flex-col gap-1"
 	}
 
-	errMsg := fld.ErrMsg
-	if errMsg == "" && fld.Required {
-		errMsg = fld.Label + " is required"
-	}
-
-	children := []*Node{
-		Label("text-sm font-medium text-gray-700 dark:text-gray-300").ID(labelID).Text(fld.Label + f.reqSuffix(fld)),
-		Div("flex gap-4").Attr("role", "group").Attr("aria-labelledby", labelID).Render(radios...),
-	}
-	if errMsg != "" {
-		children = append(children, Div(f.errClass).ID(f.errID(fld)).Attr("role", "alert").Text(errMsg))
-	}
-	return Div(wrapCls).Render(children...)
+	group := f.radioGroup(fld, "flex gap-4", labelID).Render(radios...)
+	return Div(wrapCls).Render(
+		f.label(fld, "text-sm font-medium text-gray-700 dark:text-gray-300").ID(labelID),
+		group,
+		f.hints(fld, group),
+	)
 }
 
 // renderRadioButton creates visible card-style radios with border + hover.
@@ -586,9 +737,6 @@ func (f *FormBuilder) renderRadioButton(fld *Field) *Node {
 		radio := f.formAttr(IRadio("w-4 h-4").Attr("name", rName).Attr("value", o.Value))
 		if i == 0 {
 			radio.ID(f.fieldID(fld))
-			if fld.Required {
-				radio.Attr("aria-required", "true")
-			}
 		}
 		if fld.Value == o.Value {
 			radio.Attr("checked", "true")
@@ -604,19 +752,12 @@ func (f *FormBuilder) renderRadioButton(fld *Field) *Node {
 		wrapCls = "flex flex-col gap-1"
 	}
 
-	errMsg := fld.ErrMsg
-	if errMsg == "" && fld.Required {
-		errMsg = fld.Label + " is required"
-	}
-
-	children := []*Node{
-		Label("text-sm font-medium text-gray-700 dark:text-gray-300").ID(labelID).Text(fld.Label + f.reqSuffix(fld)),
-		Div("flex gap-2").Attr("role", "group").Attr("aria-labelledby", labelID).Render(cards...),
-	}
-	if errMsg != "" {
-		children = append(children, Div(f.errClass).ID(f.errID(fld)).Attr("role", "alert").Text(errMsg))
-	}
-	return Div(wrapCls).Render(children...)
+	group := f.radioGroup(fld, "flex gap-2", labelID).Render(cards...)
+	return Div(wrapCls).Render(
+		f.label(fld, "text-sm font-medium text-gray-700 dark:text-gray-300").ID(labelID),
+		group,
+		f.hints(fld, group),
+	)
 }
 
 // renderRadioCard creates hidden-input card radios with peer-checked CSS.
@@ -629,9 +770,6 @@ func (f *FormBuilder) renderRadioCard(fld *Field) *Node {
 		radio := f.formAttr(IRadio("peer hidden").Attr("name", rName).Attr("value", o.Value))
 		if i == 0 {
 			radio.ID(f.fieldID(fld))
-			if fld.Required {
-				radio.Attr("aria-required", "true")
-			}
 		}
 		if fld.Value == o.Value {
 			radio.Attr("checked", "true")
@@ -647,26 +785,12 @@ func (f *FormBuilder) renderRadioCard(fld *Field) *Node {
 		wrapCls = "flex flex-col gap-1"
 	}
 
-	errMsg := fld.ErrMsg
-	if errMsg == "" && fld.Required {
-		errMsg = fld.Label + " is required"
-	}
-
-	children := []*Node{
-		Label("text-sm font-medium text-gray-700 dark:text-gray-300").ID(labelID).Text(fld.Label + f.reqSuffix(fld)),
-		Div("flex gap-3").Attr("role", "group").Attr("aria-labelledby", labelID).Render(cards...),
-	}
-	if errMsg != "" {
-		children = append(children, Div(f.errClass).ID(f.errID(fld)).Attr("role", "alert").Text(errMsg))
-	}
-	return Div(wrapCls).Render(children...)
-}
-
-func (f *FormBuilder) reqSuffix(fld *Field) string {
-	if fld.Required {
-		return " *"
-	}
-	return ""
+	group := f.radioGroup(fld, "flex gap-3", labelID).Render(cards...)
+	return Div(wrapCls).Render(
+		f.label(fld, "text-sm font-medium text-gray-700 dark:text-gray-300").ID(labelID),
+		group,
+		f.hints(fld, group),
+	)
 }
 
 // ---------------------------------------------------------------------------
@@ -682,11 +806,36 @@ func (f *FormBuilder) reqSuffix(fld *Field) string {
 func (f *FormBuilder) buildValidateJS(actionValue string) string {
 	var b strings.Builder
 	b.WriteString("(function(){")
+	f.writeChecks(&b)
+	fmt.Fprintf(&b, "var d={Action:'%s'};", escJS(actionValue))
+	f.writeCollect(&b)
+
+	// Phase 4: disable the clicked submit button until the server replies
+	// (the WS client re-enables gsui-busy buttons on the next message),
+	// then call the WS action.
+	b.WriteString(busyButtonJS)
+	if f.guard {
+		fmt.Fprintf(&b, "__ws.call('%s',d,null,{done:function(){var f=document.getElementById('%s');if(f&&f.__gsuiSaved)f.__gsuiSaved()}});", escJS(f.actionName), escJS(f.id))
+	} else {
+		fmt.Fprintf(&b, "__ws.call('%s',d);", escJS(f.actionName))
+	}
+	b.WriteString("})()")
+
+	return b.String()
+}
+
+// busyButtonJS disables the clicked submit button until the server replies.
+const busyButtonJS = "var sb=event.currentTarget;if(sb&&sb.tagName==='BUTTON'&&!sb.disabled){sb.disabled=true;sb.classList.add('gsui-busy','opacity-60','cursor-wait')}"
+
+// writeChecks writes the helper functions and the validation of every
+// field; the code returns early, after focusing the first invalid field,
+// when a rule fails.
+func (f *FormBuilder) writeChecks(b *strings.Builder) {
 	b.WriteString("var ok=true;")
 
 	// Helper functions
-	b.WriteString("var first=null;function err(id,show,fieldID){var e=document.getElementById(id),inp=document.getElementById(fieldID);if(e)e.classList.toggle('hidden',!show);if(inp){if(show){inp.setAttribute('aria-invalid','true');if(!first)first=inp}else inp.removeAttribute('aria-invalid')}}")
-	b.WriteString("function val(id){var e=document.getElementById(id);if(!e)return '';if(e.tagName.toLowerCase()==='textarea')return e.value.trim();return e.value.trim()}")
+	b.WriteString("var first=null;function err(id,show,fieldID,msg){var e=document.getElementById(id),inp=document.getElementById(fieldID);if(e){e.classList.toggle('hidden',!show);if(msg)e.textContent=msg;else if(!show)e.textContent=''}if(inp){if(show){inp.setAttribute('aria-invalid','true');if(!first)first=inp}else inp.removeAttribute('aria-invalid')}}")
+	b.WriteString("function val(id){var e=document.getElementById(id);if(!e)return '';if(e.hasAttribute('data-mask')||e.hasAttribute('data-money')||e.hasAttribute('data-number'))return (e.dataset.raw||'').trim();return e.value.trim()}")
 	// radioVal uses the scoped name (formID-fieldName) to query only radios
 	// belonging to this form, preventing cross-form interference.
 	b.WriteString("function radioVal(name){var c=document.querySelector('input[type=radio][name=\"'+name+'\"]:checked');return c?c.value:''}")
@@ -696,54 +845,77 @@ func (f *FormBuilder) buildValidateJS(actionValue string) string {
 	// Phase 1: clear all errors
 	for i := range f.fields {
 		fld := &f.fields[i]
-		if fld.Required || fld.Pattern != "" {
-			fmt.Fprintf(&b, "err('%s',false,'%s');", escJS(f.errID(fld)), escJS(f.fieldID(fld)))
+		if f.validated(fld) {
+			fmt.Fprintf(b, "err('%s',false,'%s');", escJS(f.errID(fld)), escJS(f.fieldID(fld)))
 		}
 	}
 
-	// Phase 2: validate required fields
+	// Phase 2: validate; the first failing rule of each field shows its message
 	for i := range f.fields {
 		fld := &f.fields[i]
-		if !fld.Required && fld.Pattern == "" {
+		if !f.validated(fld) {
 			continue
 		}
 
 		errID := f.errID(fld)
 		fieldID := f.fieldID(fld)
 		rName := f.radioName(fld) // scoped radio name
+		fail := func(rule string) string {
+			return fmt.Sprintf("(err('%s',true,'%s','%s'),ok=false)", escJS(errID), escJS(fieldID), escJS(f.ruleMsg(fld, rule)))
+		}
 
 		switch fld.Type {
 		case FieldRadio, FieldRadioBtn, FieldRadioCard:
 			if fld.Required {
-				fmt.Fprintf(&b, "if(!radioVal('%s')){err('%s',true,'%s');ok=false}", escJS(rName), escJS(errID), escJS(fieldID))
+				fmt.Fprintf(b, "if(!radioVal('%s'))%s;", escJS(rName), fail("required"))
 			}
 		case FieldSelect:
 			if fld.Required {
-				fmt.Fprintf(&b, "if(!selVal('%s')){err('%s',true,'%s');ok=false}", escJS(fieldID), escJS(errID), escJS(fieldID))
+				fmt.Fprintf(b, "if(!selVal('%s'))%s;", escJS(fieldID), fail("required"))
 			}
 		case FieldCheckbox:
 			if fld.Required {
-				fmt.Fprintf(&b, "if(!checkVal('%s')){err('%s',true,'%s');ok=false}", escJS(fieldID), escJS(errID), escJS(fieldID))
+				fmt.Fprintf(b, "if(!checkVal('%s'))%s;", escJS(fieldID), fail("required"))
 			}
 		default:
-			if fld.Required {
-				fmt.Fprintf(&b, "if(!val('%s')){err('%s',true,'%s');ok=false}", escJS(fieldID), escJS(errID), escJS(fieldID))
-			}
+			// Rules other than required apply only to non-empty values
+			fmt.Fprintf(b, "(function(v){if(!v){if(%t)%s;return}", fld.Required, fail("required"))
 			if fld.Pattern != "" {
 				patternJSON, _ := json.Marshal(fld.Pattern)
-				fmt.Fprintf(&b, "if(val('%s')&&!new RegExp(%s).test(val('%s'))){err('%s',true,'%s');ok=false}",
-					escJS(fieldID), string(patternJSON), escJS(fieldID), escJS(errID), escJS(fieldID))
+				fmt.Fprintf(b, "if(!new RegExp(%s).test(v))return %s;", string(patternJSON), fail("invalid"))
 			}
+			if fld.Type == FieldEmail {
+				fmt.Fprintf(b, "if(!/^[^\\s@]+@[^\\s@]+\\.[^\\s@]+$/.test(v))return %s;", fail("email"))
+			}
+			if fld.Type == FieldNumber && (fld.Min != nil || fld.Max != nil) {
+				fmt.Fprintf(b, "var n=Number(v);if(isNaN(n))return %s;", fail("invalid"))
+				if fld.Min != nil {
+					fmt.Fprintf(b, "if(n<%s)return %s;", strconv.FormatFloat(*fld.Min, 'g', -1, 64), fail("min"))
+				}
+				if fld.Max != nil {
+					fmt.Fprintf(b, "if(n>%s)return %s;", strconv.FormatFloat(*fld.Max, 'g', -1, 64), fail("max"))
+				}
+			} else if fld.Type != FieldNumber {
+				if fld.Min != nil {
+					fmt.Fprintf(b, "if(Array.from(v).length<%d)return %s;", int(*fld.Min), fail("minlen"))
+				}
+				if fld.Max != nil {
+					fmt.Fprintf(b, "if(Array.from(v).length>%d)return %s;", int(*fld.Max), fail("maxlen"))
+				}
+			}
+			fmt.Fprintf(b, "})(val('%s'));", escJS(fieldID))
 		}
 	}
 
 	b.WriteString("if(!ok){if(first){first.focus();first.scrollIntoView({block:'center',behavior:'smooth'})}return;}")
+}
 
+// writeCollect writes the code that copies every field value into the
+// data object d, using the helpers of writeChecks.
+func (f *FormBuilder) writeCollect(b *strings.Builder) {
 	// Phase 3: collect all values into data object.
 	// Keys use the original field Name (not the scoped radio name) so the
 	// server receives clean names like "Gender", not "myform-Gender".
-	fmt.Fprintf(&b, "var d={Action:'%s'};", escJS(actionValue))
-
 	for i := range f.fields {
 		fld := &f.fields[i]
 		fieldID := f.fieldID(fld)
@@ -753,24 +925,15 @@ func (f *FormBuilder) buildValidateJS(actionValue string) string {
 		switch fld.Type {
 		case FieldRadio, FieldRadioBtn, FieldRadioCard:
 			// Query the scoped radio name, store under the clean field name
-			fmt.Fprintf(&b, "d['%s']=radioVal('%s');", escJS(name), escJS(rName))
+			fmt.Fprintf(b, "d['%s']=radioVal('%s');", escJS(name), escJS(rName))
 		case FieldCheckbox:
-			fmt.Fprintf(&b, "d['%s']=checkVal('%s');", escJS(name), escJS(fieldID))
+			fmt.Fprintf(b, "d['%s']=checkVal('%s');", escJS(name), escJS(fieldID))
 		case FieldSelect:
-			fmt.Fprintf(&b, "d['%s']=selVal('%s');", escJS(name), escJS(fieldID))
+			fmt.Fprintf(b, "d['%s']=selVal('%s');", escJS(name), escJS(fieldID))
 		default:
-			fmt.Fprintf(&b, "d['%s']=val('%s');", escJS(name), escJS(fieldID))
+			fmt.Fprintf(b, "d['%s']=val('%s');", escJS(name), escJS(fieldID))
 		}
 	}
-
-	// Phase 4: disable the clicked submit button until the server replies
-	// (the WS client re-enables gsui-busy buttons on the next message),
-	// then call the WS action.
-	b.WriteString("var sb=event.currentTarget;if(sb&&sb.tagName==='BUTTON'&&!sb.disabled){sb.disabled=true;sb.classList.add('gsui-busy','opacity-60','cursor-wait')}")
-	fmt.Fprintf(&b, "__ws.call('%s',d);", escJS(f.actionName))
-	b.WriteString("})()")
-
-	return b.String()
 }
 
 // ---------------------------------------------------------------------------
@@ -786,6 +949,17 @@ func (fe FormErrors) HasErrors() bool { return len(fe) > 0 }
 // Get returns the error for a specific field name, or empty string.
 func (fe FormErrors) Get(name string) string { return fe[name] }
 
+// Error lists the failed fields sorted by name, so FormErrors can travel
+// as an error.
+func (fe FormErrors) Error() string {
+	names := slices.Sorted(maps.Keys(fe))
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + fe[name]
+	}
+	return strings.Join(parts, "; ")
+}
+
 // ShowErrors returns JS that displays server-side validation errors in the
 // per-field error elements produced by Build.
 //
@@ -808,56 +982,198 @@ func (f *FormBuilder) ShowErrors(errs FormErrors) string {
 	return b.String()
 }
 
+// ErrorSummary returns JS that lists every problem in a box at the top of
+// the form and moves focus there. When err is (or wraps) FormErrors, each
+// entry links to its field in form order; any other error is shown as a
+// single message. A nil err hides the box. Forms render no box until the
+// first ErrorSummary reply adds it.
+//
+//	errs := form.Validate(ctx.WsData())
+//	return form.ShowErrors(errs) + form.ErrorSummary(errs)
+func (f *FormBuilder) ErrorSummary(err error) string {
+	if err == nil {
+		return f.summaryJS(nil)
+	}
+	var items []*Node
+	var fe FormErrors
+	if errors.As(err, &fe) {
+		if len(fe) == 0 {
+			return f.summaryJS(nil)
+		}
+		for i := range f.fields {
+			fld := &f.fields[i]
+			msg, ok := fe[fld.Name]
+			if !ok {
+				continue
+			}
+			focus := fmt.Sprintf("event.preventDefault();var i=document.getElementById('%s');if(i){i.focus();i.scrollIntoView({block:'center',behavior:'smooth'})}", escJS(f.fieldID(fld)))
+			items = append(items, Li().Render(
+				A("underline hover:no-underline").Attr("href", "#"+f.fieldID(fld)).Text(msg).OnClick(JS(focus)),
+			))
+		}
+	}
+	if len(items) == 0 {
+		items = append(items, Li().Text(err.Error()))
+	}
+	return f.summaryJS(items) +
+		fmt.Sprintf("document.getElementById('%s').focus();", escJS(f.summaryID()))
+}
+
+// ShowError is the one-call error display for form actions. FormErrors
+// (or an error wrapping them) mark their fields and fill the summary; any
+// other error, such as a ctx.Body decode failure, clears the field markers
+// and is shown in the summary instead of being mistaken for validation
+// errors. A nil err clears both.
+//
+//	if err := ctx.Body(&data); err != nil {
+//		return form.ShowError(err)
+//	}
+func (f *FormBuilder) ShowError(err error) string {
+	var fe FormErrors
+	if !errors.As(err, &fe) {
+		fe = nil
+	}
+	return f.ShowErrors(fe) + f.ErrorSummary(err)
+}
+
+func (f *FormBuilder) summaryID() string { return f.id + "-summary" }
+
+// summaryJS swaps in the ErrorSummary box listing items, first adding a
+// placeholder at the top of the form when the box is not there yet.
+// Without items it hides a box already shown and adds none.
+func (f *FormBuilder) summaryJS(items []*Node) string {
+	id := escJS(f.summaryID())
+	if len(items) == 0 {
+		return "if(document.getElementById('" + id + "')){" + f.summaryNode(nil).ToJSReplace(f.summaryID()) + "}"
+	}
+	return fmt.Sprintf("(function(){if(document.getElementById('%[1]s'))return;var f=document.getElementById('%[2]s');"+
+		"if(f){var s=document.createElement('div');s.id='%[1]s';f.prepend(s)}})();", id, escJS(f.id)) +
+		f.summaryNode(items).ToJSReplace(f.summaryID())
+}
+
+// summaryNode renders the ErrorSummary box; with no items it is empty and
+// hidden.
+func (f *FormBuilder) summaryNode(items []*Node) *Node {
+	box := Div("rounded-lg border border-red-300 dark:border-red-700 bg-red-50 dark:bg-red-950 text-red-800 dark:text-red-200 px-4 py-3 text-sm").
+		ID(f.summaryID()).Attr("role", "alert").Attr("tabindex", "-1")
+	if len(items) == 0 {
+		return box.Class("hidden")
+	}
+	l := f.loc()
+	hide := fmt.Sprintf("document.getElementById('%s').classList.add('hidden')", escJS(f.summaryID()))
+	return box.Render(
+		Div("flex items-start justify-between gap-2").Render(
+			Div("font-medium").Text(l.Summary(len(items))),
+			Button("cursor-pointer opacity-70 hover:opacity-100").Attr("type", "button").
+				Attr("aria-label", l.Dismiss).Text("×").OnClick(JS(hide)),
+		),
+		Ul("list-disc pl-5 mt-1 flex flex-col gap-0.5").Render(items...),
+	)
+}
+
 // Validate checks the data map against the form's field definitions.
-// It returns a FormErrors map (empty if all valid).
+// It returns a FormErrors map (empty if all valid) holding, per field, the
+// message of the first failing rule: required, a value that is not one of
+// the field's Opts (so tampered selects and radios are caught), pattern,
+// email (for Email fields), then Min/Max.
 // The data parameter should be the map[string]any from ctx.Body().
 func (f *FormBuilder) Validate(data map[string]any) FormErrors {
 	errs := make(FormErrors)
 
 	for i := range f.fields {
 		fld := &f.fields[i]
+		if rule := f.failedRule(fld, data[fld.Name]); rule != "" {
+			errs[fld.Name] = f.ruleMsg(fld, rule)
+		}
+	}
 
+	return errs
+}
+
+// emailPattern is a deliberately loose address check; the server that sends
+// mail is the real validator.
+var emailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+
+// validated reports whether fld has any rule to check.
+func (f *FormBuilder) validated(fld *Field) bool {
+	return fld.Required || fld.Pattern != "" || fld.Type == FieldEmail || fld.Min != nil || fld.Max != nil || len(fld.Options) > 0
+}
+
+// failedRule returns the first rule that value breaks, or "".
+func (f *FormBuilder) failedRule(fld *Field, value any) string {
+	if fld.Type == FieldCheckbox {
+		if v, _ := value.(bool); fld.Required && !v {
+			return "required"
+		}
+		return ""
+	}
+	v := fmt.Sprintf("%v", value)
+	if value == nil {
+		v = ""
+	}
+	if v == "" {
 		if fld.Required {
-			switch fld.Type {
-			case FieldCheckbox:
-				v, _ := data[fld.Name].(bool)
-				if !v {
-					errs[fld.Name] = f.errMsg(fld)
-				}
-			default:
-				v := fmt.Sprintf("%v", data[fld.Name])
-				if v == "" || v == "<nil>" {
-					errs[fld.Name] = f.errMsg(fld)
-				}
-			}
+			return "required"
 		}
-
-		// Pattern validation (server-side) for string fields
-		if fld.Pattern != "" {
-			v := fmt.Sprintf("%v", data[fld.Name])
-			if v != "" && v != "<nil>" {
-				// Use Go regexp for server-side pattern check
-				// We import regexp lazily to avoid import cost when not used
-				matched := matchPattern(fld.Pattern, v)
-				if !matched {
-					msg := fld.ErrMsg
-					if msg == "" {
-						msg = fld.Label + " format is invalid"
-					}
-					errs[fld.Name] = msg
-				}
-			}
+		return ""
+	}
+	if len(fld.Options) > 0 && !slices.ContainsFunc(fld.Options, func(o FieldOption) bool { return o.Value == v }) {
+		return "invalid"
+	}
+	if fld.Pattern != "" && !matchPattern(fld.Pattern, v) {
+		return "invalid"
+	}
+	if fld.Type == FieldEmail && !emailPattern.MatchString(v) {
+		return "email"
+	}
+	if fld.Type == FieldNumber {
+		if fld.Min == nil && fld.Max == nil {
+			return ""
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "invalid"
+		}
+		if fld.Min != nil && n < *fld.Min {
+			return "min"
 		}
+		if fld.Max != nil && n > *fld.Max {
+			return "max"
+		}
+		return ""
 	}
-
-	return errs
+	if fld.Min != nil && utf8.RuneCountInString(v) < int(*fld.Min) {
+		return "minlen"
+	}
+	if fld.Max != nil && utf8.RuneCountInString(v) > int(*fld.Max) {
+		return "maxlen"
+	}
+	return ""
 }
 
-func (f *FormBuilder) errMsg(fld *Field) string {
+// ruleMsg returns the message shown when fld breaks rule. A field's custom
+// ErrMsg wins over the locale.
+func (f *FormBuilder) ruleMsg(fld *Field, rule string) string {
 	if fld.ErrMsg != "" {
 		return fld.ErrMsg
 	}
-	return fld.Label + " is required"
+	l := f.loc()
+	switch rule {
+	case "invalid":
+		return l.Invalid(fld.Label)
+	case "email":
+		return l.Email(fld.Label)
+	case "min":
+		return l.Min(fld.Label, *fld.Min)
+	case "max":
+		return l.Max(fld.Label, *fld.Max)
+	case "minlen":
+		return l.MinLen(fld.Label, int(*fld.Min))
+	case "maxlen":
+		return l.MaxLen(fld.Label, int(*fld.Max))
+	default:
+		return l.Required(fld.Label)
+	}
 }
 
 // matchPattern matches a regex pattern against a string value.
@@ -867,7 +1183,7 @@ func matchPattern(pattern, value string) bool {
 	matched, err := regexp.MatchString("^(?:"+pattern+")$", value)
 	if err != nil {
 		if _, loaded := invalidFormPatterns.LoadOrStore(pattern, struct{}{}); !loaded {
-			log.Printf("gsui: invalid form validation pattern %q: %v", pattern, err)
+			defaultLogger.Errorf("invalid form validation pattern %q: %v", pattern, err)
 		}
 		return false
 	}
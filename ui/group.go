@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Route groups: shared prefix and middleware
+// ---------------------------------------------------------------------------
+
+// Middleware wraps a page handler. It runs for full page loads and for SPA
+// navigations alike, so navigating from another page does not skip a
+// guard. Call next to continue, or return a different node (a login
+// prompt, a Redirect) to stop:
+//
+//	func requireUser(next ui.PageHandler) ui.PageHandler {
+//		return func(ctx *ui.Context) *ui.Node {
+//			if ctx.Session["user"] == nil {
+//				return ui.Div().JS(ui.Redirect("/login"))
+//			}
+//			return next(ctx)
+//		}
+//	}
+//
+// Middleware guards page renders only. Actions, including those a guarded
+// page registers with Callable or Context.Action, are called over the
+// WebSocket by name and never pass through it, so handlers of sensitive
+// actions must check the session themselves.
+type Middleware func(next PageHandler) PageHandler
+
+// Group is a set of routes sharing a path prefix and middleware, created
+// by App.Group.
+type Group struct {
+	app    *App
+	prefix string
+	mw     []Middleware
+}
+
+// Group returns a group whose routes are registered under prefix and wrapped
+// in mw, first middleware outermost:
+//
+//	admin := app.Group("/admin", requireUser, requireRole("admin"))
+//	admin.Page("/users", adminUsers)          // /admin/users
+//	admin.Handle("DELETE", "/users/{id}", deleteUser)
+func (app *App) Group(prefix string, mw ...Middleware) *Group {
+	return &Group{app: app, prefix: strings.TrimSuffix(prefix, "/"), mw: mw}
+}
+
+// Group returns a nested group: its prefix extends g's and its middleware
+// runs inside g's.
+func (g *Group) Group(prefix string, mw ...Middleware) *Group {
+	return &Group{
+		app:    g.app,
+		prefix: g.prefix + strings.TrimSuffix(prefix, "/"),
+		mw:     append(append([]Middleware(nil), g.mw...), mw...),
+	}
+}
+
+// Page registers a page at the group prefix + pattern, see App.Page.
+func (g *Group) Page(pattern string, handler PageHandler) {
+	g.app.page(g.prefix+pattern, g.wrap(handler), funcName(handler))
+}
+
+// Handle registers an HTTP handler at the group prefix + path, see
+// App.Handle. The middleware sees a Context built from the request; when it
+// returns without calling next the request is refused with 403 Forbidden.
+// Session changes the middleware made are saved before handler runs, so a
+// store failure is answered with 503 instead of going unnoticed after the
+// handler has written its response.
+func (g *Group) Handle(method, path string, handler http.HandlerFunc) {
+	g.app.Handle(method, g.prefix+path, func(w http.ResponseWriter, r *http.Request) {
+		called := false
+		page := g.wrap(func(ctx *Context) *Node {
+			called = true
+			if ctx.sessionErr == nil {
+				if err := ctx.SaveSession(); err != nil && !errors.Is(err, ErrNoSession) {
+					ctx.log().Errorf("session save sid=%s: %v", sessionTag(ctx.sessionID), err)
+					http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+					return nil
+				}
+			}
+			handler(w, ctx.Request)
+			return nil
+		})
+		ctx := &Context{
+			Request:    r,
+			PathParams: requestPathParams(r),
+			Query:      make(map[string]string),
+			app:        g.app,
+			sessionID:  requestSessionID(r),
+			requestID:  requestIDOf(r),
+		}
+		for k, v := range r.URL.Query() {
+			if len(v) > 0 {
+				ctx.Query[k] = v[0]
+			}
+		}
+		defer g.app.bindCtx(ctx, r.Context())()
+		g.app.loadSession(ctx)
+		page(ctx)
+		g.app.saveSession(ctx)
+		if !called {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+	})
+}
+
+// wrap applies the group's middleware to handler.
+func (g *Group) wrap(handler PageHandler) PageHandler {
+	for i := len(g.mw) - 1; i >= 0; i-- {
+		handler = g.mw[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,453 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// i18n: message catalogs and plural selection
+// ---------------------------------------------------------------------------
+
+// defaultLocale is used when the request matches no catalog.
+const defaultLocale = "en"
+
+// localeKey holds the SetLocale preference in Context.Session; localeCookie
+// carries it for visitors whose session has expired.
+const (
+	localeKey    = "__locale"
+	localeCookie = "gsui_lang"
+)
+
+// Translations adds catalog to the messages of locale (a BCP 47 tag such as
+// "sk" or "pt-BR"), merging with earlier calls. Messages may use fmt verbs
+// and ICU plural blocks, see Context.Translate.
+//
+//	app.Translations("sk", map[string]string{
+//		"hello": "Ahoj, %s!",
+//		"items": "{count, plural, one{# položka} few{# položky} other{# položiek}}",
+//	})
+func (app *App) Translations(locale string, catalog map[string]string) {
+	locale = normalizeLocale(locale)
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if app.catalogs == nil {
+		app.catalogs = make(map[string]map[string]string)
+	}
+	if app.catalogs[locale] == nil {
+		app.catalogs[locale] = make(map[string]string, len(catalog))
+	}
+	maps.Copy(app.catalogs[locale], catalog)
+}
+
+// DefaultLocale sets the locale used when a request matches no catalog
+// ("en" unless set).
+func (app *App) DefaultLocale(locale string) {
+	app.mu.Lock()
+	app.defLocale = normalizeLocale(locale)
+	app.mu.Unlock()
+}
+
+func (app *App) defaultLocale() string {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	if app.defLocale != "" {
+		return app.defLocale
+	}
+	return defaultLocale
+}
+
+// normalizeLocale lower-cases a tag and uses '-' as separator ("pt_br" ->
+// "pt-br") so lookups are case-insensitive.
+func normalizeLocale(l string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(l), "_", "-"))
+}
+
+// baseLocale returns the language part of a tag ("pt-br" -> "pt").
+func baseLocale(l string) string {
+	base, _, _ := strings.Cut(l, "-")
+	return base
+}
+
+// matchLocale returns the catalog locale that serves l: an exact match, the
+// base language, or a regional catalog of the same language. "" if none.
+func (app *App) matchLocale(l string) string {
+	l = normalizeLocale(l)
+	if l == "" {
+		return ""
+	}
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	if _, ok := app.catalogs[l]; ok {
+		return l
+	}
+	base := baseLocale(l)
+	if _, ok := app.catalogs[base]; ok {
+		return base
+	}
+	for _, c := range slices.Sorted(maps.Keys(app.catalogs)) {
+		if baseLocale(c) == base {
+			return c
+		}
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns the tags of an Accept-Language header, most
+// preferred first. Tags with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.TrimSpace(name)
+		if name == "" || name == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{name, q})
+		}
+	}
+	slices.SortStableFunc(tags, func(a, b tag) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.name
+	}
+	return out
+}
+
+// validLocale reports whether l looks like a language tag, so preferences
+// from cookies and clients are never echoed back unchecked.
+func validLocale(l string) bool {
+	if l == "" || len(l) > 35 {
+		return false
+	}
+	for _, r := range l {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// preferred resolves an explicit preference: the catalog serving it, or the
+// tag itself when the app has no catalogs at all.
+func (app *App) preferred(l string) string {
+	l = normalizeLocale(l)
+	if !validLocale(l) {
+		return ""
+	}
+	if m := app.matchLocale(l); m != "" {
+		return m
+	}
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	if len(app.catalogs) == 0 {
+		return l
+	}
+	return ""
+}
+
+// Locale returns the locale of the current request, trying in order the
+// preference saved by SetLocale, the gsui_lang cookie, the Accept-Language
+// header, and the app's DefaultLocale. With catalogs registered the result
+// is always one of their locales. Pages use it for <html lang>.
+func (ctx *Context) Locale() string {
+	if ctx.app == nil {
+		return defaultLocale
+	}
+	if pref, _ := ctx.Session[localeKey].(string); pref != "" {
+		if l := ctx.app.preferred(pref); l != "" {
+			return l
+		}
+	}
+	if ctx.Request != nil {
+		if c, err := ctx.Request.Cookie(localeCookie); err == nil {
+			if l := ctx.app.preferred(c.Value); l != "" {
+				return l
+			}
+		}
+		for _, l := range parseAcceptLanguage(ctx.Request.Header.Get("Accept-Language")) {
+			if m := ctx.app.matchLocale(l); m != "" {
+				return m
+			}
+		}
+	}
+	return ctx.app.defaultLocale()
+}
+
+// SetLocale saves locale as the session's language preference; Locale and
+// Translate use it from the next request on. Invalid tags are ignored.
+func (ctx *Context) SetLocale(locale string) {
+	locale = normalizeLocale(locale)
+	if !validLocale(locale) {
+		return
+	}
+	if ctx.Session == nil {
+		ctx.Session = make(map[string]any)
+	}
+	ctx.Session[localeKey] = locale
+}
+
+// Translate returns the message for key in the request's Locale, or key
+// itself when no catalog has it. Lookup falls back from the request's
+// locale to the default locale. Plural blocks are resolved first, then
+// fmt verbs are filled from args:
+//
+//	"{count, plural, =0{no items} one{# item} other{# items}}"
+//
+// The block's variable is an argument index ("{1, plural, ...}") or any
+// name, which selects the first numeric argument. '#' inside a branch is
+// replaced by the number. Branches are =N exact matches and the CLDR
+// categories zero, one, two, few, many and other; "other" is required.
+// fmt verbs see all args, so a message mixing both refers to the others by
+// explicit index: "{n, plural, one{# file} other{# files}} in %[2]s".
+func (ctx *Context) Translate(key string, args ...any) string {
+	locale := ctx.Locale()
+	msg := key
+	if ctx.app != nil {
+		msg = ctx.app.message(locale, key)
+	}
+	return formatMessage(locale, msg, args)
+}
+
+// message looks key up in locale, then in the default locale.
+func (app *App) message(locale, key string) string {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	if m, ok := app.catalogs[locale][key]; ok {
+		return m
+	}
+	def := app.defLocale
+	if def == "" {
+		def = defaultLocale
+	}
+	if m, ok := app.catalogs[def][key]; ok {
+		return m
+	}
+	return key
+}
+
+// formatMessage expands plural blocks in msg, then applies fmt verbs.
+func formatMessage(locale, msg string, args []any) string {
+	msg = expandPlurals(locale, msg, args)
+	if len(args) > 0 && strings.Contains(msg, "%") {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// expandPlurals replaces every "{var, plural, ...}" block of msg.
+// Malformed blocks are left as they are.
+func expandPlurals(locale, msg string, args []any) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(msg, "{")
+		if start < 0 {
+			b.WriteString(msg)
+			return b.String()
+		}
+		end := matchBrace(msg, start)
+		if end < 0 {
+			b.WriteString(msg)
+			return b.String()
+		}
+		b.WriteString(msg[:start])
+		if out, ok := pluralBlock(locale, msg[start+1:end], args); ok {
+			b.WriteString(out)
+		} else {
+			b.WriteString(msg[start : end+1])
+		}
+		msg = msg[end+1:]
+	}
+}
+
+// matchBrace returns the index of the '}' closing the '{' at open, or -1.
+func matchBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// pluralBlock renders the inside of one plural block.
+func pluralBlock(locale, body string, args []any) (string, bool) {
+	parts := strings.SplitN(body, ",", 3)
+	if len(parts) != 3 || strings.TrimSpace(parts[1]) != "plural" {
+		return "", false
+	}
+	n, ok := pluralArg(strings.TrimSpace(parts[0]), args)
+	if !ok {
+		return "", false
+	}
+	branches := map[string]string{}
+	rest := parts[2]
+	for {
+		open := strings.Index(rest, "{")
+		if open < 0 {
+			break
+		}
+		end := matchBrace(rest, open)
+		if end < 0 {
+			return "", false
+		}
+		branches[strings.TrimSpace(rest[:open])] = rest[open+1 : end]
+		rest = rest[end+1:]
+	}
+	text, ok := branches["="+strconv.FormatFloat(n, 'f', -1, 64)]
+	if !ok {
+		text, ok = branches[pluralCategory(locale, n)]
+	}
+	if !ok {
+		text, ok = branches["other"]
+	}
+	if !ok {
+		return "", false
+	}
+	text = expandPlurals(locale, text, args)
+	return strings.ReplaceAll(text, "#", strconv.FormatFloat(n, 'f', -1, 64)), true
+}
+
+// pluralArg returns the number a plural block selects on.
+func pluralArg(name string, args []any) (float64, bool) {
+	if i, err := strconv.Atoi(name); err == nil {
+		if i < 0 || i >= len(args) {
+			return 0, false
+		}
+		return toFloat(args[i])
+	}
+	for _, a := range args {
+		if n, ok := toFloat(a); ok {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// pluralCategory returns the CLDR plural category of n for the language of
+// locale. Languages not listed use the English rule; fractions are "other"
+// except where a language says otherwise.
+func pluralCategory(locale string, n float64) string {
+	i := int64(n)
+	integer := float64(i) == n
+	switch baseLocale(locale) {
+	case "ja", "zh", "ko", "vi", "th", "id", "ms", "tr":
+		return "other"
+	case "fr", "pt":
+		if n >= 0 && n < 2 {
+			return "one"
+		}
+		return "other"
+	case "cs", "sk":
+		switch {
+		case !integer:
+			return "many"
+		case i == 1:
+			return "one"
+		case i >= 2 && i <= 4:
+			return "few"
+		}
+		return "other"
+	case "pl":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i == 1:
+			return "one"
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return "few"
+		}
+		return "many"
+	case "ru", "uk", "be":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i%10 == 1 && i%100 != 11:
+			return "one"
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return "few"
+		}
+		return "many"
+	case "ar":
+		switch {
+		case !integer:
+			return "other"
+		case i == 0:
+			return "zero"
+		case i == 1:
+			return "one"
+		case i == 2:
+			return "two"
+		case i%100 >= 3 && i%100 <= 10:
+			return "few"
+		case i%100 >= 11:
+			return "many"
+		}
+		return "other"
+	}
+	if integer && i == 1 {
+		return "one"
+	}
+	return "other"
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTranslateUsesAcceptLanguageAndPlurals(t *testing.T) {
+	app := NewApp()
+	app.Translations("en", map[string]string{
+		"hello": "Hello, %s!",
+		"files": "{n, plural, =0{no files} one{# file} other{# files}} in %[2]s",
+	})
+	app.Translations("sk", map[string]string{
+		"files": "{n, plural, one{# súbor} few{# súbory} other{# súborov}} v %[2]s",
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "de;q=0.9, sk-SK, en;q=0.5")
+	ctx := &Context{app: app, Request: req}
+	cases := map[int]string{1: "1 súbor v koši", 3: "3 súbory v koši", 5: "5 súborov v koši"}
+	for n, want := range cases {
+		if got := ctx.Translate("files", n, "koši"); got != want {
+			t.Errorf("Translate(files, %d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := ctx.Translate("hello", "Ada"); got != "Hello, Ada!" {
+		t.Errorf("fallback to default locale = %q", got)
+	}
+	if got := ctx.Translate("missing.key"); got != "missing.key" {
+		t.Errorf("missing key = %q", got)
+	}
+
+	req.Header.Set("Accept-Language", "fr")
+	if got := ctx.Translate("files", 0, "bin"); got != "no files in bin" {
+		t.Errorf("default locale plural = %q", got)
+	}
+}
+
+func TestPluralCategories(t *testing.T) {
+	cases := []struct {
+		locale string
+		n      float64
+		want   string
+	}{
+		{"en", 1, "one"}, {"en", 2, "other"}, {"en", 1.5, "other"},
+		{"fr", 0, "one"}, {"pl", 22, "few"}, {"pl", 12, "many"},
+		{"ru", 21, "one"}, {"ru", 11, "many"}, {"ja", 1, "other"},
+	}
+	for _, c := range cases {
+		if got := pluralCategory(c.locale, c.n); got != c.want {
+			t.Errorf("pluralCategory(%s, %v) = %s, want %s", c.locale, c.n, got, c.want)
+		}
+	}
+}
+
+func TestLocalePreferenceOrder(t *testing.T) {
+	app := NewApp()
+	app.Translations("en", map[string]string{"hi": "Hi"})
+	app.Translations("sk", map[string]string{"hi": "Ahoj"})
+	app.Translations("de", map[string]string{"hi": "Hallo"})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "de-AT,de;q=0.8")
+	ctx := &Context{app: app, Request: req}
+	if got := ctx.Locale(); got != "de" {
+		t.Fatalf("Accept-Language: Locale() = %q", got)
+	}
+	req.AddCookie(&http.Cookie{Name: localeCookie, Value: "sk"})
+	if got := ctx.Translate("hi"); got != "Ahoj" {
+		t.Fatalf("cookie: Translate = %q", got)
+	}
+	ctx.SetLocale("EN")
+	ctx.SetLocale("<script>")
+	if got := ctx.Locale(); got != "en" {
+		t.Fatalf("session preference: Locale() = %q", got)
+	}
+}
+
+func TestPageLangFollowsLocaleSwitch(t *testing.T) {
+	app := NewApp()
+	app.Translations("sk", map[string]string{"hi": "Ahoj"})
+	app.Page("/", func(ctx *Context) *Node {
+		return LanguageSwitcher(ctx.Locale(), "en:English", "sk:Slovenčina")
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "sk")
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, req)
+	body := rr.Body.String()
+	if !strings.Contains(body, `<html lang="sk"`) {
+		t.Fatal("page should declare the request locale")
+	}
+	if !strings.Contains(body, "__ws.call('__locale'") {
+		t.Fatal("switcher should call the built-in __locale action")
+	}
+}
@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// JSON replies: actions that answer with data instead of DOM updates
+// ---------------------------------------------------------------------------
+
+// jsonReply is the data an action answered with via Context.JSON.
+type jsonReply struct {
+	status int
+	body   json.RawMessage
+}
+
+// JSON makes the action answer with v, marshaled as JSON, instead of
+// JavaScript; return its result from the handler:
+//
+//	app.Action("stats", func(ctx *ui.Context) string {
+//		return ctx.JSON(http.StatusOK, loadStats())
+//	})
+//
+// Over the WebSocket nothing is run or swapped on the page: the reply goes
+// to the caller, see Action.JSON. Served over HTTP with ServeAction, status
+// and the application/json content type are the response's. A value that
+// cannot be marshaled is logged and answered with status 500.
+func (ctx *Context) JSON(status int, v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		ctx.log().Errorf("marshal JSON reply: %v", err)
+		status, b = http.StatusInternalServerError, []byte(`{"error":"Server error"}`)
+	}
+	ctx.json = &jsonReply{status: status, body: b}
+	return string(b)
+}
+
+// JSON makes the call hand its reply to the page instead of running it.
+// When the handler answers with Context.JSON, the triggering element
+// dispatches a bubbling "gsui:json" event whose detail holds status and
+// data; a handler that answers with JavaScript is run as usual.
+//
+//	ui.Button().ID("refresh").Text("Refresh").OnClick(ctx.Action("stats", stats).JSON())
+//	ctx.HeadJS(`document.addEventListener('gsui:json', function (e) {
+//		if (e.target.id === 'refresh') chart.update(e.detail.data)
+//	})`)
+//
+// From your own scripts, __ws.call(name, data, null, {json: fn}) calls fn
+// with the same detail. Client-side actions (JS) ignore it.
+func (a *Action) JSON() *Action {
+	a.json = true
+	return a
+}
+
+// wsJSONReply is the reply to a tracked call answered with Context.JSON.
+func wsJSONReply(id int64, r *jsonReply) string {
+	b, err := json.Marshal(struct {
+		Reply  int64           `json:"__r"`
+		ID     int64           `json:"id"`
+		JSON   json.RawMessage `json:"json"`
+		Status int             `json:"status"`
+	}{Reply: 1, ID: id, JSON: r.body, Status: r.status})
+	if err != nil {
+		defaultLogger.Errorf("marshal WebSocket reply: %v", err)
+		return `{"__r":1,"id":0,"js":""}`
+	}
+	return string(b)
+}
+
+// ServeAction returns an HTTP handler that runs the named action, so
+// clients without the WebSocket (scripts, mobile apps, other services)
+// can use it too. The request body, a JSON object, is the action's data
+// (see Context.Body); path and query parameters are available as on a
+// page. Register it like any other route:
+//
+//	app.POST("/api/stats", app.ServeAction("stats"))
+//
+// A handler that answers with Context.JSON sets the status and body. One
+// that answers with JavaScript gets a 200 response with that script, as
+// text/javascript. An unknown action gives 404 and a panic gives 500, both
+// with a JSON error body. The request passes through the app's CSRF check,
+// rate limit and body limit like any other unsafe route.
+//
+// Only POST is served; other methods get 405. That, and the nosniff header
+// on every response, keep another site from loading the reply with a
+// <script src=...> tag and reading what it holds.
+func (app *App) ServeAction(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		app.mu.RLock()
+		handler, ok := app.actions[name]
+		app.mu.RUnlock()
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "Unknown action")
+			return
+		}
+
+		data := make(map[string]any)
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil && !errors.Is(err, io.EOF) {
+			writeJSONError(w, http.StatusBadRequest, "Request body must be a JSON object")
+			return
+		}
+		ctx := &Context{
+			Request:    r,
+			PathParams: requestPathParams(r),
+			Query:      make(map[string]string),
+			wsData:     data,
+			app:        app,
+			sessionID:  requestSessionID(r),
+			requestID:  requestIDOf(r),
+		}
+		for k, v := range r.URL.Query() {
+			if len(v) > 0 {
+				ctx.Query[k] = v[0]
+			}
+		}
+		defer app.bindCtx(ctx, r.Context())()
+		app.loadSession(ctx)
+
+		start := time.Now()
+		outcome := "ok"
+		js := func() (resp string) {
+			defer func() {
+				if rec := recover(); rec != nil {
+					ctx.log().Errorf("panic in action %q: %v\n%s", name, rec, debug.Stack())
+					outcome = "panic"
+				}
+			}()
+			return handler(ctx)
+		}()
+		app.logAction(ctx, name, outcome, start)
+		app.saveSession(ctx)
+		if outcome == "panic" {
+			writeJSONError(w, http.StatusInternalServerError, "Server error")
+			return
+		}
+
+		if ctx.json != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(ctx.json.status)
+			w.Write(ctx.json.body)
+			return
+		}
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		io.WriteString(w, js)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
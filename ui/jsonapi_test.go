@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func statsAction(ctx *Context) string {
+	var req struct{ Days int }
+	ctx.Body(&req)
+	if req.Days < 0 {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "days must not be negative"})
+	}
+	return ctx.JSON(http.StatusOK, map[string]int{"visits": 10 * req.Days})
+}
+
+func TestJSONReplyGoesToCallerOverWebSocket(t *testing.T) {
+	app := NewApp()
+	app.Action("stats", statsAction)
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	ws := dialSession(t, server, newSessionID())
+	defer ws.Close()
+	if err := ws.send(`{"act":"stats","data":{"Days":3},"id":7}`); err != nil {
+		t.Fatal(err)
+	}
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+	var reply struct {
+		ID     int64          `json:"id"`
+		JSON   map[string]int `json:"json"`
+		Status int            `json:"status"`
+		JS     *string        `json:"js"`
+	}
+	if err := json.Unmarshal([]byte(raw), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != 7 || reply.Status != 200 || reply.JSON["visits"] != 30 || reply.JS != nil {
+		t.Fatalf("reply = %s", raw)
+	}
+
+	js := Button().OnClick((&Action{Name: "stats"}).JSON()).ToJS()
+	expect(t, js, "__ws.call('stats',null,null,{json:event.currentTarget})")
+	expect(t, wsClientJS, "new CustomEvent('gsui:json'")
+}
+
+func TestServeActionAnswersOverHTTP(t *testing.T) {
+	app := NewApp()
+	app.Action("stats", statsAction)
+	app.Action("hello", func(ctx *Context) string { return Notify("info", "hi") })
+	app.POST("/api/stats", app.ServeAction("stats"))
+	app.POST("/api/hello", app.ServeAction("hello"))
+	app.POST("/api/missing", app.ServeAction("missing"))
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	post := func(path, body string) (int, string, string) {
+		t.Helper()
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(b)
+	}
+
+	code, ctype, body := post("/api/stats", `{"Days":2}`)
+	if code != 200 || ctype != "application/json" || body != `{"visits":20}` {
+		t.Fatalf("stats: %d %s %s", code, ctype, body)
+	}
+	if code, _, body = post("/api/stats", `{"Days":-1}`); code != 400 || !strings.Contains(body, "negative") {
+		t.Fatalf("stats with bad input: %d %s", code, body)
+	}
+	if code, _, _ = post("/api/stats", `[1,2]`); code != 400 {
+		t.Fatalf("non-object body: %d", code)
+	}
+	if code, ctype, body = post("/api/hello", ``); code != 200 || !strings.HasPrefix(ctype, "text/javascript") || !strings.Contains(body, "hi") {
+		t.Fatalf("JS action: %d %s %s", code, ctype, body)
+	}
+	if code, _, _ = post("/api/missing", `{}`); code != 404 {
+		t.Fatalf("unknown action: %d", code)
+	}
+}
+
+func TestServeActionRejectsScriptInclusion(t *testing.T) {
+	app := NewApp()
+	store := NewMemoryStore()
+	app.SessionStore(store)
+	app.Action("hello", func(ctx *Context) string { return Notify("info", "hi") })
+	app.Action("boom", func(ctx *Context) string {
+		ctx.Session["seen"] = "yes"
+		panic("boom")
+	})
+	app.GET("/api/hello", app.ServeAction("hello"))
+	app.POST("/api/boom", app.ServeAction("boom"))
+
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/api/hello", nil))
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "POST" {
+		t.Fatalf("GET: %d Allow=%q %s", rr.Code, rr.Header().Get("Allow"), rr.Body)
+	}
+	if rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatal("ServeAction must send nosniff")
+	}
+
+	sid := newSessionID()
+	req := httptest.NewRequest("POST", "/api/boom", strings.NewReader(`{}`))
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: sid})
+	rr = httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusInternalServerError || rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("panic: %d %v", rr.Code, rr.Header())
+	}
+	if data, _ := store.Get(sid); data["seen"] != "yes" {
+		t.Fatalf("session not saved after panic: %v", data)
+	}
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+// ---------------------------------------------------------------------------
+// Logging: where the library's own messages go
+// ---------------------------------------------------------------------------
+
+// Logger receives the messages the library writes: access log lines,
+// panics recovered from handlers, store and connection errors. Implement
+// it over zap, zerolog or slog to give them levels and fields:
+//
+//	type zapLogger struct{ *zap.SugaredLogger }
+//
+//	func (l zapLogger) Debugf(f string, a ...any) { l.SugaredLogger.Debugf(f, a...) }
+//	// ... Infof, Warnf, Errorf
+//
+//	app.Logger(zapLogger{z.Sugar()})
+//
+// Messages come without the "gsui: " prefix the default adds.
+type Logger interface {
+	// Debugf reports routine connection events: clients going away,
+	// socket deadlines.
+	Debugf(format string, args ...any)
+	// Infof reports access log lines and server start.
+	Infof(format string, args ...any)
+	// Warnf reports input the library ignored or dropped: malformed
+	// messages, unsafe theme values, full push buffers, failed sends.
+	Warnf(format string, args ...any)
+	// Errorf reports failures: panics in handlers, session store errors,
+	// values that could not be encoded.
+	Errorf(format string, args ...any)
+}
+
+// FieldLogger is a Logger that can attach key/value pairs to messages.
+// Messages raised while serving a page or action then go through
+// With("session", ..., "request_id", ...), with a short hash of the
+// session ID (as in the access log) and Context.RequestID, so a
+// structured logger records them as fields.
+type FieldLogger interface {
+	Logger
+	With(args ...any) Logger
+}
+
+// stdLogger writes every level to the standard log package, prefixed with
+// "gsui: ".
+type stdLogger struct{}
+
+func (stdLogger) Debugf(format string, args ...any) { log.Printf("gsui: "+format, args...) }
+func (stdLogger) Infof(format string, args ...any)  { log.Printf("gsui: "+format, args...) }
+func (stdLogger) Warnf(format string, args ...any)  { log.Printf("gsui: "+format, args...) }
+func (stdLogger) Errorf(format string, args ...any) { log.Printf("gsui: "+format, args...) }
+
+// defaultLogger takes messages not tied to an app, such as values that
+// could not be encoded while building nodes, and those of apps without a
+// Logger.
+var defaultLogger Logger = stdLogger{}
+
+// Logger routes the app's messages to l instead of the standard log
+// package; nil restores the default. Messages raised while building nodes,
+// outside any app, still go to the standard log package.
+func (app *App) Logger(l Logger) {
+	app.mu.Lock()
+	app.logger = l
+	app.mu.Unlock()
+}
+
+// log returns the app's Logger.
+func (app *App) log() Logger {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	if app.logger == nil {
+		return defaultLogger
+	}
+	return app.logger
+}
+
+// log returns the Logger of ctx's app, or the default outside an app,
+// with the session and request ID as fields when it is a FieldLogger.
+func (ctx *Context) log() Logger {
+	if ctx.app == nil {
+		return defaultLogger
+	}
+	lg := ctx.app.log()
+	fl, ok := lg.(FieldLogger)
+	if !ok {
+		return lg
+	}
+	var fields []any
+	if ctx.sessionID != "" {
+		fields = append(fields, "session", sessionTag(ctx.sessionID))
+	}
+	if ctx.requestID != "" {
+		fields = append(fields, "request_id", ctx.requestID)
+	}
+	if fields == nil {
+		return lg
+	}
+	return fl.With(fields...)
+}
+
+// UseSlog routes the app's messages to l as structured records, at the
+// levels described on Logger. Records from page and action handlers carry
+// a "session" attribute with a short hash of the session ID and a
+// "request_id" attribute:
+//
+//	app.UseSlog(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+//	// {"level":"ERROR","msg":"panic in action \"save\": ...","session":"3f9a0c1e","request_id":"9b2e41d07c5a8f36"}
+func (app *App) UseSlog(l *slog.Logger) {
+	app.Logger(slogLogger{l})
+}
+
+// slogLogger adapts a *slog.Logger to FieldLogger.
+type slogLogger struct{ l *slog.Logger }
+
+func (s slogLogger) Debugf(format string, args ...any) { s.log(slog.LevelDebug, format, args) }
+func (s slogLogger) Infof(format string, args ...any)  { s.log(slog.LevelInfo, format, args) }
+func (s slogLogger) Warnf(format string, args ...any)  { s.log(slog.LevelWarn, format, args) }
+func (s slogLogger) Errorf(format string, args ...any) { s.log(slog.LevelError, format, args) }
+
+func (s slogLogger) With(args ...any) Logger { return slogLogger{s.l.With(args...)} }
+
+func (s slogLogger) log(level slog.Level, format string, args []any) {
+	ctx := context.Background()
+	if s.l.Enabled(ctx, level) {
+		s.l.Log(ctx, level, fmt.Sprintf(format, args...))
+	}
+}
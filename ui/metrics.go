@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Metrics: request, action and live-update counters in Prometheus format
+// ---------------------------------------------------------------------------
+
+// metricBuckets are the upper bounds, in seconds, of the duration
+// histograms; Prometheus client defaults.
+var metricBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metrics holds the counters behind App.Metrics.
+type metrics struct {
+	mu         sync.Mutex
+	requests   map[string]uint64 // by `method="...",code="..."` labels
+	reqTime    histogram
+	actions    map[string]uint64 // by outcome
+	actTime    histogram
+	wsSent     uint64
+	wsFailed   uint64
+	sseSent    uint64
+	sseDropped uint64
+}
+
+// histogram is a cumulative-on-output Prometheus histogram.
+type histogram struct {
+	counts [12]uint64 // per bucket of metricBuckets, then +Inf
+	sum    float64
+	n      uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	s := d.Seconds()
+	i, _ := slices.BinarySearch(metricBuckets, s)
+	h.counts[i]++
+	h.sum += s
+	h.n++
+}
+
+// Metrics serves counters and gauges of the app at path, in the Prometheus
+// text format, so any Prometheus-compatible scraper can collect them
+// without client libraries:
+//
+//	app.Metrics("/metrics")
+//
+// Exposed are HTTP requests by method and status, their duration, action
+// calls by outcome and their duration, open WebSocket connections, SSE
+// streams and sessions with an open connection, and messages sent to
+// clients over each transport. WebSocket and SSE connections are counted
+// as requests but kept out of the duration histogram. The endpoint is
+// public like any route: serve it on an internal address or guard it in
+// middleware when the numbers are sensitive.
+func (app *App) Metrics(path string) {
+	app.mu.Lock()
+	if app.metrics == nil {
+		app.metrics = &metrics{requests: make(map[string]uint64), actions: make(map[string]uint64)}
+	}
+	app.mu.Unlock()
+	app.mux.HandleFunc("GET "+muxPattern(path), app.serveMetrics)
+}
+
+// collecting returns the app's metrics, nil when Metrics was not called.
+func (app *App) collecting() *metrics {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	return app.metrics
+}
+
+// measureRequests wraps next so that every request is counted once it
+// finishes.
+func (app *App) measureRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := app.collecting()
+		if m == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		stream := sw.status == http.StatusSwitchingProtocols ||
+			strings.HasPrefix(sw.Header().Get("Content-Type"), "text/event-stream")
+		labels := fmt.Sprintf(`method=%q,code="%d"`, metricMethod(r.Method), sw.status)
+		m.mu.Lock()
+		m.requests[labels]++
+		if !stream {
+			m.reqTime.observe(time.Since(start))
+		}
+		m.mu.Unlock()
+	})
+}
+
+// metricMethod keeps the method label to the standard methods, so clients
+// cannot grow the label set.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
+
+func (m *metrics) action(outcome string, d time.Duration) {
+	m.mu.Lock()
+	m.actions[outcome]++
+	m.actTime.observe(d)
+	m.mu.Unlock()
+}
+
+// wsSend records one WebSocket message; failed when it could not be sent.
+func (m *metrics) wsSend(failed bool) {
+	m.mu.Lock()
+	if failed {
+		m.wsFailed++
+	} else {
+		m.wsSent++
+	}
+	m.mu.Unlock()
+}
+
+// sseSend records SSE messages queued and dropped on full buffers.
+func (m *metrics) sseSend(sent, dropped int) {
+	m.mu.Lock()
+	m.sseSent += uint64(sent)
+	m.sseDropped += uint64(dropped)
+	m.mu.Unlock()
+}
+
+func (app *App) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	m := app.collecting()
+	app.mu.RLock()
+	ws, sse, sessions := len(app.clients), len(app.sseClients), len(app.sessions)
+	app.mu.RUnlock()
+
+	var b strings.Builder
+	m.mu.Lock()
+	writeCounterVec(&b, "gsui_http_requests_total", "HTTP requests served, by method and status code.", m.requests)
+	writeHistogram(&b, "gsui_http_request_duration_seconds", "Time to serve HTTP requests, WebSocket and SSE streams excluded.", &m.reqTime)
+	outcomes := make(map[string]uint64, len(m.actions))
+	for outcome, n := range m.actions {
+		outcomes[fmt.Sprintf("outcome=%q", outcome)] = n
+	}
+	writeCounterVec(&b, "gsui_actions_total", "Action calls, by outcome.", outcomes)
+	writeHistogram(&b, "gsui_action_duration_seconds", "Time spent in action handlers.", &m.actTime)
+	writeMetric(&b, "gsui_ws_messages_sent_total", "counter", "Messages sent over WebSocket connections.", m.wsSent)
+	writeMetric(&b, "gsui_ws_send_errors_total", "counter", "WebSocket messages that could not be sent.", m.wsFailed)
+	writeMetric(&b, "gsui_sse_messages_sent_total", "counter", "Messages queued on SSE streams.", m.sseSent)
+	writeMetric(&b, "gsui_sse_messages_dropped_total", "counter", "SSE messages dropped because a stream was full.", m.sseDropped)
+	m.mu.Unlock()
+	writeMetric(&b, "gsui_ws_connections", "gauge", "Open WebSocket connections.", uint64(ws))
+	writeMetric(&b, "gsui_sse_streams", "gauge", "Open SSE streams.", uint64(sse))
+	writeMetric(&b, "gsui_sessions_connected", "gauge", "Sessions with an open WebSocket connection.", uint64(sessions))
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	io.WriteString(w, b.String())
+}
+
+func writeMetric(b *strings.Builder, name, kind, help string, v uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, v)
+}
+
+// writeCounterVec writes one counter series per label set, sorted.
+func writeCounterVec(b *strings.Builder, name, help string, series map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	labels := make([]string, 0, len(series))
+	for l := range series {
+		labels = append(labels, l)
+	}
+	slices.Sort(labels)
+	for _, l := range labels {
+		fmt.Fprintf(b, "%s{%s} %d\n", name, l, series[l])
+	}
+}
+
+func writeHistogram(b *strings.Builder, name, help string, h *histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	var cum uint64
+	for i, le := range metricBuckets {
+		cum += h.counts[i]
+		fmt.Fprintf(b, "%s_bucket{le=\"%s\"} %d\n", name, strconv.FormatFloat(le, 'g', -1, 64), cum)
+	}
+	fmt.Fprintf(b, "%s_bucket{le=\"+Inf\"} %d\n", name, h.n)
+	fmt.Fprintf(b, "%s_sum %s\n%s_count %d\n", name, strconv.FormatFloat(h.sum, 'g', -1, 64), name, h.n)
+}
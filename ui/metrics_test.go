@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsCountRequestsActionsAndConnections(t *testing.T) {
+	app := NewApp()
+	app.Metrics("/metrics")
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("boom", func(ctx *Context) string { panic("boom") })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	for range 2 {
+		resp, err := http.Get(server.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	ws := dialSession(t, server, newSessionID()) // calls ping
+	defer ws.Close()
+	if err := ws.send(`{"act":"boom","id":2}`); err != nil {
+		t.Fatal(err)
+	}
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	out := string(b)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+	expect(t, out, `gsui_http_requests_total{method="GET",code="200"} 2`)
+	expect(t, out, "# TYPE gsui_http_request_duration_seconds histogram")
+	expect(t, out, `gsui_http_request_duration_seconds_bucket{le="+Inf"} 2`)
+	expect(t, out, "gsui_http_request_duration_seconds_count 2")
+	expect(t, out, `gsui_actions_total{outcome="ok"} 1`)
+	expect(t, out, `gsui_actions_total{outcome="panic"} 1`)
+	expect(t, out, "gsui_action_duration_seconds_count 2")
+	expect(t, out, "gsui_ws_messages_sent_total 2")
+	expect(t, out, "gsui_ws_connections 1")
+	expect(t, out, "gsui_sessions_connected 1")
+}
@@ -2,11 +2,10 @@ package ui
 
 import (
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
-
-	"golang.org/x/net/websocket"
 )
 
 func TestPageSupportsServeMuxPathValues(t *testing.T) {
@@ -87,22 +86,18 @@ func TestWebSocketNavigationSupportsPagePathValues(t *testing.T) {
 	server := httptest.NewServer(app.Handler())
 	defer server.Close()
 
-	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+"/__ws", server.URL)
-	if err != nil {
-		t.Fatalf("create WebSocket config: %v", err)
-	}
-	ws, err := websocket.DialConfig(config)
+	ws, err := dialWS(server, "/__ws", nil)
 	if err != nil {
 		t.Fatalf("connect WebSocket: %v", err)
 	}
 	defer ws.Close()
 
 	message := `{"act":"__nav","data":{"url":"/dp/ws-token"},"id":1}`
-	if err := websocket.Message.Send(ws, message); err != nil {
+	if err := ws.send(message); err != nil {
 		t.Fatalf("send navigation message: %v", err)
 	}
 	var raw string
-	if err := websocket.Message.Receive(ws, &raw); err != nil {
+	if err := ws.receive(&raw); err != nil {
 		t.Fatalf("receive navigation response: %v", err)
 	}
 	var reply struct {
@@ -121,3 +116,72 @@ func TestWebSocketNavigationSupportsPagePathValues(t *testing.T) {
 		t.Fatalf("navigation response does not contain both path values: %s", reply.JS)
 	}
 }
+
+func TestPageColonParamsAndStaticPrecedence(t *testing.T) {
+	// Matching depends on the patterns alone, never on registration order.
+	for _, staticFirst := range []bool{false, true} {
+		app := NewApp()
+		byID := func() {
+			app.Page("/users/:id", func(ctx *Context) *Node { return Div().Text("user:" + ctx.Param("id")) })
+		}
+		newUser := func() { app.Page("/users/new", func(ctx *Context) *Node { return Div().Text("new-user-form") }) }
+		if staticFirst {
+			newUser()
+			byID()
+		} else {
+			byID()
+			newUser()
+		}
+		app.GET("/api/users/:id", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("api:" + r.PathValue("id"))) })
+
+		get := func(path string) string {
+			rr := httptest.NewRecorder()
+			app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "http://example.test"+path, nil))
+			return rr.Body.String()
+		}
+		expect(t, get("/users/42"), "user:42")
+		expect(t, get("/users/new"), "new-user-form")
+		notExpect(t, get("/users/new"), "user:new")
+		if got := get("/api/users/7"); got != "api:7" {
+			t.Fatalf("GET /api/users/7 = %q", got)
+		}
+	}
+}
+
+func TestMuxPatternRewritesColonSegments(t *testing.T) {
+	cases := map[string]string{
+		"/users/:id":            "/users/{id}",
+		"/a/:x/b/:y":            "/a/{x}/b/{y}",
+		"/plain/":               "/plain/",
+		"/t/{token}":            "/t/{token}",
+		"/time/12:30":           "/time/12:30",
+		"/files/:name/:rest...": "/files/{name}/{rest...}",
+	}
+	for in, want := range cases {
+		if got := muxPattern(in); got != want {
+			t.Errorf("muxPattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPageReRegistrationReplacesSameHandler(t *testing.T) {
+	app := NewApp()
+	setup := func(greeting string) {
+		app.Page("/hello", func(ctx *Context) *Node { return Div().Text(greeting) })
+	}
+	setup("first")
+	setup("second")
+	app.Group("/admin").Page("/", func(ctx *Context) *Node { return Div().Text("admin") })
+
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "http://example.test/hello", nil))
+	expect(t, rr.Body.String(), "second")
+	notExpect(t, rr.Body.String(), "first")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a different handler for a taken pattern did not panic")
+		}
+	}()
+	app.Page("/hello", func(ctx *Context) *Node { return Div() })
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Prefetch: fetch link targets on hover, ahead of the click
+// ---------------------------------------------------------------------------
+
+// PrefetchOnHover makes pages fetch the target of a same-origin link while
+// the pointer rests on it or a finger touches it, so the click finds the
+// page in the browser's HTTP cache. Off by default.
+//
+// Links that open elsewhere (target other than _self), downloads, links
+// to the current page and links marked data-no-prefetch are skipped, as is
+// every link when the browser asks to save data or the connection is 2G.
+// Links always load with GET, and the server cannot tell a hover from an
+// intent to visit, so a hover runs the GET handler behind the link: a
+// page, or a route such as GET /logout that signs the user out. Keep GET
+// handlers free of side effects, or mark their links data-no-prefetch.
+//
+// A prefetched page render, told apart by the browser's Sec-Purpose or
+// Purpose header, does not consume flash messages, save the session or
+// issue a session cookie. When the visitor has no session yet or a flash
+// message is waiting, the response is marked no-store, so the click
+// renders the page again.
+func (app *App) PrefetchOnHover(enabled bool) {
+	app.mu.Lock()
+	app.prefetch = enabled
+	app.mu.Unlock()
+}
+
+// isPrefetch reports whether r is a speculative fetch rather than a visit.
+func isPrefetch(r *http.Request) bool {
+	for _, h := range []string{"Sec-Purpose", "Purpose", "X-Moz"} {
+		if strings.Contains(strings.ToLower(r.Header.Get(h)), "prefetch") {
+			return true
+		}
+	}
+	return false
+}
+
+// prefetchConfigJS returns the prefetch client for the page shell, "" when
+// PrefetchOnHover is off.
+func (app *App) prefetchConfigJS() string {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	if !app.prefetch {
+		return ""
+	}
+	return prefetchJS
+}
+
+// prefetchJS adds a <link rel=prefetch> for the hovered link after a short
+// delay, so links the pointer only crosses are not fetched, and removes it
+// once loaded. A URL is fetched at most once a minute.
+const prefetchJS = `(function(){
+if(window.__gsuiPrefetchInit)return;window.__gsuiPrefetchInit=true;
+var seen={},timer=0;
+function target(e){
+ var a=e.target&&e.target.closest&&e.target.closest('a[href]');
+ if(!a||(a.target&&a.target!=='_self')||a.hasAttribute('download')||a.hasAttribute('data-no-prefetch'))return null;
+ var c=navigator.connection;if(c&&(c.saveData||/2g/.test(c.effectiveType||'')))return null;
+ var u;try{u=new URL(a.href,location.href)}catch(_){return null}
+ if(u.origin!==location.origin)return null;
+ u.hash='';if(u.href===location.href.split('#')[0])return null;
+ return u.href;
+}
+function prefetch(href){
+ if(seen[href]>Date.now()-60000)return;seen[href]=Date.now();
+ var l=document.createElement('link');l.rel='prefetch';l.as='document';l.href=href;
+ l.onload=l.onerror=function(){l.remove()};
+ document.head.appendChild(l);
+}
+document.addEventListener('mouseover',function(e){var h=target(e);clearTimeout(timer);if(h)timer=setTimeout(function(){prefetch(h)},65)},{passive:true});
+document.addEventListener('mouseout',function(){clearTimeout(timer)},{passive:true});
+document.addEventListener('touchstart',function(e){var h=target(e);if(h)prefetch(h)},{passive:true});
+})();`
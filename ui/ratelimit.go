@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Rate limiting: token bucket per key
+// ---------------------------------------------------------------------------
+
+// rateLimiter refills each bucket to max tokens over per; a call spends one.
+type rateLimiter struct {
+	max   float64
+	per   time.Duration
+	key   func(*Context) string
+	mu    sync.Mutex
+	slots map[string]*bucket
+	swept time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimit throttles action calls and non-GET HTTP routes to max calls per
+// interval for each key, with bursts of up to max. keyFn picks the key; nil
+// keys by client IP. Page loads, GET routes and the built-in __ actions are
+// never limited. A throttled action gets an error toast instead of running;
+// a throttled HTTP request gets 429 with Retry-After. Pass max <= 0 to
+// remove the limit.
+//
+//	app.RateLimit(20, time.Second, func(ctx *ui.Context) string { return ctx.SessionID() })
+func (app *App) RateLimit(max int, per time.Duration, keyFn func(*Context) string) {
+	var l *rateLimiter
+	if max > 0 && per > 0 {
+		if keyFn == nil {
+			keyFn = (*Context).IP
+		}
+		l = &rateLimiter{max: float64(max), per: per, key: keyFn, slots: make(map[string]*bucket)}
+	}
+	app.mu.Lock()
+	app.limiter = l
+	app.mu.Unlock()
+}
+
+// allow spends a token from the bucket of key and reports whether one was
+// available, plus the wait until the next token when it was not.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.swept) > l.per {
+		l.sweep(now)
+	}
+	b := l.slots[key]
+	if b == nil {
+		b = &bucket{tokens: l.max, last: now}
+		l.slots[key] = b
+	}
+	rate := l.max / float64(l.per)
+	b.tokens = min(l.max, b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate)
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep forgets buckets that have refilled completely; they behave exactly
+// like new ones.
+func (l *rateLimiter) sweep(now time.Time) {
+	l.swept = now
+	for key, b := range l.slots {
+		if now.Sub(b.last) >= l.per {
+			delete(l.slots, key)
+		}
+	}
+}
+
+// limited reports whether ctx has exhausted its budget.
+func (app *App) limited(ctx *Context) (bool, time.Duration) {
+	app.mu.RLock()
+	l := app.limiter
+	app.mu.RUnlock()
+	if l == nil {
+		return false, 0
+	}
+	ok, wait := l.allow(l.key(ctx), time.Now())
+	return !ok, wait
+}
+
+func (app *App) isLimited(ctx *Context) bool {
+	limited, _ := app.limited(ctx)
+	return limited
+}
+
+// limitHTTP wraps next with the rate limit for state-changing requests.
+func (app *App) limitHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			ctx := &Context{Request: r, app: app, sessionID: requestSessionID(r)}
+			if limited, wait := app.limited(ctx); limited {
+				secs := int(wait/time.Second) + 1
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IP returns the client's address without the port, taken from the
+// connection. Behind a reverse proxy this is the proxy's address; key rate
+// limits on SessionID or a trusted forwarding header instead.
+func (ctx *Context) IP() string {
+	if ctx.Request == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+	if err != nil {
+		return strings.TrimSpace(ctx.Request.RemoteAddr)
+	}
+	return host
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	l := &rateLimiter{max: 2, per: time.Second, slots: make(map[string]*bucket)}
+	now := time.Unix(1000, 0)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("call %d within burst rejected", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait <= 0 || wait > 500*time.Millisecond {
+		t.Fatalf("third call: ok=%v wait=%v", ok, wait)
+	}
+	if ok, _ := l.allow("b", now); !ok {
+		t.Fatal("keys must not share buckets")
+	}
+	if ok, _ := l.allow("a", now.Add(500*time.Millisecond)); !ok {
+		t.Fatal("bucket did not refill")
+	}
+	l.allow("a", now.Add(3*time.Second))
+	if _, ok := l.slots["b"]; ok {
+		t.Fatal("full buckets should be swept")
+	}
+}
+
+func TestRateLimitHTTPAndActions(t *testing.T) {
+	app := NewApp()
+	app.RateLimit(1, time.Minute, nil)
+	app.POST("/save", func(w http.ResponseWriter, r *http.Request) {})
+	app.GET("/read", func(w http.ResponseWriter, r *http.Request) {})
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("work", func(ctx *Context) string { return "done()" })
+	h := app.Handler()
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+	if rr := serve("POST", "/save"); rr.Code != http.StatusOK {
+		t.Fatalf("first POST: %d", rr.Code)
+	}
+	rr := serve("POST", "/save")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("second POST: %d %v", rr.Code, rr.Header())
+	}
+	if rr := serve("GET", "/read"); rr.Code != http.StatusOK {
+		t.Fatalf("GET must not be limited: %d", rr.Code)
+	}
+
+	app.RateLimit(1, time.Minute, func(ctx *Context) string { return ctx.SessionID() })
+	server := httptest.NewServer(h)
+	defer server.Close()
+	ws := dialSession(t, server, newSessionID()) // spends the single token
+	defer ws.Close()
+	ws.send(`{"act":"work","id":2}`)
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(raw, "done()") || !strings.Contains(raw, "Too many requests") {
+		t.Fatalf("limited action reply = %s", raw)
+	}
+	ws.send(`{"act":"__ping"}`)
+	if err := ws.receive(&raw); err != nil || raw != wsPong {
+		t.Fatalf("built-in actions must bypass the limit: %q %v", raw, err)
+	}
+
+	app.RateLimit(0, 0, nil)
+	if rr := serve("POST", "/save"); rr.Code != http.StatusOK {
+		t.Fatalf("RateLimit(0) must remove the limit: %d", rr.Code)
+	}
+}
+
+func TestContextIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "[::1]:5555"
+	if got := (&Context{Request: r}).IP(); got != "::1" {
+		t.Fatalf("IP() = %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// ---------------------------------------------------------------------------
+// Request IDs: correlate a response, its log lines and a user's report
+// ---------------------------------------------------------------------------
+
+// requestIDHeader carries the request ID in both directions.
+const requestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// RequestID returns the ID of the current request: the X-Request-Id
+// header sent by a proxy or client when it is a plain token, otherwise one
+// generated for it. Page loads and HTTP routes echo it in the
+// X-Request-Id response header; each WebSocket action call gets its own.
+// Log lines and the built-in error page show it, so a user's report can
+// be matched with the server log:
+//
+//	app.OnError(func(ctx *ui.Context, err error) *ui.Node {
+//		return ui.P().Text("Something went wrong. Reference: " + ctx.RequestID())
+//	})
+func (ctx *Context) RequestID() string {
+	return ctx.requestID
+}
+
+// assignRequestIDs wraps next so that every request carries an ID in its
+// context and response header.
+func assignRequestIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestIDOf returns the ID assigned to r, "" outside the middleware.
+func requestIDOf(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic("gsui: crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts incoming IDs of up to 128 letters, digits, '-',
+// '_', '.' and ':', so a client cannot inject text into log lines or the
+// error page.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == ':') {
+			return false
+		}
+	}
+	return true
+}
@@ -6,6 +6,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/coder/websocket"
 )
 
 const scriptBreakoutPayload = "</script><script>alert(1)</script>"
@@ -53,18 +55,47 @@ func TestWebSocketOriginHandshake(t *testing.T) {
 		r.Header.Set("Origin", origin)
 		return r
 	}
-	if err := app.wsHandshake(nil, request("https://example.test")); err != nil {
+	if err := app.wsHandshake(request("https://example.test")); err != nil {
 		t.Fatalf("same origin rejected: %v", err)
 	}
-	if err := app.wsHandshake(nil, request("https://other.test")); err == nil {
+	if err := app.wsHandshake(request("https://other.test")); err == nil {
 		t.Fatal("cross origin accepted")
 	}
 	app.AllowedOrigins = []string{"https://other.test"}
-	if err := app.wsHandshake(nil, request("https://other.test")); err != nil {
+	if err := app.wsHandshake(request("https://other.test")); err != nil {
 		t.Fatalf("allowed origin rejected: %v", err)
 	}
 }
 
+func TestWebSocketMessageOverBodyLimitClosesConnection(t *testing.T) {
+	app := NewApp()
+	app.MaxBodySize(256, "/__ws")
+	app.Action("ping", func(ctx *Context) string { return "" })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	ws := dialSession(t, server, newSessionID())
+	defer ws.Close()
+	big := `{"act":"ping","id":2,"data":{"x":"` + strings.Repeat("a", 1000) + `"}}`
+	if err := ws.send(big); err != nil {
+		t.Fatal(err)
+	}
+	var raw string
+	err := ws.receive(&raw)
+	if got := websocket.CloseStatus(err); got != websocket.StatusMessageTooBig {
+		t.Fatalf("close status = %v (%v), want %v", got, err, websocket.StatusMessageTooBig)
+	}
+
+	// Pages learn the limit, so the client fails such calls itself.
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	expect(t, rr.Body.String(), "max:256,tooBig:function(){")
+	expect(t, rr.Body.String(), "Message too large")
+	expect(t, wsClientJS, "if(!fits(msg)){tooBig();fail(id);return}")
+	expect(t, wsClientJS, "var big=e&&e.code===1009;if(big){tooBig();Object.keys(retry).forEach(fail)}")
+}
+
 func TestWebSocketReplyEnvelope(t *testing.T) {
 	var got struct {
 		Reply int64  `json:"__r"`
@@ -163,6 +194,26 @@ func TestAppShellGatesInitialPaintUntilStylesAreReady(t *testing.T) {
 	expect(t, body, `timer=setTimeout(paintReveal,4000)`)
 }
 
+func TestThemeAppliedBeforeAnyHeadResource(t *testing.T) {
+	app := NewApp()
+	app.HTMLHead = append(app.HTMLHead, `<link rel="stylesheet" href="/app.css">`)
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	body := rr.Body.String()
+
+	theme := strings.Index(body, "window.__gsuiThemeInit")
+	bg := strings.Index(body, "html.dark,html.gsui-loading-dark")
+	for _, res := range []string{`<link rel="stylesheet" href="/app.css">`, "<link rel=\"preconnect\"", "<script src="} {
+		if i := strings.Index(body, res); i < 0 || theme < 0 || bg < 0 || theme > i || bg > i {
+			t.Errorf("theme script (%d) and background CSS (%d) must precede %s (%d)", theme, bg, res, i)
+		}
+	}
+	expect(t, body, `<meta name="color-scheme" content="light dark">`)
+	expect(t, body, "try{return localStorage.getItem('theme')||'system'}catch(_){return 'system'}")
+}
+
 func TestMarkdownOmitsRawHTMLAndEscapesScriptBreakout(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -194,3 +245,111 @@ func assertNoRawScriptBreakout(t *testing.T, got, rawPayload string) {
 		t.Fatalf("output contains raw payload %q: %s", rawPayload, truncate(got, 500))
 	}
 }
+
+func TestOnlyHTMLParsesMarkup(t *testing.T) {
+	payload := `"><img src=x onerror=alert(1)>` + scriptBreakoutPayload
+	js := Div().Render(
+		Span().Text(payload),
+		Input().Attr("value", payload).Attr("placeholder", payload).ID(payload).Class(payload),
+	).ToJS()
+	assertNoRawScriptBreakout(t, js, payload)
+	notExpect(t, js, "innerHTML")
+	expect(t, js, ".textContent='\"\\u003e\\u003cimg src\\u003dx")
+	expect(t, js, ".setAttribute('value','\"\\u003e\\u003cimg")
+
+	js = Div().HTML("<b>" + EscapeHTML(payload) + "</b>").ToJS()
+	assertNoRawScriptBreakout(t, js, payload)
+	expect(t, js, ".innerHTML='\\u003cb\\u003e\\u0026#34;\\u0026gt;\\u0026lt;img")
+	if got := EscapeHTML(`<a href="x">'&'</a>`); got != "&lt;a href=&#34;x&#34;&gt;&#39;&amp;&#39;&lt;/a&gt;" {
+		t.Fatalf("EscapeHTML = %q", got)
+	}
+}
+
+func TestAttributeValuesCannotAddAttributes(t *testing.T) {
+	payload := `" onmouseover="alert(1)`
+	form := NewForm("f")
+	form.Text("Name", "name").Value(payload).Placeholder(payload).PatternValidation(payload).Render()
+	form.Submit("save", "Save", "")
+	js := form.Build().ToJS()
+	notExpect(t, js, "setAttribute('onmouseover'")
+	expect(t, js, `.setAttribute('value','" onmouseover\u003d"alert(1)')`)
+	expect(t, js, `.setAttribute('placeholder','" onmouseover\u003d"alert(1)')`)
+	expect(t, js, `.setAttribute('pattern','" onmouseover\u003d"alert(1)')`)
+
+	js = Input().Attr("autocomplete", payload).ToJS()
+	notExpect(t, js, "setAttribute('onmouseover'")
+
+	app := NewApp()
+	app.CSS([]string{`/site.css` + payload}, "")
+	app.Page("/", func(ctx *Context) *Node {
+		ctx.HeadCSS([]string{`/page.css` + payload}, "")
+		return Div()
+	})
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	body := rr.Body.String()
+	notExpect(t, body, `" onmouseover="`)
+	expect(t, body, `href="/site.css&#34; onmouseover=&#34;alert(1)"`)
+	expect(t, body, `href="/page.css&#34; onmouseover=&#34;alert(1)"`)
+
+	ctx := &Context{}
+	ctx.HeadCSS([]string{`/page.css` + payload}, "")
+	expect(t, ctx.cssInjectJS(), `x.getAttribute('href')==='/page.css" onmouseover\u003d"alert(1)'`)
+}
+
+func TestStrictCSPStampsEveryScriptWithRequestNonce(t *testing.T) {
+	app := NewApp()
+	app.HTMLHead = append(app.HTMLHead, `<script src="/app.js"></script>`)
+	var seen string
+	app.Page("/", func(ctx *Context) *Node {
+		seen = ctx.Nonce()
+		ctx.HeadJS("window.x=1")
+		return Div().Text("hi")
+	})
+	get := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		return rr
+	}
+
+	rr := get()
+	if rr.Header().Get("Content-Security-Policy") != "" || seen != "" {
+		t.Fatal("CSP must be off by default")
+	}
+	expect(t, rr.Body.String(), `onload="this.dataset.gsuiLoaded='true'"`)
+
+	app.StrictCSP(true)
+	rr = get()
+	first := seen
+	if first == "" {
+		t.Fatal("ctx.Nonce is empty under StrictCSP")
+	}
+	csp := rr.Header().Get("Content-Security-Policy")
+	expect(t, csp, "script-src 'nonce-"+first+"'")
+	notExpect(t, csp, "script-src 'self' 'unsafe-inline'")
+	body := rr.Body.String()
+	if n, stamped := strings.Count(body, "<script"), strings.Count(body, `<script nonce="`+first+`"`); n != stamped {
+		t.Fatalf("%d of %d script tags carry the nonce:\n%s", stamped, n, body)
+	}
+	expect(t, body, `<script nonce="`+first+`" src="/app.js">`)
+	notExpect(t, body, "onload=")
+	notExpect(t, body, "onerror=")
+
+	get()
+	if seen == first {
+		t.Fatal("nonce must change per request")
+	}
+}
+
+func TestStampNonceOnlyStampsScriptTags(t *testing.T) {
+	in := `<!-- <script> --><meta content="<script>" data-x='<script'>` +
+		`<SCRIPT src="/a.js"></SCRIPT><script>if (a<b) document.write("<script>")</script>` +
+		`<style>p::after{content:"<script"}</style><scripts>`
+	got := stampNonce(in, "N")
+	want := `<!-- <script> --><meta content="<script>" data-x='<script'>` +
+		`<SCRIPT nonce="N" src="/a.js"></SCRIPT><script nonce="N">if (a<b) document.write("<script>")</script>` +
+		`<style>p::after{content:"<script"}</style><scripts>`
+	if got != want {
+		t.Fatalf("stampNonce:\n got %s\nwant %s", got, want)
+	}
+}
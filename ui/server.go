@@ -1,34 +1,55 @@
 package ui
 
 import (
-	"compress/gzip"
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"html"
 	"io"
 	"io/fs"
-	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
-	"golang.org/x/net/websocket"
+	"github.com/coder/websocket"
 )
 
 // connState tracks per-connection cancellation so that server-side Push
 // goroutines stop when the client navigates away or reports a missing
 // target element.
 type connState struct {
-	ctx     context.Context
-	cancel  context.CancelFunc
-	writeMu sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	sid    string // browser session the connection belongs to
 }
 
+// socket is a client's WebSocket connection and the request that opened it.
+type socket struct {
+	conn *websocket.Conn
+	req  *http.Request
+}
+
+// Request returns the upgrade request of the connection.
+func (ws *socket) Request() *http.Request { return ws.req }
+
+// wsWriteTimeout bounds a send to one client, so a stalled connection
+// does not hold up Push or Broadcast; the connection is closed when it
+// passes.
+const wsWriteTimeout = 10 * time.Second
+
 // ---------------------------------------------------------------------------
 // App: routes, actions, server
 // ---------------------------------------------------------------------------
@@ -42,14 +63,48 @@ type LayoutHandler func(ctx *Context) *Node
 // and named actions (WS). Pages return a *Node tree that compiles to JS
 // for the initial render. Actions return raw JS strings for DOM mutations.
 type App struct {
-	mu         sync.RWMutex
-	actions    map[string]ActionHandler
-	clients    map[*websocket.Conn]bool
-	connStates map[*websocket.Conn]*connState
-	mux        *http.ServeMux
-	pageMux    *http.ServeMux
-	layout     LayoutHandler
-	setupOnce  sync.Once
+	mu           sync.RWMutex
+	actions      map[string]ActionHandler
+	clients      map[*socket]bool
+	connStates   map[*socket]*connState
+	sessions     map[string]map[*socket]bool // WS clients by session ID
+	channels     map[string]map[string]bool  // session IDs by channel
+	mux          *http.ServeMux
+	pageMux      *http.ServeMux
+	routeMux     *http.ServeMux // method-aware stubs of every route, for 405 replies
+	layout       LayoutHandler
+	setupOnce    sync.Once
+	ssePath      string
+	sseClients   map[*sseClient]bool
+	wsPing       time.Duration                // client heartbeat interval
+	wsStale      time.Duration                // silence after which a connection is dropped
+	wsRetry      time.Duration                // first client reconnect delay
+	wsRetryMax   time.Duration                // cap of the doubling reconnect delay
+	noCompress   bool                         // disables the gzip middleware
+	prefetch     bool                         // PrefetchOnHover enabled
+	csrf         bool                         // CSRF verification enabled
+	csrfKey      []byte                       // HMAC key for CSRF tokens
+	store        SessionStore                 // backs Context.Session
+	lastSweep    time.Time                    // last SessionStore.Sweep
+	sessionsUsed bool                         // a session was written, see needsSession
+	limiter      *rateLimiter                 // nil when RateLimit is off
+	toast        *ToastOptions                // app-wide toast defaults, nil for built-ins
+	lenient      bool                         // Body drops disallowed oneof values instead of failing
+	accessLog    bool                         // log every request and action call
+	onError      func(*Context, error) *Node  // 500 page content, see OnError
+	strictCSP    bool                         // nonce-based CSP on page loads
+	maxBody      int64                        // MaxBodySize default, 0 for DefaultMaxBodySize, -1 for none
+	bodyLimits   map[string]int64             // MaxBodySize per route pattern
+	cache        nodeCache                    // subtrees memoized by Cache
+	deferred     deferStore                   // sections waiting for Context.Defer fills
+	catalogs     map[string]map[string]string // Translations by normalized locale
+	defLocale    string                       // DefaultLocale, "" for "en"
+	theme        *ThemeConfig                 // brand colors, nil for built-ins
+	timeout      time.Duration                // HandlerTimeout, 0 for none
+	sessLocks    sessionLocks                 // serialize writes per session
+	pages        map[string]*pageRoute        // Page routes by ServeMux pattern
+	metrics      *metrics                     // nil until Metrics is called
+	logger       Logger                       // nil for the standard log package
 
 	// Favicon is the URL path for the site favicon (e.g. "/assets/favicon.svg").
 	// When set, a <link rel="icon"> tag is emitted in the HTML shell.
@@ -76,6 +131,10 @@ type App struct {
 	// Origin header are allowed for non-browser clients. Use "*" to disable
 	// origin validation entirely.
 	AllowedOrigins []string
+
+	// AutoTLSCache is the directory where ListenAutoTLS keeps certificates.
+	// Defaults to "gsui-autocert" under the user cache directory.
+	AutoTLSCache string
 }
 
 // PageHandler builds the initial DOM for a GET route.
@@ -89,31 +148,147 @@ type ActionHandler func(ctx *Context) string
 func NewApp() *App {
 	return &App{
 		actions:    make(map[string]ActionHandler),
-		clients:    make(map[*websocket.Conn]bool),
-		connStates: make(map[*websocket.Conn]*connState),
+		clients:    make(map[*socket]bool),
+		connStates: make(map[*socket]*connState),
+		sessions:   make(map[string]map[*socket]bool),
+		channels:   make(map[string]map[string]bool),
+		sseClients: make(map[*sseClient]bool),
 		mux:        http.NewServeMux(),
 		pageMux:    http.NewServeMux(),
+		routeMux:   http.NewServeMux(),
+		wsPing:     defaultWSPing,
+		wsStale:    defaultWSStale,
+		wsRetry:    defaultWSRetry,
+		wsRetryMax: defaultWSRetryMax,
+		csrfKey:    newCSRFKey(),
+		store:      NewMemoryStore(),
+	}
+}
+
+const (
+	defaultWSPing     = 25 * time.Second
+	defaultWSStale    = 75 * time.Second
+	defaultWSRetry    = 500 * time.Millisecond
+	defaultWSRetryMax = 10 * time.Second
+)
+
+// WSConfig tunes the WebSocket heartbeat. The browser sends a ping every
+// pingInterval and the server answers; either side drops a connection that
+// has been silent for staleTimeout, after which the client reconnects.
+// Lower both values when a proxy or load balancer closes idle sockets
+// sooner than the 25s/75s defaults. staleTimeout must exceed pingInterval.
+func (app *App) WSConfig(pingInterval, staleTimeout time.Duration) error {
+	if pingInterval <= 0 {
+		return fmt.Errorf("gsui: ping interval must be positive, got %v", pingInterval)
+	}
+	if staleTimeout <= pingInterval {
+		return fmt.Errorf("gsui: stale timeout %v must exceed ping interval %v", staleTimeout, pingInterval)
+	}
+	app.mu.Lock()
+	app.wsPing = pingInterval
+	app.wsStale = staleTimeout
+	app.mu.Unlock()
+	return nil
+}
+
+// WSReconnect tunes how the browser reconnects a dropped WebSocket. The
+// first attempt waits initial, and each failed one doubles the wait up to
+// maxDelay. Every wait is spread by ±25% at random, so clients dropped
+// together by a restart or deploy do not all reconnect at once. An open
+// connection resets the wait to initial. The defaults are 500ms and 10s;
+// raise maxDelay when many clients share one server.
+func (app *App) WSReconnect(initial, maxDelay time.Duration) error {
+	if initial <= 0 {
+		return fmt.Errorf("gsui: initial reconnect delay must be positive, got %v", initial)
 	}
+	if maxDelay < initial {
+		return fmt.Errorf("gsui: max reconnect delay %v is below initial delay %v", maxDelay, initial)
+	}
+	app.mu.Lock()
+	app.wsRetry = initial
+	app.wsRetryMax = maxDelay
+	app.mu.Unlock()
+	return nil
+}
+
+// wsConfigJS publishes the heartbeat and reconnect settings, and the
+// message size limit with the toast shown for calls over it, to the client
+// script.
+func (app *App) wsConfigJS() string {
+	limit := app.bodyLimit("/__ws")
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	return fmt.Sprintf("window.__gsuiWS={ping:%d,stale:%d,retry:%d,retryMax:%d,max:%d,tooBig:function(){%s}};",
+		app.wsPing.Milliseconds(), app.wsStale.Milliseconds(), app.wsRetry.Milliseconds(), app.wsRetryMax.Milliseconds(),
+		limit, Notify("error", "Message too large"))
+}
+
+// ToastDefaults sets the position, duration and close button of every
+// toast shown by Notify on this app's pages. NotifyOpts still overrides
+// them per toast.
+func (app *App) ToastDefaults(opts ToastOptions) {
+	app.mu.Lock()
+	app.toast = &opts
+	app.mu.Unlock()
+}
+
+func (app *App) toastConfigJS() string {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	if app.toast == nil {
+		return ""
+	}
+	return "window.__gsuiToast=" + app.toast.js() + ";"
 }
 
 // Page registers a GET route using Go's http.ServeMux pattern syntax. Named
-// wildcards are available through ctx.Request.PathValue and ctx.PathParams.
-// The handler returns a *Node tree which is compiled to JS and served inside
-// the standard HTML shell.
+// wildcards are available through ctx.Param, ctx.Request.PathValue and
+// ctx.PathParams; ":name" segments are accepted as shorthand for "{name}".
+// Static routes take precedence over wildcard ones, so "/users/new" wins
+// over "/users/:id". The handler returns a *Node tree which is compiled to
+// JS and served inside the standard HTML shell.
 //
 //	app.Page("/dp/{token}", func(ctx *ui.Context) *ui.Node {
-//		token := ctx.Request.PathValue("token")
+//		token := ctx.Param("token")
 //		return ui.Div().Text(token)
 //	})
+//
+// Registering the same pattern again with the same handler function (the
+// same func, or a closure from the same function literal) replaces the
+// handler, so setup code can run more than once per process, as in tests
+// or after a hot reload. A different handler function for a pattern that
+// is already taken panics, as ServeMux does for conflicting patterns.
 func (app *App) Page(pattern string, handler PageHandler) {
-	serveMuxPattern := pattern
+	app.page(pattern, handler, funcName(handler))
+}
+
+// page registers handler for pattern; name identifies the handler for
+// repeated registrations and is that of the function the caller passed,
+// before any wrapping.
+func (app *App) page(pattern string, handler PageHandler, name string) {
+	serveMuxPattern := muxPattern(pattern)
 	// Preserve the historical exact-match behavior of static routes ending in
 	// a slash. ServeMux otherwise treats them as subtree routes. Callers that
 	// want a subtree can register an explicit {name...} wildcard.
 	if strings.HasSuffix(serveMuxPattern, "/") && !strings.Contains(serveMuxPattern, "{") {
 		serveMuxPattern += "{$}"
 	}
-	app.pageMux.Handle("GET "+serveMuxPattern, pageRoute{app: app, handler: handler})
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if route := app.pages[serveMuxPattern]; route != nil {
+		if route.name == name {
+			route.handler = handler
+			return
+		}
+		panic(fmt.Sprintf("gsui: page %q is already registered by %s", pattern, route.name))
+	}
+	route := &pageRoute{app: app, handler: handler, name: name}
+	app.pageMux.Handle("GET "+serveMuxPattern, route)
+	app.routeMux.Handle("GET "+serveMuxPattern, http.NotFoundHandler())
+	if app.pages == nil {
+		app.pages = make(map[string]*pageRoute)
+	}
+	app.pages[serveMuxPattern] = route
 }
 
 // CSS registers external stylesheets and/or inline CSS rules that apply
@@ -134,7 +309,7 @@ func (app *App) CSS(urls []string, css string) {
 	defer app.mu.Unlock()
 	for _, u := range urls {
 		app.HTMLHead = append(app.HTMLHead,
-			fmt.Sprintf(`<link rel="stylesheet" href="%s">`, u))
+			fmt.Sprintf(`<link rel="stylesheet" href="%s">`, html.EscapeString(u)))
 	}
 	if css != "" {
 		app.HTMLHead = append(app.HTMLHead,
@@ -151,20 +326,129 @@ func (app *App) Action(name string, handler ActionHandler) {
 	app.mu.Unlock()
 }
 
-// GET registers a standard HTTP GET handler on the internal mux.
-// Use this for REST API endpoints that return JSON, files, etc.
+// Callable registers fn under a name derived from the function itself and
+// returns an Action that calls it, so a handler can be wired up where it is
+// built without inventing a name:
+//
+//	ui.Button().Text("Refresh").OnClick(app.Callable(refreshStats))
+//
+// Closures and method values need key, a stable ID of the instance they
+// serve (a row ID, a component ID): all closures made from one function
+// literal, and the method values of all receivers, share one code pointer
+// and so one derived name, and the last registration would answer every
+// button. Callable panics when such a function comes without a key:
+//
+//	for _, row := range rows {
+//		del := app.Callable(func(ctx *ui.Context) string { return remove(row.ID) }, row.ID)
+//		ui.Button().Text("Delete").OnClick(del)
+//	}
+//
+// The name depends only on fn's code and key, so rendering the same page
+// again replaces the handler instead of registering a new action. Every
+// distinct key adds an action the app keeps until it stops, so use keys
+// from a bounded set, such as the IDs of stored records, never values new
+// on each request.
+func (app *App) Callable(fn ActionHandler, key ...string) *Action {
+	if len(key) == 0 && boundFunc(funcName(fn)) {
+		panic(fmt.Sprintf("gsui: Callable(%s) needs a key: closures and method values share a name across instances", funcName(fn)))
+	}
+	name := callableName(fn, key)
+	app.Action(name, fn)
+	return &Action{Name: name}
+}
+
+// boundFuncName matches the runtime names of closures ("pkg.F.func1") and
+// method values ("pkg.(*T).M-fm").
+var boundFuncName = regexp.MustCompile(`(\.func\d+(\.\d+)*|-fm)$`)
+
+// boundFunc reports whether the function named name may carry state of
+// its own, so that values of it must not share a derived action name.
+func boundFunc(name string) bool {
+	return boundFuncName.MatchString(name)
+}
+
+// callableName hashes the runtime name of fn together with key, keeping
+// package paths out of the page.
+func callableName(fn ActionHandler, key []string) string {
+	h := fnv.New64a()
+	h.Write([]byte(funcName(fn)))
+	for _, k := range key {
+		h.Write([]byte{0})
+		h.Write([]byte(k))
+	}
+	return fmt.Sprintf("fn.%016x", h.Sum64())
+}
+
+// funcName returns the runtime name of the function fn, shared by all
+// closures made from the same function literal.
+func funcName(fn any) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+// Handle registers a standard HTTP handler for method and path on the
+// internal mux. Use it for REST API endpoints that return JSON, files, etc.
+// Paths use the same pattern syntax as Page; read parameters with
+// r.PathValue. A request whose path is routed but whose method is not gets
+// 405 Method Not Allowed with an Allow header listing the methods that are.
+// Unsafe methods (anything but GET, HEAD, OPTIONS) go through the CSRF
+// check and rate limit like POST.
+//
+//	app.Handle("PUT", "/api/items/{id}", updateItem)
+func (app *App) Handle(method, path string, handler http.HandlerFunc) {
+	pattern := strings.ToUpper(method) + " " + muxPattern(path)
+	app.mux.HandleFunc(pattern, handler)
+	app.routeMux.Handle(pattern, http.NotFoundHandler())
+}
+
+// GET registers a standard HTTP GET handler, see Handle.
 func (app *App) GET(path string, handler http.HandlerFunc) {
-	app.mux.HandleFunc("GET "+path, handler)
+	app.Handle(http.MethodGet, path, handler)
 }
 
-// POST registers a standard HTTP POST handler on the internal mux.
+// POST registers a standard HTTP POST handler, see Handle.
 func (app *App) POST(path string, handler http.HandlerFunc) {
-	app.mux.HandleFunc("POST "+path, handler)
+	app.Handle(http.MethodPost, path, handler)
+}
+
+// PUT registers a standard HTTP PUT handler, see Handle.
+func (app *App) PUT(path string, handler http.HandlerFunc) {
+	app.Handle(http.MethodPut, path, handler)
+}
+
+// PATCH registers a standard HTTP PATCH handler, see Handle.
+func (app *App) PATCH(path string, handler http.HandlerFunc) {
+	app.Handle(http.MethodPatch, path, handler)
 }
 
-// DELETE registers a standard HTTP DELETE handler on the internal mux.
+// DELETE registers a standard HTTP DELETE handler, see Handle.
 func (app *App) DELETE(path string, handler http.HandlerFunc) {
-	app.mux.HandleFunc("DELETE "+path, handler)
+	app.Handle(http.MethodDelete, path, handler)
+}
+
+// Mux returns the app's own http.ServeMux, which serves its pages, actions
+// and assets. Nothing is registered on http.DefaultServeMux, so several
+// apps can run in one process. Handlers added here run behind the app's
+// middleware (compression, CSRF, rate limits) when served through Handler
+// or Listen. "/" and the /__ws paths are taken by the app.
+//
+//	app.Mux().Handle("GET /metrics", promhttp.Handler())
+func (app *App) Mux() *http.ServeMux {
+	return app.mux
+}
+
+// muxPattern rewrites ":name" path segments into ServeMux "{name}"
+// wildcards and leaves everything else untouched.
+func muxPattern(pattern string) string {
+	if !strings.Contains(pattern, "/:") {
+		return pattern
+	}
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if len(seg) > 1 && seg[0] == ':' {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
 }
 
 // Assets serves static files from an embedded or on-disk filesystem.
@@ -176,12 +460,99 @@ func (app *App) DELETE(path string, handler http.HandlerFunc) {
 //	app.Assets(assets, "assets", "/assets/")
 //
 // This makes assets/favicon.svg available at /assets/favicon.svg.
+// Files get an ETag from a hash of their content, computed once per file
+// since fsys is expected not to change (embed.FS), so clients revalidate
+// with If-None-Match and get 304 Not Modified. A dir that is not a valid
+// path within fsys is logged as an error and nothing is registered.
 func (app *App) Assets(fsys fs.FS, dir, prefix string) {
 	sub, err := fs.Sub(fsys, dir)
 	if err != nil {
-		log.Fatalf("gsui: assets: fs.Sub(%q): %v", dir, err)
+		app.log().Errorf("assets: fs.Sub(%q): %v; %s not served", dir, err, prefix)
+		return
+	}
+	files := http.FileServerFS(sub)
+	var etags sync.Map // file name -> ETag
+	app.mux.Handle(prefix, http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if fs.ValidPath(name) {
+			if v, ok := etags.Load(name); ok {
+				w.Header().Set("ETag", v.(string))
+			} else if b, err := fs.ReadFile(sub, name); err == nil {
+				sum := sha256.Sum256(b)
+				etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+				etags.Store(name, etag)
+				w.Header().Set("ETag", etag)
+			}
+			if serveUnseekable(w, r, sub, name) {
+				return
+			}
+		}
+		files.ServeHTTP(w, r)
+	})))
+}
+
+// serveUnseekable serves a regular file whose fs.File cannot seek from
+// memory via http.ServeContent, so Range requests still get 206 Partial
+// Content. It reports false for seekable files (http.FileServerFS already
+// serves ranges of those), directories and errors.
+func serveUnseekable(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) bool {
+	if name == "" {
+		return false
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if _, ok := f.(io.Seeker); ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return false
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return false
 	}
-	app.mux.Handle(prefix, http.StripPrefix(prefix, http.FileServerFS(sub)))
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), bytes.NewReader(b))
+	return true
+}
+
+// AssetsDir serves files from a directory on disk under urlPrefix, so CSS
+// and JS can be edited during development without re-embedding and
+// rebuilding. Responses carry "Cache-Control: public, max-age=..." for a
+// positive maxAge and "no-cache" otherwise, plus Last-Modified and an ETag
+// from the file's size and modification time, so conditional requests get
+// 304 Not Modified. Paths escaping dir ("..") and directory listings are
+// answered with 404. Prefer Assets with an embed.FS for production builds.
+//
+//	app.AssetsDir("/assets/", "example/assets", 0)
+func (app *App) AssetsDir(urlPrefix, dir string, maxAge time.Duration) {
+	if !strings.HasSuffix(urlPrefix, "/") {
+		urlPrefix += "/"
+	}
+	fsys := os.DirFS(dir)
+	files := http.FileServerFS(fsys)
+	cache := "no-cache"
+	if maxAge > 0 {
+		cache = fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	}
+	app.mux.Handle(urlPrefix, http.StripPrefix(urlPrefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if name == "" || !fs.ValidPath(name) {
+			http.NotFound(w, r)
+			return
+		}
+		fi, err := fs.Stat(fsys, name)
+		if err != nil || fi.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", cache)
+		w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, fi.ModTime().UnixNano(), fi.Size()))
+		files.ServeHTTP(w, r)
+	})))
 }
 
 // Layout registers a layout handler that wraps every page render.
@@ -200,14 +571,19 @@ func (app *App) Layout(handler LayoutHandler) {
 //	srv.ListenAndServe()
 func (app *App) Handler() http.Handler {
 	app.setup()
-	return app.mux
+	return app.handler()
+}
+
+// handler wraps the mux with the app's middleware.
+func (app *App) handler() http.Handler {
+	return assignRequestIDs(app.measureRequests(app.logRequests(hsts(app.compress(app.limitBody(app.checkCSRF(app.limitHTTP(app.mux))))))))
 }
 
 // Listen sets up HTTP handlers and starts the server.
 func (app *App) Listen(addr string) error {
 	app.setup()
-	log.Printf("gsui: listening on %s", addr)
-	return http.ListenAndServe(addr, app.mux)
+	app.log().Infof("listening on %s", addr)
+	return http.ListenAndServe(addr, app.handler())
 }
 
 // ---------------------------------------------------------------------------
@@ -274,12 +650,28 @@ func (app *App) setupRoutes() {
 
 		// With a layout: replace only __content__ inner content.
 		// Without a layout: clear body and append the full page tree.
+		// Flash messages are shown, and consumed, as on a full load.
 		if layoutFn != nil {
-			return pageNode.ToJSInner("__content__")
+			return pageNode.ToJSInner("__content__") + ctx.takeFlashJS()
+		}
+		return "(function(){" + keepJS("document.body") + "document.body.innerHTML='';" + pageNode.ToJS() + keptJS + "})();" + ctx.takeFlashJS()
+	})
+
+	// Built-in __locale action: sent by LanguageSwitcher. Saves the
+	// preference in the session and reloads so the page renders in it.
+	app.Action("__locale", func(ctx *Context) string {
+		var req struct {
+			Locale string `json:"locale"`
 		}
-		return "(function(){document.body.innerHTML=''})();" + pageNode.ToJS()
+		ctx.Body(&req)
+		ctx.SetLocale(req.Locale)
+		return "location.reload()"
 	})
 
+	// Built-in __defer action: a section rendered by ctx.Defer asks for its
+	// content once the page's WebSocket is up.
+	app.Action("__defer", app.fillDeferred)
+
 	// Built-in __notfound action: the client sends this when a WS patch
 	// targets a DOM element that no longer exists. Cancel push context so
 	// server-side goroutines calling ctx.Push() get an error and stop.
@@ -292,11 +684,22 @@ func (app *App) setupRoutes() {
 	app.mux.HandleFunc("GET /__ws.js", app.serveWSClient)
 
 	// WebSocket endpoint
-	app.mux.Handle("/__ws", websocket.Server{Handshake: app.wsHandshake, Handler: app.handleWS})
+	app.mux.HandleFunc("/__ws", app.serveWS)
 
 	// Page routes (catch-all). The nested mux provides ServeMux pattern
 	// matching and populates Request.PathValue for Page handlers.
-	app.mux.Handle("/", app.pageMux)
+	app.mux.HandleFunc("/", app.servePages)
+}
+
+// servePages hands requests that match a page to pageMux. Anything else
+// lands here because "/" catches every method, so routeMux, which knows the
+// methods of all pages and Handle routes, answers 405 with Allow or 404.
+func (app *App) servePages(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := app.pageMux.Handler(r); pattern != "" {
+		app.pageMux.ServeHTTP(w, r)
+		return
+	}
+	app.routeMux.ServeHTTP(w, r)
 }
 
 // ---------------------------------------------------------------------------
@@ -323,16 +726,20 @@ func injectContent(node, pageNode *Node) bool {
 // by WebSocket navigation.
 type pageRoute struct {
 	app     *App
-	handler PageHandler
+	handler PageHandler // guarded by app.mu, replaced by a repeated Page
+	name    string      // handler's function name, see Page
 }
 
-func (route pageRoute) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+func (route *pageRoute) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route.app.mu.RLock()
+	handler := route.handler
+	route.app.mu.RUnlock()
 	if match, ok := r.Context().Value(pageMatchContextKey{}).(*pageMatch); ok {
-		match.handler = route.handler
+		match.handler = handler
 		match.request = r.WithContext(match.requestContext)
 		return
 	}
-	route.app.renderPage(w, r, route.handler)
+	route.app.renderPage(w, r, handler)
 }
 
 type pageMatchContextKey struct{}
@@ -397,7 +804,24 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 		PathParams: requestPathParams(r),
 		Query:      make(map[string]string),
 		app:        app,
+		sessionID:  requestSessionID(r),
+		requestID:  requestIDOf(r),
 	}
+	// A prefetch is not a visit yet: it leaves the session alone, see
+	// PrefetchOnHover.
+	prefetch := isPrefetch(r)
+	ctx.sessionMint = ctx.sessionID == "" && !prefetch
+	if app.needsSession() {
+		ctx.useSession()
+	}
+	app.mu.RLock()
+	if app.strictCSP {
+		ctx.nonce = newNonce()
+		w.Header().Set("Content-Security-Policy", cspHeader(ctx.nonce))
+	}
+	app.mu.RUnlock()
+	defer app.bindCtx(ctx, r.Context())()
+	defer app.recoverPage(w, ctx)
 
 	// Parse query params
 	for k, v := range r.URL.Query() {
@@ -405,6 +829,7 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 			ctx.Query[k] = v[0]
 		}
 	}
+	app.loadSession(ctx)
 
 	// Build the node tree
 	pageNode := handler(ctx)
@@ -419,6 +844,8 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 	var root *Node
 	app.mu.RLock()
 	layoutFn := app.layout
+	ssePath := app.ssePath
+	appHead := strings.Join(app.HTMLHead, "\n")
 	app.mu.RUnlock()
 
 	if layoutFn != nil {
@@ -432,9 +859,21 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 	} else {
 		root = pageNode
 	}
+	var flashJS string
+	if !prefetch {
+		flashJS = ctx.takeFlashJS()
+		app.saveSession(ctx)
+		if ctx.sessionNew {
+			setSessionCookie(w, r, ctx.sessionID)
+		}
+	} else if ctx.sessionID == "" || ctx.Session[flashKey] != nil {
+		// Without its session or flash messages the page must not stand
+		// in for the visit; the click loads it again.
+		w.Header().Set("Cache-Control", "no-store")
+	}
 
 	// Compile to JS
-	jsBody := root.ToJS()
+	jsBody := root.ToJS() + flashJS
 
 	// Respond with minimal HTML shell
 	faviconTag := ""
@@ -449,32 +888,40 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 	if app.Description != "" {
 		descTag = fmt.Sprintf(`<meta name="description" content="%s">`, html.EscapeString(app.Description))
 	}
+	descTag += app.csrfMetaTag(ctx.sessionID)
 
 	// Build custom head HTML (app-wide + per-page)
-	customHead := strings.Join(app.HTMLHead, "\n")
+	customHead := appHead
 	if pageCSS := ctx.cssHeadHTML(); pageCSS != "" {
 		customHead += "\n" + pageCSS
 	}
 	if pageJS := ctx.jsHeadHTML(); pageJS != "" {
 		customHead += "\n" + pageJS
 	}
+	sseTag := ""
+	if ssePath != "" {
+		sseTag = stampNonce("<script>"+sseClientJS(ssePath)+"</script>", ctx.nonce)
+	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	// Use gzip compression if the client supports it
-	var writer io.Writer = w
-	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-		w.Header().Set("Content-Encoding", "gzip")
-		gz := gzip.NewWriter(w)
-		defer gz.Close()
-		writer = gz
+	styleAttrs, fontAttrs := styleEngineLoadAttrs, iconFontLoadAttrs
+	if ctx.nonce != "" {
+		styleAttrs, fontAttrs = "", ""
 	}
 
-	fmt.Fprintf(writer, `<!DOCTYPE html>
-<html lang="en" class="gsui-booting">
+	fmt.Fprintf(w, stampNonce(pageShell, ctx.nonce), html.EscapeString(ctx.Locale()), themeInitJS, loadingCSS+app.themeCSS(), faviconTag, titleTag, descTag, wsStubJS, app.wsConfigJS()+app.toastConfigJS()+app.prefetchConfigJS(), styleAttrs, fontAttrs, darkOverrideCSS, stampNonce(customHead, ctx.nonce), wsClientVersion, sseTag, bootInitJS, jsBody)
+}
+
+// pageShell is the HTML of a full page load, see renderPage.
+const pageShell = `<!DOCTYPE html>
+<html lang="%s" class="gsui-booting">
 <head>
 <meta charset="UTF-8">
 <meta name="viewport" content="width=device-width,initial-scale=1">
+<meta name="color-scheme" content="light dark">
+<script>%s</script>
+<style>%s</style>
 %s
 %s
 %s
@@ -484,8 +931,8 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 <script>%s
 %s</script>
 
-<script src="https://cdn.jsdelivr.net/npm/@tailwindcss/browser@4" data-gsui-style-engine async onload="this.dataset.gsuiLoaded='true'" onerror="this.dataset.gsuiLoaded='error'"></script>
-<link rel="stylesheet" href="https://fonts.googleapis.com/icon?family=Material+Icons+Round" media="print" onload="this.media='all';this.dataset.gsuiLoaded='true'" onerror="this.dataset.gsuiLoaded='error'">
+<script src="https://cdn.jsdelivr.net/npm/@tailwindcss/browser@4" data-gsui-style-engine async%s></script>
+<link rel="stylesheet" href="https://fonts.googleapis.com/icon?family=Material+Icons+Round"%s>
 <noscript><link rel="stylesheet" href="https://fonts.googleapis.com/icon?family=Material+Icons+Round"></noscript>
 <style type="text/tailwindcss">
 @custom-variant dark (&:where(.dark, .dark *));
@@ -494,7 +941,7 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 <style>%s</style>
 %s
 <script src="/__ws.js?v=%s" defer></script>
-<style>%s</style>
+%s
 <script>%s</script>
 </head>
 <body>
@@ -502,8 +949,7 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 %s
 </script>
 </body>
-</html>`, faviconTag, titleTag, descTag, themeInitJS, wsStubJS, darkOverrideCSS, customHead, wsClientVersion, loadingCSS, bootInitJS, jsBody)
-}
+</html>`
 
 // ---------------------------------------------------------------------------
 // WebSocket client script
@@ -512,15 +958,6 @@ func (app *App) renderPage(w http.ResponseWriter, r *http.Request, handler PageH
 func (app *App) serveWSClient(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/javascript")
 	w.Header().Set("Cache-Control", "public, max-age=86400")
-
-	// Gzip compress if supported
-	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-		w.Header().Set("Content-Encoding", "gzip")
-		gz := gzip.NewWriter(w)
-		defer gz.Close()
-		gz.Write([]byte(wsClientJS))
-		return
-	}
 	w.Write([]byte(wsClientJS))
 }
 
@@ -540,16 +977,20 @@ var wsClientVersion = func() string {
 const wsStubJS = `window.__ws||(window.__ws={__q:[],call:function(){this.__q.push(['call',arguments])},callSilent:function(){this.__q.push(['callSilent',arguments])},notfound:function(){this.__q.push(['notfound',arguments])}});`
 
 // themeInitJS runs synchronously in <head> before the body renders to
-// prevent FOUC. It reads the stored theme from localStorage, applies the
-// "dark" class on <html>, and exposes setTheme(mode) / toggleTheme() globals.
+// prevent FOUC. The shell emits it, with loadingCSS, ahead of every other
+// head resource so the first paint already has the right background. It
+// reads the stored theme from localStorage (blocked storage falls back to
+// the system preference instead of throwing), applies the "dark" class on
+// <html>, and exposes setTheme(mode) / toggleTheme() globals.
 const themeInitJS = `(function(){
 if(window.__gsuiThemeInit)return;window.__gsuiThemeInit=true;
 var d=document.documentElement;
+function stored(){try{return localStorage.getItem('theme')||'system'}catch(_){return 'system'}}
 function apply(m){if(m==='system')m=(window.matchMedia&&window.matchMedia('(prefers-color-scheme: dark)').matches)?'dark':'light';if(m==='dark'){d.classList.add('dark');d.style.colorScheme='dark'}else{d.classList.remove('dark');d.style.colorScheme='light'}}
-function set(m){localStorage.setItem('theme',m);apply(m)}
+function set(m){try{localStorage.setItem('theme',m)}catch(_){}apply(m)}
 window.setTheme=set;window.toggleTheme=function(){set(d.classList.contains('dark')?'light':'dark')};
-apply(localStorage.getItem('theme')||'system');
-window.matchMedia('(prefers-color-scheme: dark)').addEventListener('change',function(){var s=localStorage.getItem('theme')||'';if(!s||s==='system')apply('system')});
+apply(stored());
+if(window.matchMedia)window.matchMedia('(prefers-color-scheme: dark)').addEventListener('change',function(){if(stored()==='system')apply('system')});
 })();`
 
 // bootInitJS keeps the application hidden until its initial DOM, stylesheets,
@@ -599,7 +1040,7 @@ html.gsui-revealing body{visibility:visible;transition:opacity 160ms cubic-bezie
 // may not carry explicit dark: Tailwind classes (e.g. plain body, form UA styles).
 // These use .dark selectors without !important so explicit dark: variants win.
 // Also applies a local font stack to avoid late webfont swaps and CLS.
-const darkOverrideCSS = `.dark body{color:#e5e7eb;background-color:#0b1120}
+const darkOverrideCSS = `.dark body{color:var(--gsui-text-dark,#e5e7eb);background-color:var(--gsui-surface-dark,#0b1120)}
 .dark input::placeholder,.dark textarea::placeholder{color:#9ca3af}
 body{font-family:ui-sans-serif,system-ui,-apple-system,'Segoe UI',sans-serif}`
 
@@ -607,9 +1048,18 @@ body{font-family:ui-sans-serif,system-ui,-apple-system,'Segoe UI',sans-serif}`
 // It connects to the WS endpoint, sends action calls, executes
 // whatever JS string the server sends back, and shows an offline
 // overlay when the WebSocket disconnects.
-const wsClientJS = `var __wsPre=window.__ws;
+const wsClientJS = `if(!window.__gsuiWSInit){window.__gsuiWSInit=true;
+` + timeZoneJS + `
+// __gsuiKeep notes the elements marked data-gsui-keep (Node.Keep) inside
+// scope before a swap and returns a function that, once the new content
+// is in, puts each back in place of the new element with its ID.
+window.__gsuiKeep=function(scope){
+  var kept={};scope.querySelectorAll('[data-gsui-keep][id]').forEach(function(el){kept[el.id]=el});
+  return function(){Object.keys(kept).forEach(function(id){var n=document.getElementById(id);if(n&&n!==kept[id]&&n.hasAttribute('data-gsui-keep'))n.replaceWith(kept[id])})};
+};
+var __wsPre=window.__ws;
 var __offline=(function(){
-  var el=null;
+  var el=null,sub=null,waiting=0;
   function show(){
     if(document.getElementById('__offline__')){el=document.getElementById('__offline__');return;}
     try{document.body.classList.add('pointer-events-none');}catch(_){}
@@ -620,7 +1070,7 @@ var __offline=(function(){
     b.style.background='linear-gradient(135deg,#ef4444,#ec4899)';
     var dot=document.createElement('span');dot.className='inline-block h-2.5 w-2.5 rounded-full bg-white/95 animate-pulse';
     var lbl=document.createElement('span');lbl.className='font-semibold tracking-wide';lbl.style.color='#fff';lbl.textContent='Offline';
-    var sub=document.createElement('span');sub.className='ml-1 text-xs';sub.style.color='rgba(255,255,255,0.9)';sub.textContent='Trying to reconnect\u2026';
+    sub=document.createElement('span');sub.className='ml-1 text-xs';sub.style.color='rgba(255,255,255,0.9)';label();
     b.appendChild(dot);b.appendChild(lbl);b.appendChild(sub);o.appendChild(b);
     document.body.appendChild(o);
     requestAnimationFrame(function(){o.style.opacity='1';});
@@ -631,12 +1081,30 @@ var __offline=(function(){
     var o=document.getElementById('__offline__');if(!o){el=null;return;}
     try{o.style.opacity='0';}catch(_){}
     setTimeout(function(){try{if(o&&o.parentNode){o.parentNode.removeChild(o);}}catch(_){}},150);
-    el=null;
+    el=null;sub=null;
   }
-  return{show:show,hide:hide};
+  // pending shows how many calls wait to be resent (see Action.Retry).
+  function pending(n){waiting=n;label()}
+  function label(){if(sub)sub.textContent='Trying to reconnect\u2026'+(waiting?' '+waiting+' pending':'')}
+  return{show:show,hide:hide,pending:pending};
 })();
 var __ws=(function(){
-  var ws,q=[],ready=false,seq=0,inflight={},loaderEl=null,loaderTimer=0,hadClose=false,backoff=500;
+  var ws,q=[],ready=false,seq=0,inflight={},undo={},done={},jsonTo={},here=location.href,loaderEl=null,loaderTimer=0,hadClose=false,retry={},resync=false;
+  var cfg=window.__gsuiWS||{},pingMs=cfg.ping||25000,staleMs=cfg.stale||75000,lastSeen=0,pingTimer=0;
+  var retryMs=cfg.retry||500,retryMaxMs=cfg.retryMax||10000,backoff=retryMs,maxBytes=cfg.max>0?cfg.max:0;
+  var csrfMeta=document.querySelector('meta[name="csrf-token"]'),csrf=csrfMeta?csrfMeta.content:'';
+  if(csrf){
+    var unsafe=function(m){return !/^(GET|HEAD|OPTIONS|TRACE)$/i.test(m||'GET')};
+    if(window.fetch){var fetch0=window.fetch;window.fetch=function(input,init){
+      init=init||{};var isReq=typeof Request!=='undefined'&&input instanceof Request;
+      var u=new URL(isReq?input.url:String(input),location.href);
+      if(u.origin===location.origin&&unsafe(init.method||(isReq?input.method:''))){var h=new Headers(init.headers||(isReq?input.headers:undefined));if(!h.has('X-CSRF-Token'))h.set('X-CSRF-Token',csrf);init.headers=h}
+      return fetch0.call(this,input,init)}}
+    document.addEventListener('submit',function(e){
+      var f=e.target;if(!f||!unsafe(f.method)||f.querySelector('input[name="csrf_token"]'))return;
+      var i=document.createElement('input');i.type='hidden';i.name='csrf_token';i.value=csrf;f.appendChild(i);
+    },true);
+  }
   function showLoader(){
     if(loaderEl||loaderTimer)return;
     loaderTimer=setTimeout(function(){
@@ -647,7 +1115,7 @@ var __ws=(function(){
       o.style.cssText='backdrop-filter:blur(3px);-webkit-backdrop-filter:blur(3px);background:'+(document.documentElement.classList.contains('dark')?'rgba(0,0,0,0.35)':'rgba(255,255,255,0.28)')+';pointer-events:auto';
       var b=document.createElement('div');
       b.className='absolute top-3 left-3 flex items-center gap-2 rounded-full px-3 py-1 text-white shadow-lg ring-1 ring-white/30';
-      b.style.background='linear-gradient(135deg,#6366f1,#22d3ee)';
+      b.style.background='linear-gradient(135deg,var(--gsui-primary,#6366f1),var(--gsui-secondary,#22d3ee))';
       var dot=document.createElement('span');dot.className='inline-block h-2.5 w-2.5 rounded-full bg-white/95 animate-pulse';
       var lbl=document.createElement('span');lbl.className='font-semibold tracking-wide';lbl.textContent='Loading\u2026';
       var sub=document.createElement('span');sub.className='ml-1 text-white/85 text-xs';sub.style.color='rgba(255,255,255,0.9)';sub.textContent='Please wait';
@@ -657,27 +1125,118 @@ var __ws=(function(){
       loaderEl=o;
     },120);
   }
+  function unbusy(){
+    document.querySelectorAll('.gsui-busy').forEach(function(b){
+      if(b.tagName==='BUTTON')b.disabled=false;
+      b.removeAttribute('aria-busy');
+      b.classList.remove('gsui-busy','opacity-60','cursor-wait');
+      var sp=b.querySelector(':scope>.gsui-spin');if(sp)sp.remove();
+    });
+  }
+  // deliver hands a Context.JSON reply to the function or element that
+  // asked for it (see Action.JSON).
+  function deliver(to,m){
+    var detail={status:m.status,data:m.json};
+    try{if(typeof to==='function')to(detail);else if(to&&to.dispatchEvent)to.dispatchEvent(new CustomEvent('gsui:json',{bubbles:true,detail:detail}))}catch(err){console.error('gsui: JSON reply handler failed:',err)}
+  }
+  // rollback undoes the optimistic updates of calls that will get no reply.
+  // Calls marked Action.Retry keep theirs; they are resent.
+  function rollback(){var u=undo;undo={};Object.keys(u).forEach(function(k){if(retry[k]){undo[k]=u[k];return}try{u[k]()}catch(err){console.error('gsui: optimistic undo failed:',err)}})}
+  // reply settles a tracked call with the server's reply envelope.
+  function reply(m){delete retry[m.id];if(resync&&!Object.keys(retry).length){resync=false;setTimeout(function(){location.reload()})}if(inflight[m.id]){delete inflight[m.id];hideLoader()}var u=undo[m.id],dn=done[m.id],jt=jsonTo[m.id];delete undo[m.id];delete done[m.id];delete jsonTo[m.id];if('json' in m){deliver(jt,m);unbusy()}else{if(m.err&&u){try{u()}catch(err){console.error('gsui: optimistic undo failed:',err)}}if(m.js){try{new Function(m.js)()}catch(err){console.error('ws exec error:',err,m.js)}}if(dn&&!m.err){try{dn()}catch(err){console.error('gsui: reply callback failed:',err)}}unbusy()}}
+  // Messages over the server's limit would close the connection (1009).
+  // They are not sent: the call fails as if the server had answered with
+  // an error, and a toast says why.
+  function fits(msg){return !maxBytes||new Blob([msg]).size<=maxBytes}
+  function tooBig(){if(cfg.tooBig)try{cfg.tooBig()}catch(_){}}
+  function fail(id){delete retry[id];reply(jsonTo[id]?{__r:1,id:id,json:{error:'Message too large'},status:413}:{__r:1,id:id,err:true})}
   function hideLoader(){
     if(Object.keys(inflight).length)return;
     if(loaderTimer){clearTimeout(loaderTimer);loaderTimer=0;}
     if(loaderEl){loaderEl.style.opacity='0';var el=loaderEl;loaderEl=null;setTimeout(function(){try{if(el&&el.parentNode)el.parentNode.removeChild(el)}catch(_){}},160);}
   }
   function connect(){
-    ws=new WebSocket((location.protocol==='https:'?'wss://':'ws://')+location.host+'/__ws');
+    ws=new WebSocket((location.protocol==='https:'?'wss://':'ws://')+location.host+'/__ws',csrf?['csrf.'+csrf]:[]);
     ws.onopen=function(){
-      ready=true;backoff=500;
-      __offline.hide();
+      ready=true;backoff=retryMs;lastSeen=Date.now();
+      clearInterval(pingTimer);
+      pingTimer=setInterval(function(){
+        if(!ready)return;
+        if(Date.now()-lastSeen>staleMs){ws.close();return}
+        ws.send('{"act":"__ping"}');
+      },pingMs);
+      __offline.hide();__offline.pending(0);
+      // Resend calls marked Action.Retry, then whatever was queued.
+      var ids=Object.keys(retry);
+      ids.forEach(function(k){ws.send(retry[k])});
       q.forEach(function(m){ws.send(m)});q=[];
-      if(hadClose){hadClose=false;try{location.reload();return;}catch(_){}}
+      // After a drop the page reloads, once resent calls have replied.
+      if(hadClose){hadClose=false;if(ids.length)resync=true;else try{location.reload();return;}catch(_){}}
     };
-    ws.onmessage=function(e){var m;try{m=JSON.parse(e.data)}catch(_){}if(m&&typeof m==='object'&&m.__r){if(inflight[m.id]){delete inflight[m.id];hideLoader()}if(m.js){try{new Function(m.js)()}catch(err){console.error('ws exec error:',err,m.js)}}document.querySelectorAll('button.gsui-busy').forEach(function(b){b.disabled=false;b.classList.remove('gsui-busy','opacity-60','cursor-wait')})}else{try{new Function(e.data)()}catch(err){console.error('ws exec error:',err,e.data)}}try{window.dispatchEvent(new Event('gsui:updated'))}catch(_){}};
-    ws.onclose=function(){ready=false;inflight={};hideLoader();document.querySelectorAll('button.gsui-busy').forEach(function(b){b.disabled=false;b.classList.remove('gsui-busy','opacity-60','cursor-wait')});__offline.show();hadClose=true;var d=Math.min(10000,backoff)*(0.75+Math.random()*0.5);backoff=Math.min(10000,backoff*2);setTimeout(connect,d)};
+    ws.onmessage=function(e){lastSeen=Date.now();var m;try{m=JSON.parse(e.data)}catch(_){}if(m&&m.__p)return;if(m&&typeof m==='object'&&m.__r){reply(m)}else{try{new Function(e.data)()}catch(err){console.error('ws exec error:',err,e.data)}}here=location.href;try{window.dispatchEvent(new Event('gsui:updated'))}catch(_){}};
+    ws.onclose=function(e){
+      // A token the server no longer accepts (it restarted with a new
+      // key) needs a fresh page; reload at most once a minute.
+      if(e&&e.code===4403){try{var t=+sessionStorage.getItem('gsui_csrf_reload')||0;if(Date.now()-t>60000){sessionStorage.setItem('gsui_csrf_reload',String(Date.now()));location.reload();return}}catch(_){}}
+      // A message over the limit got through anyway (the limit changed
+      // since the page loaded): fail the calls that would be resent
+      // instead of sending them again, and reconnect without a reload.
+      var big=e&&e.code===1009;if(big){tooBig();Object.keys(retry).forEach(fail)}
+      ready=false;clearInterval(pingTimer);inflight={};done={};jsonTo={};rollback();hideLoader();unbusy();__offline.show();__offline.pending(Object.keys(retry).length);hadClose=!big;var d=Math.min(retryMaxMs,backoff)*(0.75+Math.random()*0.5);backoff=Math.min(retryMaxMs,backoff*2);setTimeout(connect,d)};
     ws.onerror=function(){ws.close()};
   }
   connect();
+  // Forms built with GuardUnsaved mark themselves dirty; leaving the
+  // page or the current view asks first.
+  function dirtyForm(){return [].find.call(document.querySelectorAll('form[data-guard-unsaved]'),function(f){return f.__gsuiDirty})}
+  function mayLeave(){
+    var f=dirtyForm();if(!f)return true;
+    if(!confirm(f.getAttribute('data-guard-unsaved')))return false;
+    document.querySelectorAll('form[data-guard-unsaved]').forEach(function(f){f.__gsuiDirty=false});
+    return true;
+  }
+  window.addEventListener('beforeunload',function(e){if(dirtyForm()){e.preventDefault();e.returnValue=''}});
+  document.addEventListener('click',function(e){
+    var a=e.target&&e.target.closest&&e.target.closest('a[href]');
+    if(!a||e.defaultPrevented||e.button!==0||e.metaKey||e.ctrlKey||e.shiftKey||e.altKey)return;
+    if(a.target&&a.target!=='_self'||a.hasAttribute('download')||a.getAttribute('href').charAt(0)==='#')return;
+    if(!mayLeave()){e.preventDefault();e.stopImmediatePropagation()}
+  },true);
+  // Each history entry keeps its scroll position in history.state, so
+  // back/forward can restore it once __nav has rendered the page. The
+  // browser's own restoration would run before the swap.
+  if('scrollRestoration' in history)history.scrollRestoration='manual';
+  var scrollTimer=0,movedTimer=0;
+  function saveScroll(){clearTimeout(scrollTimer);try{history.replaceState(Object.assign({},history.state,{gsuiScroll:[window.scrollX,window.scrollY]}),'')}catch(_){}}
+  function restoreScroll(){var p=history.state&&history.state.gsuiScroll;window.scrollTo(p?p[0]:0,p?p[1]:0)}
+  // arrive finishes a navigation: focus on the main content and the new
+  // title read out. Back/forward also scroll as the entry left it; a reply
+  // that pushed a new entry (SetLocation) passes keepScroll.
+  function arrive(keepScroll){
+    if(!keepScroll)restoreScroll();
+    var c=document.getElementById('__content__')||document.querySelector('main,[role=main]');
+    if(c){if(!c.hasAttribute('tabindex')){c.setAttribute('tabindex','-1');c.style.outline='none'}try{c.focus({preventScroll:true})}catch(_){}}
+    announce(document.title);
+  }
+  // announce reads text to screen readers through a polite live region.
+  function announce(text){
+    var r=document.getElementById('__gsui-live__');
+    if(!r){r=document.createElement('div');r.id='__gsui-live__';r.setAttribute('aria-live','polite');r.setAttribute('aria-atomic','true');
+      r.style.cssText='position:absolute;width:1px;height:1px;margin:-1px;padding:0;overflow:hidden;clip:rect(0,0,0,0);white-space:nowrap;border:0';document.body.appendChild(r)}
+    r.textContent='';setTimeout(function(){r.textContent=text},100);
+  }
+  window.addEventListener('scroll',function(){clearTimeout(scrollTimer);scrollTimer=setTimeout(saveScroll,150)},{passive:true});
+  window.addEventListener('pagehide',saveScroll);
+  if(history.state&&history.state.gsuiScroll)restoreScroll();
   window.addEventListener('popstate',function(){
+    var from=new URL(here);
+    // Hash-only moves stay on the page; the entry's scroll is restored
+    // here, since restoration is manual.
+    if(from.pathname===location.pathname&&from.search===location.search){here=location.href;restoreScroll();return}
+    if(!mayLeave()){history.pushState(null,'',here);return}
+    here=location.href;
     var id=++seq,msg=JSON.stringify({act:'__nav',data:{url:location.pathname+location.search},id:id});
-    inflight[id]=true;
+    inflight[id]=true;done[id]=arrive;
     showLoader();
     if(ready)ws.send(msg);else queue(msg);
   });
@@ -694,6 +1253,8 @@ var __ws=(function(){
       d[name]=el.checked;
     }else if(tag==='select'){
       d[name]=el.value;
+    }else if(el.hasAttribute('data-mask')||el.hasAttribute('data-money')||el.hasAttribute('data-number')){
+      d[name]=el.dataset.raw||'';
     }else{
       d[name]=el.value;
     }
@@ -716,28 +1277,40 @@ var __ws=(function(){
   });
   function queue(msg){if(q.length>=100){console.warn('gsui: WebSocket queue full; dropping message');return}q.push(msg)}
   return{
-    call:function(act,data,collect){
+    call:function(act,data,collect,opt){
       var d=Object.assign({},data||{});
       if(collect&&collect.length){
         collect.forEach(function(id){collectValue(id,d)});
       }
       var id=++seq,msg=JSON.stringify({act:act,data:d,id:id});
-      inflight[id]=true;
-      showLoader();
-      if(ready)ws.send(msg);else queue(msg);
+      // quiet calls show their own busy state instead of the page loader
+      if(!(opt&&opt.quiet)){inflight[id]=true;showLoader()}
+      if(opt&&typeof opt.undo==='function')undo[id]=opt.undo;
+      if(opt&&typeof opt.done==='function')done[id]=opt.done;
+      if(opt&&opt.json)jsonTo[id]=opt.json;
+      if(!fits(msg)){tooBig();fail(id);return}
+      if(opt&&opt.retry){retry[id]=msg;if(!ready)__offline.pending(Object.keys(retry).length)}
+      if(ready)ws.send(msg);else if(!(opt&&opt.retry))queue(msg);
     },
     callSilent:function(act,data){
       var d=Object.assign({},data||{});
       var msg=JSON.stringify({act:act,data:d});
+      if(!fits(msg)){tooBig();return}
       if(ready)ws.send(msg);else queue(msg);
     },
+    connected:function(){return ready},
+    // moved is called by SetLocation; arrive waits for the rest of the
+    // reply to render.
+    moved:function(){clearTimeout(movedTimer);movedTimer=setTimeout(function(){arrive(true)})},
+    csrf:function(){return csrf},
     notfound:function(id){
       var msg=JSON.stringify({act:'__notfound',data:{id:id}});
       if(ready)ws.send(msg);else queue(msg);
     }
   };
 })();
-if(__wsPre&&__wsPre.__q){__wsPre.__q.forEach(function(it){try{__ws[it[0]].apply(__ws,it[1])}catch(e){console.error('gsui: queued ws call failed:',e)}})}`
+if(__wsPre&&__wsPre.__q){__wsPre.__q.forEach(function(it){try{__ws[it[0]].apply(__ws,it[1])}catch(e){console.error('gsui: queued ws call failed:',e)}})}
+}`
 
 // ---------------------------------------------------------------------------
 // WebSocket handler
@@ -752,7 +1325,7 @@ type wsMessage struct {
 
 // wsHandshake accepts same-origin browser requests, configured origins, and
 // non-browser clients that do not send Origin.
-func (app *App) wsHandshake(_ *websocket.Config, r *http.Request) error {
+func (app *App) wsHandshake(r *http.Request) error {
 	origin := r.Header.Get("Origin")
 	if origin == "" {
 		return nil
@@ -772,28 +1345,84 @@ func (app *App) wsHandshake(_ *websocket.Config, r *http.Request) error {
 	return fmt.Errorf("origin %q is not allowed", origin)
 }
 
-// send serializes outbound frames for a connection shared by handler replies,
-// Push calls, and broadcasts.
-func (app *App) send(ws *websocket.Conn, s string) error {
+// serveWS upgrades requests that pass wsHandshake and serves the
+// connection. Messages are compressed with permessage-deflate when the
+// browser supports it, unless Compression is off.
+func (app *App) serveWS(w http.ResponseWriter, r *http.Request) {
+	if err := app.wsHandshake(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	app.mu.RLock()
+	mode := websocket.CompressionNoContextTakeover
+	if app.noCompress {
+		mode = websocket.CompressionDisabled
+	}
+	app.mu.RUnlock()
+	// Origins were checked above, against AllowedOrigins. The CSRF token
+	// subprotocol is echoed, as browsers require, and checked once the
+	// connection is open: a close code is the only answer a page's
+	// WebSocket can see, and it reloads the page on this one.
+	opts := &websocket.AcceptOptions{InsecureSkipVerify: true, CompressionMode: mode}
+	proto := wsCSRFProtocol(r)
+	if proto != "" {
+		opts.Subprotocols = []string{proto}
+	}
+	conn, err := websocket.Accept(w, r, opts)
+	if err != nil {
+		app.log().Debugf("ws accept: %v", err)
+		return
+	}
+	if app.csrfEnabled() && !app.validCSRF(r, strings.TrimPrefix(proto, csrfProtocol)) {
+		app.log().Debugf("ws connection without a valid CSRF token; closing")
+		conn.Close(wsCloseCSRF, "invalid CSRF token")
+		return
+	}
+	app.handleWS(&socket{conn: conn, req: r})
+}
+
+// send writes one message to a connection shared by handler replies, Push
+// calls, and broadcasts; the connection orders concurrent writes.
+func (app *App) send(ws *socket, s string) error {
 	app.mu.RLock()
-	st, ok := app.connStates[ws]
+	_, ok := app.connStates[ws]
 	app.mu.RUnlock()
 	if !ok {
 		return fmt.Errorf("connection is closed")
 	}
-	st.writeMu.Lock()
-	defer st.writeMu.Unlock()
-	return websocket.Message.Send(ws, s)
+	ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+	err := ws.conn.Write(ctx, websocket.MessageText, []byte(s))
+	cancel()
+	if m := app.collecting(); m != nil {
+		m.wsSend(err != nil)
+	}
+	return err
 }
 
+// wsPong answers a client heartbeat. The client recognizes it and does not
+// execute it.
+const wsPong = `{"__p":1}`
+
+// wsReply wraps the JS answering the tracked call id.
 func wsReply(id int64, js string) string {
+	return wsEnvelope(id, js, false)
+}
+
+// wsFailReply is wsReply for a call that failed; the client undoes the
+// call's optimistic update (see Action.Optimistic) before running js.
+func wsFailReply(id int64, js string) string {
+	return wsEnvelope(id, js, true)
+}
+
+func wsEnvelope(id int64, js string, failed bool) string {
 	b, err := json.Marshal(struct {
 		Reply int64  `json:"__r"`
 		ID    int64  `json:"id"`
 		JS    string `json:"js"`
-	}{Reply: 1, ID: id, JS: js})
+		Err   bool   `json:"err,omitempty"`
+	}{Reply: 1, ID: id, JS: js, Err: failed})
 	if err != nil {
-		log.Printf("gsui: marshal WebSocket reply: %v", err)
+		defaultLogger.Errorf("marshal WebSocket reply: %v", err)
 		return `{"__r":1,"id":0,"js":""}`
 	}
 	return string(b)
@@ -801,7 +1430,7 @@ func wsReply(id int64, js string) string {
 
 // cancelConn cancels the push context for a connection and creates a fresh
 // one. Any goroutine holding the old context will see ctx.pushCtx.Err() != nil.
-func (app *App) cancelConn(ws *websocket.Conn) {
+func (app *App) cancelConn(ws *socket) {
 	app.mu.Lock()
 	defer app.mu.Unlock()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -815,7 +1444,7 @@ func (app *App) cancelConn(ws *websocket.Conn) {
 }
 
 // pushCtxForConn returns the current push context for a connection.
-func (app *App) pushCtxForConn(ws *websocket.Conn) context.Context {
+func (app *App) pushCtxForConn(ws *socket) context.Context {
 	app.mu.RLock()
 	defer app.mu.RUnlock()
 	if st, ok := app.connStates[ws]; ok {
@@ -824,12 +1453,19 @@ func (app *App) pushCtxForConn(ws *websocket.Conn) context.Context {
 	return context.Background()
 }
 
-func (app *App) handleWS(ws *websocket.Conn) {
+func (app *App) handleWS(ws *socket) {
 	// Register client and create initial push context
 	pushCtx, pushCancel := context.WithCancel(context.Background())
+	sid := requestSessionID(ws.Request())
 	app.mu.Lock()
 	app.clients[ws] = true
-	app.connStates[ws] = &connState{ctx: pushCtx, cancel: pushCancel}
+	app.connStates[ws] = &connState{ctx: pushCtx, cancel: pushCancel, sid: sid}
+	if sid != "" {
+		if app.sessions[sid] == nil {
+			app.sessions[sid] = make(map[*socket]bool)
+		}
+		app.sessions[sid][ws] = true
+	}
 	app.mu.Unlock()
 
 	defer func() {
@@ -839,24 +1475,54 @@ func (app *App) handleWS(ws *websocket.Conn) {
 			delete(app.connStates, ws)
 		}
 		delete(app.clients, ws)
+		if conns := app.sessions[sid]; conns != nil {
+			delete(conns, ws)
+			if len(conns) == 0 {
+				delete(app.sessions, sid)
+			}
+		}
 		app.mu.Unlock()
-		ws.Close()
+		app.releaseSession(sid)
+		ws.conn.CloseNow()
 	}()
 
+	app.mu.RLock()
+	stale := app.wsStale
+	app.mu.RUnlock()
+	limit := app.bodyLimit("/__ws")
+	ws.conn.SetReadLimit(limit)
+
 	for {
-		var raw string
-		err := websocket.Message.Receive(ws, &raw)
+		// Any inbound message, including the client heartbeat, proves the
+		// connection is alive; silence beyond stale drops it.
+		readCtx, cancelRead := context.WithTimeout(ws.req.Context(), stale)
+		_, data, err := ws.conn.Read(readCtx)
+		cancelRead()
 		if err != nil {
-			if err != io.EOF {
-				log.Printf("gsui: ws read error: %v", err)
+			switch status := websocket.CloseStatus(err); {
+			case errors.Is(err, context.DeadlineExceeded):
+				app.log().Warnf("ws connection stale for %v; closing", stale)
+			case errors.Is(err, websocket.ErrMessageTooBig):
+				app.log().Warnf("ws message over %d bytes; closing", limit)
+			case status != websocket.StatusNormalClosure && status != websocket.StatusGoingAway:
+				app.log().Debugf("ws read error: %v", err)
 			}
 			return
 		}
+		raw := string(data)
 
 		// Parse the incoming message
 		var msg wsMessage
 		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
-			log.Printf("gsui: invalid WebSocket message: %s", raw)
+			app.log().Warnf("invalid WebSocket message: %s", raw)
+			continue
+		}
+
+		if msg.Act == "__ping" {
+			if err := app.send(ws, wsPong); err != nil {
+				app.log().Warnf("ws send error: %v", err)
+				return
+			}
 			continue
 		}
 
@@ -868,10 +1534,10 @@ func (app *App) handleWS(ws *websocket.Conn) {
 		if !ok {
 			errJS := Notify("error", fmt.Sprintf("Unknown action: %s", msg.Act))
 			if msg.ID != 0 {
-				errJS = wsReply(msg.ID, errJS)
+				errJS = wsFailReply(msg.ID, errJS)
 			}
 			if err := app.send(ws, errJS); err != nil {
-				log.Printf("gsui: ws send error: %v", err)
+				app.log().Warnf("ws send error: %v", err)
 				return
 			}
 			continue
@@ -885,19 +1551,51 @@ func (app *App) handleWS(ws *websocket.Conn) {
 			wsConn:     ws,
 			wsData:     msg.Data,
 			app:        app,
+			sessionID:  sid,
+			requestID:  newRequestID(),
 			pushCtx:    app.pushCtxForConn(ws),
 		}
+		if !strings.HasPrefix(msg.Act, "__") && app.isLimited(ctx) {
+			errJS := Notify("error", "Too many requests, slow down")
+			if msg.ID != 0 {
+				errJS = wsFailReply(msg.ID, errJS)
+			}
+			if err := app.send(ws, errJS); err != nil {
+				app.log().Warnf("ws send error: %v", err)
+				return
+			}
+			continue
+		}
+		cancel := app.bindCtx(ctx, ws.Request().Context())
+		app.loadSession(ctx)
 
 		// Execute handler -> get JS string (recover from panics)
+		start := time.Now()
+		outcome := "ok"
 		jsResponse := func() (resp string) {
 			defer func() {
 				if r := recover(); r != nil {
-					log.Printf("gsui: panic in action %q: %v\n%s", msg.Act, r, debug.Stack())
+					ctx.log().Errorf("panic in action %q: %v\n%s", msg.Act, r, debug.Stack())
 					resp = Notify("error", "Server error")
+					outcome = "panic"
 				}
 			}()
 			return handler(ctx)
 		}()
+		app.logAction(ctx, msg.Act, outcome, start)
+		app.saveSession(ctx)
+		cancel()
+
+		// Data replies go to the caller as they are, without page updates.
+		if ctx.json != nil && outcome == "ok" {
+			if msg.ID != 0 {
+				if err := app.send(ws, wsJSONReply(msg.ID, ctx.json)); err != nil {
+					app.log().Warnf("ws send error: %v", err)
+					return
+				}
+			}
+			continue
+		}
 
 		// Prepend any per-page CSS/JS injection from ctx.HeadCSS()/ctx.HeadJS()
 		var prefix string
@@ -914,12 +1612,14 @@ func (app *App) handleWS(ws *websocket.Conn) {
 		}
 
 		// Tracked requests always receive an envelope, including empty JS.
-		if msg.ID != 0 {
+		if msg.ID != 0 && outcome == "panic" {
+			jsResponse = wsFailReply(msg.ID, jsResponse)
+		} else if msg.ID != 0 {
 			jsResponse = wsReply(msg.ID, jsResponse)
 		}
 		if jsResponse != "" {
 			if err := app.send(ws, jsResponse); err != nil {
-				log.Printf("gsui: ws send error: %v", err)
+				app.log().Warnf("ws send error: %v", err)
 				return
 			}
 		}
@@ -932,16 +1632,26 @@ func (app *App) handleWS(ws *websocket.Conn) {
 
 // Context carries request data for both page renders and WS action calls.
 type Context struct {
-	Request    *http.Request
-	Session    map[string]any
-	PathParams map[string]string
-	Query      map[string]string
-	wsConn     *websocket.Conn
-	wsData     map[string]any
-	app        *App
-	pushCtx    context.Context // cancelled when client navigates away or reports element not found
-	headCSS    []string        // per-page <style>/<link> tags collected via ctx.HeadCSS()
-	headJS     []string        // per-page <script> blocks collected via ctx.HeadJS()
+	Request       *http.Request
+	Session       map[string]any
+	PathParams    map[string]string
+	Query         map[string]string
+	wsConn        *socket
+	wsData        map[string]any
+	app           *App
+	sessionID     string
+	sessionMint   bool            // a page render that may still issue a session, see useSession
+	sessionNew    bool            // sessionID was minted here and its cookie must be sent
+	sessionLoaded bool            // store held data for this session when loaded
+	sessionErr    error           // store error from loading the session, see SessionErr
+	requestID     string          // see RequestID
+	sessionBase   sessionSnapshot // JSON of each session value as loaded, see saveSession
+	pushCtx       context.Context // cancelled when client navigates away or reports element not found
+	headCSS       []string        // per-page <style>/<link> tags collected via ctx.HeadCSS()
+	headJS        []headScript    // per-page <script> blocks collected via ctx.HeadJS()
+	nonce         string          // CSP nonce of this page load, see App.StrictCSP
+	json          *jsonReply      // data answered with ctx.JSON, nil for JS
+	ctx           context.Context // see Ctx
 }
 
 // WsData returns the raw WebSocket data map. Useful for passing to
@@ -950,6 +1660,25 @@ func (ctx *Context) WsData() map[string]any {
 	return ctx.wsData
 }
 
+// Action registers fn under id on the app and returns an Action that calls
+// it. Components that are instances (a struct with handler methods) use it
+// to bind their methods while rendering, with an id that names the
+// instance, so two instances never share a handler:
+//
+//	func (c *Cart) Render(ctx *ui.Context) *ui.Node {
+//		return ui.Button().Text("Add").OnClick(ctx.Action("cart."+c.ID+".add", c.Add))
+//	}
+//
+// Registering the same id again replaces the handler, so re-rendering is
+// safe; ids must be unique across the whole app. Each id stays registered
+// until the app stops, so derive ids from a bounded set of instances.
+func (ctx *Context) Action(id string, fn ActionHandler) *Action {
+	if ctx.app != nil {
+		ctx.app.Action(id, fn)
+	}
+	return &Action{Name: id}
+}
+
 // HeadCSS registers external stylesheets and/or inline CSS rules for the
 // current page. On a full page load the tags are injected into the HTML
 // <head> server-side (instant, no JS needed). On SPA navigations (WS
@@ -968,7 +1697,7 @@ func (ctx *Context) WsData() map[string]any {
 func (ctx *Context) HeadCSS(urls []string, css string) {
 	for _, u := range urls {
 		ctx.headCSS = append(ctx.headCSS,
-			fmt.Sprintf(`<link rel="stylesheet" href="%s">`, u))
+			fmt.Sprintf(`<link rel="stylesheet" href="%s">`, html.EscapeString(u)))
 	}
 	if css != "" {
 		ctx.headCSS = append(ctx.headCSS,
@@ -994,26 +1723,71 @@ func (ctx *Context) HeadCSS(urls []string, css string) {
 //	`)
 func (ctx *Context) HeadJS(code string) {
 	if code != "" {
-		ctx.headJS = append(ctx.headJS, code)
+		ctx.headJS = append(ctx.headJS, headScript{code: code})
 	}
 }
 
+// HeadJSOnce is HeadJS for a block that must run at most once per
+// document, such as one adding document-wide event listeners: a block
+// that already ran, on the first load or an earlier navigation, is
+// skipped. Blocks are told apart by content.
+//
+//	ctx.HeadJSOnce(`document.addEventListener('keydown', onShortcut)`)
+func (ctx *Context) HeadJSOnce(code string) {
+	if code != "" {
+		ctx.headJS = append(ctx.headJS, headScript{code: code, once: true})
+	}
+}
+
+// headScript is a block registered with HeadJS or HeadJSOnce.
+type headScript struct {
+	code string
+	once bool
+}
+
+// headID names a HeadCSS or HeadJSOnce block by its content, so the client can
+// tell whether a navigation brings one it already has.
+func headID(block string) string {
+	sum := sha256.Sum256([]byte(block))
+	return hex.EncodeToString(sum[:6])
+}
+
 // cssHeadHTML returns the collected per-page CSS as raw HTML for <head>.
+// Inline styles carry their headID for cssInjectJS.
 func (ctx *Context) cssHeadHTML() string {
-	return strings.Join(ctx.headCSS, "\n")
+	tags := make([]string, len(ctx.headCSS))
+	for i, tag := range ctx.headCSS {
+		if inner, ok := strings.CutPrefix(tag, "<style>"); ok {
+			tag = `<style data-gsui-head="` + headID(strings.TrimSuffix(inner, "</style>")) + `">` + inner
+		}
+		tags[i] = tag
+	}
+	return strings.Join(tags, "\n")
 }
 
-// jsHeadHTML returns the collected per-page JS as a <script> block for <head>.
+// jsHeadHTML returns the collected per-page JS as a <script> block for <head>,
+// marking HeadJSOnce blocks as run for jsInjectJS. The blocks stay at the
+// top level of the script, so their function declarations are global.
 func (ctx *Context) jsHeadHTML() string {
 	if len(ctx.headJS) == 0 {
 		return ""
 	}
-	return "<script>" + strings.Join(ctx.headJS, "\n") + "</script>"
+	var js strings.Builder
+	js.WriteString("<script>")
+	code := make([]string, len(ctx.headJS))
+	for i, b := range ctx.headJS {
+		if b.once {
+			js.WriteString("(window.__gsuiHead=window.__gsuiHead||{})['" + headID(b.code) + "']=1;")
+		}
+		code[i] = b.code
+	}
+	js.WriteString("\n" + strings.Join(code, "\n") + "</script>")
+	return js.String()
 }
 
 // cssInjectJS returns JS code that injects the per-page CSS into <head>
 // at runtime (used during SPA/WS navigations). External links are
-// deduplicated by href.
+// deduplicated by href, inline styles by headID.
 func (ctx *Context) cssInjectJS() string {
 	if len(ctx.headCSS) == 0 {
 		return ""
@@ -1031,24 +1805,29 @@ func (ctx *Context) cssInjectJS() string {
 			if end < 0 {
 				continue
 			}
-			href := tag[start : start+end]
-			eu := escJS(href)
+			// The href was HTML-escaped for the tag; compare the raw URL via
+			// getAttribute rather than a selector it could break out of.
+			eu := escJS(html.UnescapeString(tag[start : start+end]))
 			fmt.Fprintf(&js,
-				"if(!document.querySelector('link[href=\\'%s\\']')){"+
+				"if(!Array.prototype.some.call(document.querySelectorAll('link[rel=stylesheet]'),function(x){return x.getAttribute('href')==='%s'})){"+
 					"var l=document.createElement('link');"+
-					"l.rel='stylesheet';l.href='%s';"+
+					"l.rel='stylesheet';l.setAttribute('href','%s');"+
 					"document.head.appendChild(l);}",
 				eu, eu,
 			)
 		} else if after, ok := strings.CutPrefix(tag, "<style>"); ok {
-			// Extract CSS content between <style> and </style>
+			// Extract CSS content between <style> and </style>; a style
+			// already in <head> is not added again.
 			inner := after
 			inner = strings.TrimSuffix(inner, "</style>")
+			id := headID(inner)
 			fmt.Fprintf(&js,
-				"var _s=document.createElement('style');"+
+				"if(!document.querySelector('style[data-gsui-head=\"%s\"]')){"+
+					"var _s=document.createElement('style');"+
+					"_s.setAttribute('data-gsui-head','%s');"+
 					"_s.textContent='%s';"+
-					"document.head.appendChild(_s);",
-				escJS(inner),
+					"document.head.appendChild(_s);}",
+				id, id, escJS(inner),
 			)
 		}
 	}
@@ -1056,20 +1835,18 @@ func (ctx *Context) cssInjectJS() string {
 }
 
 // jsInjectJS returns the collected per-page JS as raw code to prepend
-// to a WS response (used during SPA navigations).
+// to a WS response (used during SPA navigations). A HeadJSOnce block runs
+// only if its headID is not marked yet.
 func (ctx *Context) jsInjectJS() string {
-	return strings.Join(ctx.headJS, "\n")
-}
-
-func (ctx *Context) Body(target any) error {
-	if ctx.wsData == nil {
-		return nil
-	}
-	b, err := json.Marshal(ctx.wsData)
-	if err != nil {
-		return err
+	blocks := make([]string, len(ctx.headJS))
+	for i, b := range ctx.headJS {
+		blocks[i] = b.code
+		if b.once {
+			id := headID(b.code)
+			blocks[i] = "if(!(window.__gsuiHead=window.__gsuiHead||{})['" + id + "']){__gsuiHead['" + id + "']=1;\n" + b.code + "\n}"
+		}
 	}
-	return json.Unmarshal(b, target)
+	return strings.Join(blocks, "\n")
 }
 
 // Push sends a JS string to THIS client connection immediately.
@@ -1098,6 +1875,97 @@ func (ctx *Context) Push(js string) error {
 	return ctx.app.send(ctx.wsConn, js)
 }
 
+// PushBatch sends several JS updates to this client in one frame, see
+// Batch.
+func (ctx *Context) PushBatch(js ...string) error {
+	return ctx.Push(Batch(js...))
+}
+
+// Replace pushes node to this client in place of the element with ID id.
+func (ctx *Context) Replace(id string, node *Node) error {
+	return ctx.Push(node.ToJSReplace(id))
+}
+
+// Inner pushes node to this client as the only content of the element with
+// ID id.
+func (ctx *Context) Inner(id string, node *Node) error {
+	return ctx.Push(node.ToJSInner(id))
+}
+
+// Append pushes node to this client as the last child of the element with
+// ID id, e.g. a new chat message or log line:
+//
+//	ctx.Append("log", ui.Div().Text(line))
+func (ctx *Context) Append(id string, node *Node) error {
+	return ctx.Push(node.ToJSAppend(id))
+}
+
+// Prepend pushes node to this client as the first child of the element
+// with ID id.
+func (ctx *Context) Prepend(id string, node *Node) error {
+	return ctx.Push(node.ToJSPrepend(id))
+}
+
+// Param returns the value of the named path wildcard of the matched page
+// route ("" when absent). For "/users/:id" or "/users/{id}", ctx.Param("id")
+// on /users/42 returns "42".
+func (ctx *Context) Param(name string) string {
+	return ctx.PathParams[name]
+}
+
+// QueryInt returns the named query parameter as an int, or def when it is
+// missing or not a valid integer.
+func (ctx *Context) QueryInt(name string, def int) int {
+	v, ok := ctx.Query[name]
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// QueryIntRange is QueryInt clamped to [lo, hi], for values such as page
+// numbers and page sizes that must stay within bounds.
+func (ctx *Context) QueryIntRange(name string, def, lo, hi int) int {
+	return min(max(ctx.QueryInt(name, def), lo), hi)
+}
+
+// QueryBool reports whether the named query parameter is set to a truthy
+// value: "1", "true", "yes" or "on" (any case), or present without a value
+// as in "?debug". Everything else, including a missing parameter, is false.
+func (ctx *Context) QueryBool(name string) bool {
+	v, ok := ctx.Query[name]
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// SessionID returns the browser session the request belongs to, taken from
+// the session cookie. A page render of a browser without one issues it with
+// the page. It is empty for actions of clients that carry no session cookie
+// (e.g. non-browser WebSocket clients).
+func (ctx *Context) SessionID() string {
+	return ctx.useSession()
+}
+
+// PushSession sends a JS string to every WebSocket connection and SSE stream
+// of session sid — all tabs of that browser — and to no one else. Use it
+// for per-user live updates; ctx.Push only reaches the calling connection.
+func (ctx *Context) PushSession(sid, js string) error {
+	if ctx.app == nil {
+		return fmt.Errorf("no app context")
+	}
+	return ctx.app.PushSession(sid, js)
+}
+
 // Broadcast sends a JS string to ALL connected WebSocket clients.
 func (ctx *Context) Broadcast(js string) {
 	if ctx.app != nil {
@@ -1105,21 +1973,22 @@ func (ctx *Context) Broadcast(js string) {
 	}
 }
 
-// Broadcast sends a JS string to ALL connected WebSocket clients.
-// Can be called from anywhere (background goroutines, HTTP handlers, etc.)
-// without needing a Context.
+// Broadcast sends a JS string to ALL connected WebSocket clients and SSE
+// streams. Can be called from anywhere (background goroutines, HTTP
+// handlers, etc.) without needing a Context.
 func (app *App) Broadcast(js string) {
 	app.mu.RLock()
-	clients := make([]*websocket.Conn, 0, len(app.clients))
+	clients := make([]*socket, 0, len(app.clients))
 	for conn := range app.clients {
 		clients = append(clients, conn)
 	}
 	app.mu.RUnlock()
 	for _, conn := range clients {
 		if err := app.send(conn, js); err != nil {
-			log.Printf("gsui: broadcast send error: %v", err)
+			app.log().Warnf("broadcast send error: %v", err)
 		}
 	}
+	app.sendSSE("", js)
 }
 
 // ---------------------------------------------------------------------------
@@ -1188,3 +2057,30 @@ func (r *Response) Back() *Response {
 func (r *Response) Build() string {
 	return strings.Join(r.parts, "")
 }
+
+// PushSession sends a JS string to every WebSocket connection and SSE stream
+// of session sid. Returns an error when the session has no open connection.
+// Like Broadcast, it can be called from anywhere without a Context.
+func (app *App) PushSession(sid, js string) error {
+	if sid == "" {
+		return fmt.Errorf("no session")
+	}
+	app.mu.RLock()
+	conns := make([]*socket, 0, len(app.sessions[sid]))
+	for conn := range app.sessions[sid] {
+		conns = append(conns, conn)
+	}
+	app.mu.RUnlock()
+	n := app.sendSSE(sid, js)
+	for _, conn := range conns {
+		if err := app.send(conn, js); err != nil {
+			app.log().Warnf("session send error: %v", err)
+			continue
+		}
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("session %s has no open connection", sid)
+	}
+	return nil
+}
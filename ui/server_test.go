@@ -0,0 +1,1175 @@
+package ui
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// WebSocket heartbeat tests
+// ---------------------------------------------------------------------------
+
+func TestWSConfigValidatesIntervals(t *testing.T) {
+	app := NewApp()
+	if err := app.WSConfig(30*time.Second, 10*time.Second); err == nil {
+		t.Fatal("expected error when stale <= ping")
+	}
+	if err := app.WSConfig(0, time.Second); err == nil {
+		t.Fatal("expected error for zero ping interval")
+	}
+	if err := app.WSConfig(10*time.Second, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "http://example.test/", nil))
+	expect(t, rr.Body.String(), "window.__gsuiWS={ping:10000,stale:30000,retry:500,retryMax:10000,max:10485760,")
+}
+
+func TestWSReconnectValidatesAndPublishesDelays(t *testing.T) {
+	app := NewApp()
+	if err := app.WSReconnect(0, time.Second); err == nil {
+		t.Fatal("expected error for zero initial delay")
+	}
+	if err := app.WSReconnect(2*time.Second, time.Second); err == nil {
+		t.Fatal("expected error when max < initial")
+	}
+	if err := app.WSReconnect(time.Second, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "http://example.test/", nil))
+	expect(t, rr.Body.String(), "retry:1000,retryMax:60000,")
+}
+
+func TestWSPingAnsweredAndStaleConnectionDropped(t *testing.T) {
+	app := NewApp()
+	if err := app.WSConfig(20*time.Millisecond, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	ws, err := dialWS(server, "/__ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	if err := ws.send(`{"act":"__ping"}`); err != nil {
+		t.Fatal(err)
+	}
+	var raw string
+	if err := ws.receive(&raw); err != nil || raw != wsPong {
+		t.Fatalf("ping reply = %q, %v", raw, err)
+	}
+
+	// Stay silent past the stale timeout: the server must hang up.
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := ws.receive(&raw); err == nil {
+		t.Fatalf("expected closed connection, got frame %q", raw)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Head injection tests
+// ---------------------------------------------------------------------------
+
+func TestHeadBlocksAreInjectedOncePerDocument(t *testing.T) {
+	ctx := &Context{}
+	ctx.HeadCSS(nil, ".hero{color:red}")
+	ctx.HeadJS("window.setup()")
+	ctx.HeadJSOnce("document.addEventListener('keydown',onKey)")
+	id := headID("document.addEventListener('keydown',onKey)")
+
+	page := ctx.jsHeadHTML()
+	expect(t, page, "<script>(window.__gsuiHead=window.__gsuiHead||{})['"+id+"']=1;\nwindow.setup()\ndocument.addEventListener('keydown',onKey)</script>")
+	expect(t, ctx.jsInjectJS(), "window.setup()\nif(!(window.__gsuiHead=window.__gsuiHead||{})['"+id+"']){__gsuiHead['"+id+"']=1;\ndocument.addEventListener('keydown',onKey)\n}")
+
+	styleID := headID(".hero{color:red}")
+	expect(t, ctx.cssHeadHTML(), `<style data-gsui-head="`+styleID+`">.hero{color:red}</style>`)
+	expect(t, ctx.cssInjectJS(), `if(!document.querySelector('style[data-gsui-head="`+styleID+`"]'))`)
+}
+
+// ---------------------------------------------------------------------------
+// History navigation tests
+// ---------------------------------------------------------------------------
+
+func TestPopstateRestoresScrollAndSkipsHashChanges(t *testing.T) {
+	expect(t, wsClientJS, "history.scrollRestoration='manual'")
+	expect(t, wsClientJS, "{gsuiScroll:[window.scrollX,window.scrollY]}")
+	expect(t, wsClientJS, "if(from.pathname===location.pathname&&from.search===location.search){here=location.href;restoreScroll();return}")
+	expect(t, wsClientJS, "inflight[id]=true;done[id]=arrive;")
+}
+
+func TestPrefetchOnHoverIsOptIn(t *testing.T) {
+	app := NewApp()
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	get := func() string {
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		return rr.Body.String()
+	}
+	notExpect(t, get(), "__gsuiPrefetchInit")
+
+	app.PrefetchOnHover(true)
+	body := get()
+	expect(t, body, "window.__gsuiPrefetchInit=true;")
+	expect(t, body, "c.saveData")
+	expect(t, body, "l.rel='prefetch'")
+}
+
+func TestPrefetchLeavesFlashAndSessionAlone(t *testing.T) {
+	app := NewApp()
+	store := NewMemoryStore()
+	app.SessionStore(store)
+	app.Page("/set", func(ctx *Context) *Node {
+		ctx.Session["seen"] = true
+		return Div()
+	})
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	get := func(path, sid string, prefetch bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		if sid != "" {
+			r.AddCookie(&http.Cookie{Name: sessionCookie, Value: sid})
+		}
+		if prefetch {
+			r.Header.Set("Sec-Purpose", "prefetch")
+		}
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, r)
+		return rr
+	}
+
+	rr := get("/set", "", true)
+	if rr.Header().Get("Set-Cookie") != "" || rr.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("prefetch without session: Set-Cookie=%q Cache-Control=%q", rr.Header().Get("Set-Cookie"), rr.Header().Get("Cache-Control"))
+	}
+
+	sid := newSessionID()
+	store.Set(sid, map[string]any{flashKey: []any{map[string]any{"kind": "success", "message": "Saved"}}})
+	rr = get("/", sid, true)
+	notExpect(t, rr.Body.String(), "Saved")
+	if rr.Header().Get("Cache-Control") != "no-store" {
+		t.Fatal("prefetch with a waiting flash must not be cached")
+	}
+	expect(t, get("/", sid, false).Body.String(), "Saved")
+	if rr = get("/", sid, true); rr.Header().Get("Cache-Control") != "" {
+		t.Fatalf("plain prefetch: Cache-Control=%q", rr.Header().Get("Cache-Control"))
+	}
+}
+
+func TestBackForwardFocusesContentAndAnnouncesTitle(t *testing.T) {
+	expect(t, wsClientJS, "document.getElementById('__content__')||document.querySelector('main,[role=main]')")
+	expect(t, wsClientJS, "c.focus({preventScroll:true})")
+	expect(t, wsClientJS, "r.setAttribute('aria-live','polite')")
+	expect(t, wsClientJS, "announce(document.title)")
+	expect(t, wsClientJS, "function arrive(keepScroll){\n    if(!keepScroll)restoreScroll();")
+	expect(t, wsClientJS, "movedTimer=setTimeout(function(){arrive(true)})")
+	expect(t, SetLocation("/next"), "history.pushState(null,'','/next');window.__ws&&__ws.moved&&__ws.moved();")
+}
+
+// ---------------------------------------------------------------------------
+// Query helper tests
+// ---------------------------------------------------------------------------
+
+func TestContextQueryHelpers(t *testing.T) {
+	ctx := &Context{Query: map[string]string{"page": " 3 ", "size": "500", "bad": "x", "debug": "", "on": "Yes", "off": "0"}}
+
+	if got := ctx.QueryInt("page", 1); got != 3 {
+		t.Errorf("QueryInt(page) = %d", got)
+	}
+	if got := ctx.QueryInt("bad", 7); got != 7 {
+		t.Errorf("QueryInt(bad) = %d", got)
+	}
+	if got := ctx.QueryInt("missing", 9); got != 9 {
+		t.Errorf("QueryInt(missing) = %d", got)
+	}
+	if got := ctx.QueryIntRange("size", 20, 1, 100); got != 100 {
+		t.Errorf("QueryIntRange(size) = %d", got)
+	}
+	for name, want := range map[string]bool{"debug": true, "on": true, "off": false, "bad": false, "missing": false} {
+		if got := ctx.QueryBool(name); got != want {
+			t.Errorf("QueryBool(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Static asset tests
+// ---------------------------------------------------------------------------
+
+func TestAssetsDirServesFilesWithCacheControl(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "css"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "css", "site.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp()
+	app.AssetsDir("/static", dir, time.Hour)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "http://example.test"+path, nil))
+		return rr
+	}
+	rr := get("/static/css/site.css")
+	if rr.Code != 200 || rr.Body.String() != "body{}" {
+		t.Fatalf("file: %d %q", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Fatalf("Cache-Control = %q", got)
+	}
+	expect(t, rr.Header().Get("Content-Type"), "text/css")
+	for _, path := range []string{"/static/css/", "/static/missing.js", "/static/%2e%2e/go.mod"} {
+		if code := get(path).Code; code != 404 {
+			t.Errorf("GET %s = %d, want 404", path, code)
+		}
+	}
+}
+
+func TestAssetsRevalidateWithETag(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log(1)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp()
+	app.Assets(os.DirFS(dir), ".", "/assets/")
+	app.AssetsDir("/static/", dir, 0)
+
+	for _, path := range []string{"/assets/app.js", "/static/app.js"} {
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		etag := rr.Header().Get("ETag")
+		if rr.Code != 200 || etag == "" {
+			t.Fatalf("%s: %d ETag=%q", path, rr.Code, etag)
+		}
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("If-None-Match", etag)
+		rr = httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+			t.Fatalf("%s revalidation: %d", path, rr.Code)
+		}
+	}
+	req := httptest.NewRequest("GET", "/static/app.js", nil)
+	req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since: %d", rr.Code)
+	}
+}
+
+// streamFS hides Seek, like filesystems that decompress or download files.
+type streamFS struct{ fs.FS }
+
+type streamFile struct{ f fs.File }
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return streamFile{f}, nil
+}
+
+func (s streamFile) Stat() (fs.FileInfo, error) { return s.f.Stat() }
+func (s streamFile) Read(b []byte) (int, error) { return s.f.Read(b) }
+func (s streamFile) Close() error               { return s.f.Close() }
+
+func TestAssetsLogsInvalidDir(t *testing.T) {
+	app := NewApp()
+	lg := &levelLogger{}
+	app.Logger(lg)
+	app.Assets(os.DirFS(t.TempDir()), "../up", "/assets/")
+	expect(t, lg.String(), `ERROR assets: fs.Sub("../up")`)
+}
+
+func TestAssetsServeByteRanges(t *testing.T) {
+	media := bytes.Repeat([]byte("0123456789"), 400) // 4000 bytes
+	files := fstest.MapFS{"media/clip.mp4": {Data: media}}
+	app := NewApp()
+	app.Assets(files, "media", "/media/")
+	app.Assets(streamFS{files}, "media", "/stream/")
+	h := app.Handler()
+
+	for _, base := range []string{"/media/", "/stream/"} {
+		t.Run(base, func(t *testing.T) { testByteRanges(t, h, base+"clip.mp4", media) })
+	}
+}
+
+func testByteRanges(t *testing.T, h http.Handler, path string, media []byte) {
+	get := func(header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	full := get()
+	if full.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("Accept-Ranges = %q", full.Header().Get("Accept-Ranges"))
+	}
+	rr := get("Range", "bytes=1000-1009")
+	if rr.Code != http.StatusPartialContent || rr.Body.String() != "0123456789" ||
+		rr.Header().Get("Content-Range") != "bytes 1000-1009/4000" || rr.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("range: %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+	if rr := get("Range", "bytes=3990-", "If-Range", full.Header().Get("ETag")); rr.Code != http.StatusPartialContent || rr.Body.Len() != 10 {
+		t.Fatalf("If-Range with current ETag: %d", rr.Code)
+	}
+	if rr := get("Range", "bytes=0-9", "If-Range", `"stale"`); rr.Code != http.StatusOK || rr.Body.Len() != len(media) {
+		t.Fatalf("If-Range with stale ETag: %d", rr.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Compression tests
+// ---------------------------------------------------------------------------
+
+func TestCompressionGzipsLargeTextResponses(t *testing.T) {
+	app := NewApp()
+	big := strings.Repeat("hello gsui ", 500)
+	app.GET("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"` + big + `"`))
+	})
+	app.GET("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("tiny"))
+	})
+	app.GET("/png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(big))
+	})
+	h := app.Handler()
+
+	get := func(path, enc string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if enc != "" {
+			req.Header.Set("Accept-Encoding", enc)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/big", "gzip, deflate")
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip, got headers %v", rr.Header())
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if string(body) != `"`+big+`"` {
+		t.Fatal("decompressed body mismatch")
+	}
+	if rr := get("/big", "gzip;q=0"); rr.Header().Get("Content-Encoding") != "" {
+		t.Fatal("gzip;q=0 must disable compression")
+	}
+	if rr := get("/small", "gzip"); rr.Header().Get("Content-Encoding") != "" || rr.Body.String() != "tiny" {
+		t.Fatal("small bodies must be sent as-is")
+	}
+	if rr := get("/png", "gzip"); rr.Header().Get("Content-Encoding") != "" {
+		t.Fatal("images must not be compressed")
+	}
+
+	app.Compression(false)
+	if rr := get("/big", "gzip"); rr.Header().Get("Content-Encoding") != "" {
+		t.Fatal("Compression(false) must disable the middleware")
+	}
+}
+
+func TestThemeEmitsCSSVariables(t *testing.T) {
+	app := NewApp()
+	if css := app.themeCSS(); css != "" {
+		t.Fatalf("no theme should emit nothing, got %q", css)
+	}
+	app.Theme(ThemeConfig{Primary: "#0f766e", SurfaceDark: "#0c0a09", Text: "red;}</style><script>"})
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	body := rr.Body.String()
+	for _, want := range []string{"--gsui-primary:#0f766e;", "--gsui-info-bg:color-mix(", "--gsui-loading-bg-dark:var(--gsui-surface-dark);"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	if strings.Contains(body, "--gsui-text:") {
+		t.Error("unsafe color value must be dropped")
+	}
+}
+
+func TestTLSServerBoundsSlowClients(t *testing.T) {
+	srv := tlsServer(":443", http.NotFoundHandler())
+	if srv.ReadHeaderTimeout == 0 || srv.IdleTimeout == 0 {
+		t.Fatalf("timeouts = %v, %v; slow clients could hold connections", srv.ReadHeaderTimeout, srv.IdleTimeout)
+	}
+	if srv.ReadTimeout != 0 || srv.WriteTimeout != 0 {
+		t.Fatal("whole-request deadlines would cut WebSockets and SSE streams")
+	}
+}
+
+func TestTLSResponsesGetHSTSAndSecureCookies(t *testing.T) {
+	app := NewApp()
+	app.Page("/", func(ctx *Context) *Node { ctx.Session["seen"] = true; return Div() })
+	srv := httptest.NewTLSServer(app.Handler())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Strict-Transport-Security"); got != hstsHeader {
+		t.Fatalf("HSTS = %q", got)
+	}
+	if len(resp.Cookies()) == 0 {
+		t.Fatal("no session cookie")
+	}
+	for _, c := range resp.Cookies() {
+		if c.Name == sessionCookie && !c.Secure {
+			t.Fatal("session cookie over TLS must be Secure")
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Header().Get("Strict-Transport-Security") != "" {
+		t.Fatal("plain HTTP must not send HSTS")
+	}
+}
+
+func TestAppsInOneProcessAreIndependent(t *testing.T) {
+	a, b := NewApp(), NewApp()
+	a.Page("/", func(ctx *Context) *Node { return Div().Text("app-a") })
+	b.Page("/", func(ctx *Context) *Node { return Div().Text("app-b") })
+	b.Mux().HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+
+	get := func(app *App, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+	if body := get(a, "/").Body.String(); !strings.Contains(body, "app-a") || strings.Contains(body, "app-b") {
+		t.Fatal("app a rendered the wrong page")
+	}
+	if body := get(b, "/").Body.String(); !strings.Contains(body, "app-b") {
+		t.Fatal("app b rendered the wrong page")
+	}
+	if rr := get(b, "/health"); rr.Body.String() != "ok" {
+		t.Fatalf("Mux route: %d %q", rr.Code, rr.Body.String())
+	}
+	if rr := get(a, "/health"); rr.Body.String() == "ok" {
+		t.Fatal("routes must not leak between apps")
+	}
+}
+
+type testCounter struct{ id, label string }
+
+func (c *testCounter) Inc(ctx *Context) string { return c.label }
+
+func testTopLevelAction(ctx *Context) string { return "" }
+
+func TestCallableKeysSeparateClosuresAndInstances(t *testing.T) {
+	app := NewApp()
+	var names []string
+	for _, id := range []string{"1", "2"} {
+		names = append(names, app.Callable(func(ctx *Context) string { return "row" + id }, id).Name)
+	}
+	if names[0] == names[1] {
+		t.Fatal("keyed closures must get distinct names")
+	}
+	if again := app.Callable(func(ctx *Context) string { return "" }, "1").Name; again == names[0] {
+		t.Fatal("a different function literal must not reuse the name")
+	}
+	for i, n := range names {
+		if got := app.actions[n](&Context{}); got != "row"+[]string{"1", "2"}[i] {
+			t.Fatalf("%s called %q", n, got)
+		}
+	}
+
+	// Without a key, closures and method values would share one name.
+	for _, fn := range []ActionHandler{func(ctx *Context) string { return "" }, (&testCounter{}).Inc} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("keyless Callable(%s) must panic", funcName(fn))
+				}
+			}()
+			app.Callable(fn)
+		}()
+	}
+	if app.Callable(testTopLevelAction).Name != app.Callable(testTopLevelAction).Name {
+		t.Fatal("a plain function needs no key and keeps its name")
+	}
+
+	ctx := &Context{app: app}
+	a, b := &testCounter{"a", "A"}, &testCounter{"b", "B"}
+	actA, actB := ctx.Action("counter."+a.id, a.Inc), ctx.Action("counter."+b.id, b.Inc)
+	if app.actions[actA.Name](ctx) != "A" || app.actions[actB.Name](ctx) != "B" {
+		t.Fatal("instance methods must keep their own receivers")
+	}
+}
+
+func TestHandleRoutesByMethodWith405(t *testing.T) {
+	app := NewApp()
+	app.Handle("put", "/api/items/{id}", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("put " + r.PathValue("id"))) })
+	app.PATCH("/api/items/{id}", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("patch")) })
+	app.DELETE("/api/items/{id}", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	app.Page("/items", func(ctx *Context) *Node { return Div().Text("items") })
+	h := app.Handler()
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+	if rr := serve("PUT", "/api/items/7"); rr.Body.String() != "put 7" {
+		t.Fatalf("PUT: %d %q", rr.Code, rr.Body.String())
+	}
+	if rr := serve("DELETE", "/api/items/7"); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: %d", rr.Code)
+	}
+	rr := serve("GET", "/api/items/7")
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "DELETE, PATCH, PUT" {
+		t.Fatalf("GET on a PUT route: %d Allow=%q", rr.Code, rr.Header().Get("Allow"))
+	}
+	if rr := serve("POST", "/items"); rr.Code != http.StatusMethodNotAllowed || !strings.Contains(rr.Header().Get("Allow"), "GET") {
+		t.Fatalf("POST on a page: %d Allow=%q", rr.Code, rr.Header().Get("Allow"))
+	}
+	if rr := serve("GET", "/nowhere"); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown path: %d", rr.Code)
+	}
+	if rr := serve("GET", "/items"); !strings.Contains(rr.Body.String(), "items") {
+		t.Fatalf("page: %d", rr.Code)
+	}
+}
+
+func TestGroupAppliesPrefixAndMiddleware(t *testing.T) {
+	app := NewApp()
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next PageHandler) PageHandler {
+			return func(ctx *Context) *Node { order = append(order, name); return next(ctx) }
+		}
+	}
+	guard := func(next PageHandler) PageHandler {
+		return func(ctx *Context) *Node {
+			if ctx.Query["user"] == "" {
+				return Div().Text("please log in")
+			}
+			return next(ctx)
+		}
+	}
+	admin := app.Group("/admin/", tag("outer"), guard)
+	admin.Page("/users", func(ctx *Context) *Node { return Div().Text("user list") })
+	admin.Handle("DELETE", "/users/{id}", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("deleted " + r.PathValue("id"))) })
+	admin.Group("/reports", tag("inner")).Page("/daily", func(ctx *Context) *Node { return Div().Text("daily") })
+	h := app.Handler()
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+	if body := serve("GET", "/admin/users").Body.String(); !strings.Contains(body, "please log in") || strings.Contains(body, "user list") {
+		t.Fatal("guard did not stop the page")
+	}
+	if body := serve("GET", "/admin/users?user=ann").Body.String(); !strings.Contains(body, "user list") {
+		t.Fatal("guard blocked an allowed request")
+	}
+	if rr := serve("DELETE", "/admin/users/3"); rr.Code != http.StatusForbidden {
+		t.Fatalf("guarded Handle: %d", rr.Code)
+	}
+	if rr := serve("DELETE", "/admin/users/3?user=ann"); rr.Body.String() != "deleted 3" {
+		t.Fatalf("allowed Handle: %d %q", rr.Code, rr.Body.String())
+	}
+	order = nil
+	serve("GET", "/admin/reports/daily?user=ann")
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Fatalf("middleware order = %v", order)
+	}
+
+	// SPA navigation resolves the wrapped handler too
+	handler, _, ok := app.matchPage(httptest.NewRequest("GET", "/admin/users", nil))
+	if !ok || !strings.Contains(handler(&Context{Query: map[string]string{}}).ToJS(), "please log in") {
+		t.Fatal("navigation bypassed the group middleware")
+	}
+}
+
+// readOnlyStore fails every Set.
+type readOnlyStore struct{ *MemoryStore }
+
+func (readOnlyStore) Set(string, map[string]any) error { return errors.New("read-only replica") }
+
+func TestGroupHandleSavesSessionBeforeHandler(t *testing.T) {
+	app := NewApp()
+	app.SessionStore(readOnlyStore{NewMemoryStore()})
+	lg := &levelLogger{}
+	app.Logger(lg)
+	touch := func(next PageHandler) PageHandler {
+		return func(ctx *Context) *Node { ctx.Session["seen"] = true; return next(ctx) }
+	}
+	ran := false
+	app.Group("/api", touch).Handle("POST", "/items", func(w http.ResponseWriter, r *http.Request) { ran = true })
+
+	req := httptest.NewRequest("POST", "/api/items", nil)
+	req.Header.Set("Cookie", sessionCookie+"="+newSessionID())
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable || ran {
+		t.Fatalf("store failure: status %d, handler ran %v", rr.Code, ran)
+	}
+	expect(t, lg.String(), "read-only replica")
+}
+
+// lockedBuffer collects log output written from server goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAccessLogRecordsRequestsAndActions(t *testing.T) {
+	var out lockedBuffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	app := NewApp()
+	app.GET("/missing", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) })
+	app.Action("ping", func(ctx *Context) string { return "" })
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/missing?q=1", nil))
+	if out.String() != "" {
+		t.Fatalf("access log must be off by default: %q", out.String())
+	}
+
+	app.AccessLog(true)
+	rr = httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/missing?q=1", nil))
+	if !strings.Contains(out.String(), "gsui: GET /missing?q=1 404 ") || !strings.Contains(out.String(), "sid=-") {
+		t.Fatalf("request line = %q", out.String())
+	}
+
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+	sid := newSessionID()
+	ws := dialSession(t, server, sid)
+	ws.Close()
+	if line := out.String(); !strings.Contains(line, "gsui: ACT ping ok ") || !strings.Contains(line, "sid="+sessionTag(sid)) {
+		t.Fatalf("action line = %q", line)
+	}
+	if strings.Contains(out.String(), sid) {
+		t.Fatal("raw session IDs must not be logged")
+	}
+}
+
+// levelLogger records messages by level.
+type levelLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *levelLogger) add(level, format string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *levelLogger) Debugf(format string, args ...any) { l.add("DEBUG", format, args) }
+func (l *levelLogger) Infof(format string, args ...any)  { l.add("INFO", format, args) }
+func (l *levelLogger) Warnf(format string, args ...any)  { l.add("WARN", format, args) }
+func (l *levelLogger) Errorf(format string, args ...any) { l.add("ERROR", format, args) }
+
+func (l *levelLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+func TestLoggerReceivesAppMessagesByLevel(t *testing.T) {
+	var std lockedBuffer
+	log.SetOutput(&std)
+	defer log.SetOutput(os.Stderr)
+
+	app := NewApp()
+	lg := &levelLogger{}
+	app.Logger(lg)
+	app.AccessLog(true)
+	app.Page("/boom", func(ctx *Context) *Node { panic("db down") })
+	app.Action("ping", func(ctx *Context) string { return "" })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/boom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	ws := dialSession(t, server, newSessionID())
+	defer ws.Close()
+	ws.send("not json")
+	ws.send(`{"act":"ping","id":2}`)
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	out := lg.String()
+	expect(t, out, `ERROR panic in page "/boom": db down`)
+	expect(t, out, "INFO GET /boom 500 ")
+	expect(t, out, "INFO ACT ping ok ")
+	expect(t, out, "WARN invalid WebSocket message: not json")
+	notExpect(t, out, "gsui: ")
+	notExpect(t, std.String(), "/boom")
+}
+
+func TestUseSlogWritesStructuredRecords(t *testing.T) {
+	var out lockedBuffer
+	app := NewApp()
+	app.UseSlog(slog.New(slog.NewJSONHandler(&out, nil)))
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("boom", func(ctx *Context) string { panic("db down") })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	sid := newSessionID()
+	ws := dialSession(t, server, sid)
+	defer ws.Close()
+	ws.send(`{"act":"boom","id":2}`)
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	var rec struct {
+		Level   string `json:"level"`
+		Msg     string `json:"msg"`
+		Session string `json:"session"`
+		Request string `json:"request_id"`
+	}
+	if err := json.Unmarshal([]byte(strings.SplitN(out.String(), "\n", 2)[0]), &rec); err != nil {
+		t.Fatalf("record %q: %v", out.String(), err)
+	}
+	if rec.Level != "ERROR" || !strings.HasPrefix(rec.Msg, `panic in action "boom": db down`) || rec.Session != sessionTag(sid) || len(rec.Request) != 16 {
+		t.Fatalf("record = %+v", rec)
+	}
+}
+
+func TestRequestIDIsEchoedLoggedAndShown(t *testing.T) {
+	var out lockedBuffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	app := NewApp()
+	app.AccessLog(true)
+	var seen string
+	app.Page("/", func(ctx *Context) *Node { seen = ctx.RequestID(); return Div() })
+	app.Page("/boom", func(ctx *Context) *Node { panic("db down") })
+	get := func(path, id string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		if id != "" {
+			r.Header.Set("X-Request-Id", id)
+		}
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, r)
+		return rr
+	}
+
+	rr := get("/", "edge-42")
+	if seen != "edge-42" || rr.Header().Get("X-Request-Id") != "edge-42" {
+		t.Fatalf("incoming ID: ctx %q, header %q", seen, rr.Header().Get("X-Request-Id"))
+	}
+	expect(t, out.String(), "gsui: GET / 200 ")
+	expect(t, out.String(), "req=edge-42")
+
+	rr = get("/", "bad id\nforged log line")
+	if len(seen) != 16 || rr.Header().Get("X-Request-Id") != seen {
+		t.Fatalf("invalid incoming ID not replaced: ctx %q, header %q", seen, rr.Header().Get("X-Request-Id"))
+	}
+	notExpect(t, out.String(), "forged")
+
+	rr = get("/boom", "")
+	id := rr.Header().Get("X-Request-Id")
+	if id == "" {
+		t.Fatal("no generated request ID")
+	}
+	expect(t, rr.Body.String(), "Reference: "+id)
+	expect(t, out.String(), "req="+id)
+}
+
+func TestPagePanicRendersErrorPage(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	app := NewApp()
+	app.Page("/boom", func(ctx *Context) *Node { panic("db down") })
+	get := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/boom", nil))
+		return rr
+	}
+
+	rr := get()
+	if rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), "Something went wrong") {
+		t.Fatalf("default page: %d %q", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "<script") || strings.Contains(rr.Body.String(), "db down") {
+		t.Fatal("default error page must be static and must not leak the panic")
+	}
+
+	var got error
+	app.OnError(func(ctx *Context, err error) *Node {
+		got = err
+		return Div().Text("custom failure")
+	})
+	rr = get()
+	if rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), "custom failure") {
+		t.Fatalf("OnError page: %d %q", rr.Code, rr.Body.String())
+	}
+	if got == nil || !strings.Contains(got.Error(), "db down") {
+		t.Fatalf("OnError err = %v", got)
+	}
+	if strings.Contains(rr.Body.String(), "/__ws.js") {
+		t.Fatal("error page must not load the WebSocket client")
+	}
+
+	app.OnError(func(ctx *Context, err error) *Node { panic("again") })
+	if rr = get(); rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), "Something went wrong") {
+		t.Fatalf("panicking OnError: %d", rr.Code)
+	}
+}
+
+func TestCacheBuildsOncePerKeyAndTTL(t *testing.T) {
+	app := NewApp()
+	var mu sync.Mutex
+	builds := 0
+	nav := func() *Node {
+		mu.Lock()
+		builds++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		return Nav().ID("nav").Text("menu")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			expect(t, Div().Render(app.Cache("nav", time.Hour, nav)).ToJS(), "'menu'")
+		}()
+	}
+	wg.Wait()
+	if builds != 1 {
+		t.Fatalf("concurrent misses built %d times", builds)
+	}
+
+	app.Cache("nav", time.Hour, nav).Class("changed")
+	notExpect(t, app.Cache("nav", time.Hour, nav).ToJS(), "changed")
+
+	app.Invalidate("nav")
+	app.Cache("nav", time.Hour, nav)
+	if builds != 2 {
+		t.Fatalf("Invalidate did not force a rebuild: %d", builds)
+	}
+	app.Cache("short", time.Nanosecond, nav)
+	time.Sleep(time.Millisecond)
+	app.Cache("short", time.Nanosecond, nav)
+	if builds != 4 {
+		t.Fatalf("expired entry was reused: %d builds", builds)
+	}
+
+	func() {
+		defer func() { recover() }()
+		app.Cache("boom", 0, func() *Node { panic("fail") })
+	}()
+	if n := app.Cache("boom", 0, func() *Node { return Span().Text("ok") }); !strings.Contains(n.ToJS(), "'ok'") {
+		t.Fatal("a panicking build must not be cached")
+	}
+}
+
+func TestMaxBodySizePerRoute(t *testing.T) {
+	app := NewApp()
+	var readErr error
+	read := func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+		if readErr != nil {
+			return
+		}
+		w.Write([]byte("ok"))
+	}
+	app.POST("/small", read)
+	app.POST("/upload", read)
+	app.PUT("/items/:id", read)
+	app.MaxBodySize(8)
+	app.MaxBodySize(64, "POST /upload", "/items/{id}")
+	h := app.Handler()
+
+	send := func(method, path, body string, chunked bool) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		readErr = nil
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	big := strings.Repeat("x", 32)
+	if code := send("POST", "/small", "tiny", false); code != http.StatusOK {
+		t.Fatalf("small body: %d", code)
+	}
+	if code := send("POST", "/small", big, false); code != http.StatusRequestEntityTooLarge || readErr != nil {
+		t.Fatalf("oversized body: %d, handler read %v", code, readErr)
+	}
+	send("POST", "/small", big, true)
+	var tooLarge *http.MaxBytesError
+	if !errors.As(readErr, &tooLarge) {
+		t.Fatalf("chunked oversized body: read error %v", readErr)
+	}
+	if code := send("POST", "/upload", big, false); code != http.StatusOK {
+		t.Fatalf("route override: %d", code)
+	}
+	if code := send("PUT", "/items/7", big, false); code != http.StatusOK {
+		t.Fatalf("override without method: %d", code)
+	}
+	if code := send("POST", "/upload", big+big+big, false); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("over route limit: %d", code)
+	}
+
+	app.MaxBodySize(0)
+	if code := send("POST", "/small", big, false); code != http.StatusOK {
+		t.Fatalf("no limit: %d", code)
+	}
+}
+
+func TestDeferFillsSectionOverWebSocket(t *testing.T) {
+	app := NewApp()
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Page("/", func(ctx *Context) *Node {
+		return Div().Render(ctx.Defer(Div().Text("loading"), func(ctx *Context) *Node {
+			return Div().Text("report ready")
+		}))
+	})
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	sid := newSessionID()
+	req, _ := http.NewRequest("GET", server.URL+"/", nil)
+	req.Header.Set("Cookie", sessionCookie+"="+sid)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	_, rest, ok := strings.Cut(string(page), `__ws.callSilent('__defer',{id:'`)
+	if !ok || !strings.Contains(string(page), "loading") {
+		t.Fatalf("page lacks skeleton and fill call:\n%s", page)
+	}
+	id, _, _ := strings.Cut(rest, "'")
+
+	// fill asks for the section and returns the next frame, "" if none
+	// arrives within wait.
+	fill := func(ws *testSocket, wait time.Duration) string {
+		t.Helper()
+		if err := ws.send(`{"act":"__defer","data":{"id":"` + id + `"}}`); err != nil {
+			t.Fatal(err)
+		}
+		ws.SetReadDeadline(time.Now().Add(wait))
+		var raw string
+		if err := ws.receive(&raw); err != nil {
+			return ""
+		}
+		return raw
+	}
+
+	other := dialSession(t, server, newSessionID())
+	defer other.Close()
+	if got := fill(other, 200*time.Millisecond); got != "" {
+		t.Fatalf("another session filled the section: %q", got)
+	}
+	ws := dialSession(t, server, sid)
+	defer ws.Close()
+	got := fill(ws, 2*time.Second)
+	if !strings.Contains(got, "report ready") || !strings.Contains(got, "document.getElementById('"+id+"')") {
+		t.Fatalf("fill = %q", got)
+	}
+	if got := fill(ws, 200*time.Millisecond); got != "" {
+		t.Fatalf("section filled twice: %q", got)
+	}
+}
+
+func TestContextSwapHelpersPushToCaller(t *testing.T) {
+	app := NewApp()
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("chat.send", func(ctx *Context) string {
+		ctx.Append("log", Div().Text("last"))
+		ctx.Prepend("log", Div().Text("first"))
+		ctx.Inner("count", Span().Text("2"))
+		ctx.Replace("typing", Div().ID("typing"))
+		return ""
+	})
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+	ws := dialSession(t, server, newSessionID())
+	defer ws.Close()
+
+	if err := ws.send(`{"act":"chat.send"}`); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"_p.appendChild(", "_p.prepend(", "_t.innerHTML='';", "_t.replaceWith("} {
+		var raw string
+		ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := ws.receive(&raw); err != nil {
+			t.Fatal(err)
+		}
+		expect(t, raw, want)
+	}
+
+	if err := (&Context{}).Append("log", Div()); err == nil {
+		t.Fatal("Append without a connection must fail")
+	}
+}
+
+func TestFailedCallsAreFlaggedForOptimisticUndo(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	app := NewApp()
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("ok", func(ctx *Context) string { return "done()" })
+	app.Action("boom", func(ctx *Context) string { panic("db down") })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+	ws := dialSession(t, server, newSessionID())
+	defer ws.Close()
+
+	for act, wantErr := range map[string]bool{"ok": false, "boom": true, "missing": true} {
+		if err := ws.send(`{"act":"` + act + `","id":5}`); err != nil {
+			t.Fatal(err)
+		}
+		var raw string
+		if err := ws.receive(&raw); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(raw, `"err":true`); got != wantErr {
+			t.Fatalf("%s: reply %s, err flag %v want %v", act, raw, got, wantErr)
+		}
+	}
+}
+
+func TestHandlerCtxCarriesTimeoutAndEndsWithHandler(t *testing.T) {
+	app := NewApp()
+	app.HandlerTimeout(time.Minute)
+	var page, action context.Context
+	app.Page("/", func(ctx *Context) *Node {
+		page = ctx.Ctx()
+		if _, ok := page.Deadline(); !ok {
+			t.Error("page Ctx has no deadline")
+		}
+		return Div()
+	})
+	app.Action("slow", func(ctx *Context) string {
+		action = ctx.Ctx()
+		if dl, ok := action.Deadline(); !ok || time.Until(dl) > time.Minute {
+			t.Errorf("action deadline = %v, %v", dl, ok)
+		}
+		return ""
+	})
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	ws := dialSession(t, server, newSessionID())
+	defer ws.Close()
+	if err := ws.send(`{"act":"slow","id":1}`); err != nil {
+		t.Fatal(err)
+	}
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range map[string]context.Context{"page": page, "action": action} {
+		if c == nil || c.Err() == nil {
+			t.Fatalf("%s Ctx still live after the handler returned", name)
+		}
+	}
+	if (&Context{}).Ctx() != context.Background() {
+		t.Fatal("Ctx outside a handler must be Background")
+	}
+}
+
+func TestRegisteringWhileServingIsRaceFree(t *testing.T) {
+	app := NewApp()
+	app.Page("/", func(ctx *Context) *Node { return Div().Text("home") })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(server.URL + "/")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			key := strconv.Itoa(i)
+			app.CSS(nil, ".c"+key+"{}")
+			app.Callable(func(ctx *Context) string { return "" }, key)
+			app.Page("/p"+key, func(ctx *Context) *Node { return Div() })
+			app.GET("/api/"+key, func(w http.ResponseWriter, r *http.Request) {})
+		}()
+	}
+	wg.Wait()
+}
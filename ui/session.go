@@ -0,0 +1,695 @@
+package ui
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Session identity
+// ---------------------------------------------------------------------------
+
+// sessionCookie names the cookie that ties page loads, WebSocket
+// connections and SSE streams from the same browser together.
+const sessionCookie = "gsui_sid"
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("gsui: crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestSessionID returns the session ID carried by r, or "" when the
+// browser has not been issued one yet.
+func requestSessionID(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	c, err := r.Cookie(sessionCookie)
+	if err != nil || len(c.Value) != 32 {
+		return ""
+	}
+	if _, err := hex.DecodeString(c.Value); err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// ensureSession returns the session ID of r, issuing a new cookie on w
+// when the request does not carry a valid one.
+func ensureSession(w http.ResponseWriter, r *http.Request) string {
+	if sid := requestSessionID(r); sid != "" {
+		return sid
+	}
+	sid := newSessionID()
+	setSessionCookie(w, r, sid)
+	return sid
+}
+
+func setSessionCookie(w http.ResponseWriter, r *http.Request, sid string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    sid,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// useSession returns the session ID, first minting one for a page render
+// of a browser that has none. renderPage sends the cookie of a minted ID
+// with the page, so visitors get a session cookie only once something
+// needs the session: the app (SSE, CSRF, a session written before), or
+// the page (a session value, a subscription, a deferred section,
+// SessionID). Other contexts cannot set a cookie and keep the empty ID.
+func (ctx *Context) useSession() string {
+	if ctx.sessionID == "" && ctx.sessionMint {
+		ctx.sessionID = newSessionID()
+		ctx.sessionMint = false
+		ctx.sessionNew = true
+	}
+	return ctx.sessionID
+}
+
+// needsSession reports whether every page load should carry a session
+// cookie: SSE and CSRF tie their requests to it, and once any handler has
+// stored session data, actions of new visitors need it to save theirs.
+func (app *App) needsSession() bool {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	return app.ssePath != "" || app.csrf || app.sessionsUsed
+}
+
+func (app *App) markSessionsUsed() {
+	app.mu.RLock()
+	used := app.sessionsUsed
+	app.mu.RUnlock()
+	if !used {
+		app.mu.Lock()
+		app.sessionsUsed = true
+		app.mu.Unlock()
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Channels: lightweight pub/sub over sessions
+// ---------------------------------------------------------------------------
+
+// channelGrace is how long a session keeps its channel subscriptions after
+// its last connection closes, so a reload or navigation does not drop them.
+const channelGrace = time.Minute
+
+// Subscribe adds session sid to channel (e.g. "room:42"). Publish on that
+// channel then reaches every connection of the session. Subscriptions last
+// until Unsubscribe, or until the session has had no connection for a
+// minute.
+func (app *App) Subscribe(sid, channel string) {
+	if sid == "" || channel == "" {
+		return
+	}
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if app.channels[channel] == nil {
+		app.channels[channel] = make(map[string]bool)
+	}
+	app.channels[channel][sid] = true
+}
+
+// Unsubscribe removes session sid from channel.
+func (app *App) Unsubscribe(sid, channel string) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	app.unsubscribeLocked(sid, channel)
+}
+
+func (app *App) unsubscribeLocked(sid, channel string) {
+	subs := app.channels[channel]
+	delete(subs, sid)
+	if len(subs) == 0 {
+		delete(app.channels, channel)
+	}
+}
+
+// Publish sends a JS string to every session subscribed to channel. Like
+// Broadcast it can be called from anywhere; sessions without an open
+// connection are skipped.
+func (app *App) Publish(channel, js string) {
+	app.mu.RLock()
+	sids := make([]string, 0, len(app.channels[channel]))
+	for sid := range app.channels[channel] {
+		sids = append(sids, sid)
+	}
+	app.mu.RUnlock()
+	for _, sid := range sids {
+		_ = app.PushSession(sid, js)
+	}
+}
+
+// hasConnectionsLocked reports whether session sid has an open WebSocket or
+// SSE stream. The caller must hold app.mu.
+func (app *App) hasConnectionsLocked(sid string) bool {
+	if len(app.sessions[sid]) > 0 {
+		return true
+	}
+	for c := range app.sseClients {
+		if c.sid == sid {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseSession drops the channel subscriptions of sid once it has stayed
+// without connections for channelGrace. It is called whenever one of the
+// session's connections closes.
+func (app *App) releaseSession(sid string) {
+	if sid == "" {
+		return
+	}
+	time.AfterFunc(channelGrace, func() {
+		app.mu.Lock()
+		defer app.mu.Unlock()
+		if app.hasConnectionsLocked(sid) {
+			return
+		}
+		for channel := range app.channels {
+			app.unsubscribeLocked(sid, channel)
+		}
+	})
+}
+
+// Subscribe adds the caller's session to channel. See App.Subscribe.
+func (ctx *Context) Subscribe(channel string) {
+	if ctx.app != nil {
+		ctx.app.Subscribe(ctx.useSession(), channel)
+	}
+}
+
+// Unsubscribe removes the caller's session from channel.
+func (ctx *Context) Unsubscribe(channel string) {
+	if ctx.app != nil {
+		ctx.app.Unsubscribe(ctx.useSession(), channel)
+	}
+}
+
+// Publish sends a JS string to every session subscribed to channel.
+func (ctx *Context) Publish(channel, js string) {
+	if ctx.app != nil {
+		ctx.app.Publish(channel, js)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Session data: pluggable store behind Context.Session
+// ---------------------------------------------------------------------------
+
+// Session data idles out after sessionIdle without a request, and the store
+// is swept at most once per sessionSweepEvery.
+const (
+	sessionIdle       = 24 * time.Hour
+	sessionSweepEvery = 30 * time.Second
+)
+
+// SessionStore persists the Context.Session map of each browser session.
+// The default keeps data in memory; implement it over Redis or a database
+// table to survive restarts and share sessions between instances.
+//
+// Get returns nil (and no error) for an unknown session. Implementations
+// must be safe for concurrent use and must not hand out maps that another
+// caller may still mutate.
+type SessionStore interface {
+	Get(sid string) (map[string]any, error)
+	Set(sid string, data map[string]any) error
+	Delete(sid string) error
+	// Sweep removes sessions that have not been read or written for idle.
+	Sweep(idle time.Duration) error
+}
+
+// ContextSessionStore is a SessionStore that takes the context of the
+// handler call (Context.Ctx), so its queries observe HandlerTimeout, stop
+// when the request is cancelled and carry tracing values. When the store
+// passed to App.SessionStore implements it, the Context methods are used
+// instead of Get and Set. Writes keep the values of the handler context
+// but not its deadline or cancellation: a handler that ran long still has
+// its session changes saved.
+type ContextSessionStore interface {
+	SessionStore
+	GetContext(ctx context.Context, sid string) (map[string]any, error)
+	SetContext(ctx context.Context, sid string, data map[string]any) error
+}
+
+// MemoryStore is the default in-process SessionStore.
+type MemoryStore struct {
+	mu   sync.Mutex
+	data map[string]*memorySession
+}
+
+type memorySession struct {
+	values map[string]any
+	seen   time.Time
+}
+
+// NewMemoryStore creates an empty in-memory session store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string]*memorySession)}
+}
+
+func (s *MemoryStore) Get(sid string) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.data[sid]
+	if rec == nil {
+		return nil, nil
+	}
+	rec.seen = time.Now()
+	return maps.Clone(rec.values), nil
+}
+
+func (s *MemoryStore) Set(sid string, data map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[sid] = &memorySession{values: maps.Clone(data), seen: time.Now()}
+	return nil
+}
+
+func (s *MemoryStore) Delete(sid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, sid)
+	return nil
+}
+
+func (s *MemoryStore) Sweep(idle time.Duration) error {
+	cutoff := time.Now().Add(-idle)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sid, rec := range s.data {
+		if rec.seen.Before(cutoff) {
+			delete(s.data, sid)
+		}
+	}
+	return nil
+}
+
+// SessionStore replaces the store behind Context.Session. Call it before
+// Listen; sessions held by the previous store are not migrated.
+func (app *App) SessionStore(s SessionStore) {
+	app.mu.Lock()
+	app.store = s
+	app.mu.Unlock()
+}
+
+// loadSession fills ctx.Session from the store and occasionally triggers a
+// background sweep.
+func (app *App) loadSession(ctx *Context) {
+	if ctx.sessionID == "" {
+		ctx.Session = make(map[string]any)
+		return
+	}
+	app.mu.Lock()
+	store := app.store
+	sweep := time.Since(app.lastSweep) >= sessionSweepEvery
+	if sweep {
+		app.lastSweep = time.Now()
+	}
+	app.mu.Unlock()
+
+	if sweep {
+		go func() {
+			if err := store.Sweep(sessionIdle); err != nil {
+				app.log().Errorf("session sweep: %v", err)
+			}
+		}()
+	}
+	data, err := app.getSession(ctx.Ctx(), ctx.sessionID)
+	if err != nil {
+		ctx.log().Errorf("session load sid=%s: %v", sessionTag(ctx.sessionID), err)
+		ctx.sessionErr = fmt.Errorf("gsui: load session: %w", err)
+	}
+	ctx.sessionLoaded = len(data) > 0
+	if data == nil {
+		data = make(map[string]any)
+	}
+	ctx.Session = data
+	ctx.sessionBase = snapshotSession(data)
+}
+
+// saveSession writes the changes the handler made to ctx.Session back to
+// the store. Handlers that change nothing cost the store nothing. Neither
+// are sessions whose load failed written: the stored data was never seen
+// and would be overwritten.
+func (app *App) saveSession(ctx *Context) {
+	if ctx.sessionErr != nil || ctx.sessionID == "" && len(ctx.Session) == 0 {
+		return
+	}
+	if ctx.useSession() == "" {
+		ctx.log().Warnf("session changed on a request without a session cookie; not saved")
+		app.markSessionsUsed()
+		return
+	}
+	if err := app.writeSession(ctx); err != nil {
+		ctx.log().Errorf("session save sid=%s: %v", sessionTag(ctx.sessionID), err)
+	}
+}
+
+// writeSession merges the values changed in ctx.Session since it was
+// loaded (or last written) into the stored session, under the session's
+// lock. Values set or removed meanwhile by other handlers of the same
+// session are kept.
+func (app *App) writeSession(ctx *Context) error {
+	set := make(map[string]any)
+	var removed []string
+	for k, v := range ctx.Session {
+		if old, ok := ctx.sessionBase[k]; !ok || old == "" || old != encodeSessionValue(v) {
+			set[k] = v
+		}
+	}
+	for k := range ctx.sessionBase {
+		if _, ok := ctx.Session[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	if len(set) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	mu := app.sessLocks.of(ctx.sessionID)
+	mu.Lock()
+	defer mu.Unlock()
+	c := context.WithoutCancel(ctx.Ctx())
+	data, err := app.getSession(c, ctx.sessionID)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = make(map[string]any)
+	}
+	for k, v := range set {
+		data[k] = v
+	}
+	for _, k := range removed {
+		delete(data, k)
+	}
+	if err := app.setSession(c, ctx.sessionID, data); err != nil {
+		return err
+	}
+	app.markSessionsUsed()
+	ctx.sessionBase = snapshotSession(ctx.Session)
+	ctx.sessionLoaded = len(data) > 0
+	return nil
+}
+
+// getSession reads session sid from the app's store, passing c to a
+// ContextSessionStore.
+func (app *App) getSession(c context.Context, sid string) (map[string]any, error) {
+	app.mu.RLock()
+	store := app.store
+	app.mu.RUnlock()
+	if cs, ok := store.(ContextSessionStore); ok {
+		return cs.GetContext(c, sid)
+	}
+	return store.Get(sid)
+}
+
+// setSession writes session sid to the app's store. Writes keep the values
+// of c but not its deadline or cancellation.
+func (app *App) setSession(c context.Context, sid string, data map[string]any) error {
+	app.mu.RLock()
+	store := app.store
+	app.mu.RUnlock()
+	if cs, ok := store.(ContextSessionStore); ok {
+		return cs.SetContext(context.WithoutCancel(c), sid, data)
+	}
+	return store.Set(sid, data)
+}
+
+// sessionSnapshot holds the JSON encoding of every session value, so
+// saveSession can tell which values a handler changed, even in place.
+type sessionSnapshot map[string]string
+
+func snapshotSession(data map[string]any) sessionSnapshot {
+	base := make(sessionSnapshot, len(data))
+	for k, v := range data {
+		base[k] = encodeSessionValue(v)
+	}
+	return base
+}
+
+// encodeSessionValue returns v as JSON, or "" when it cannot be encoded;
+// such values always count as changed.
+func encodeSessionValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// sessionLocks serializes writes to the same session. Sessions share a
+// fixed set of mutexes by hash, so the set never grows; the zero value is
+// ready to use.
+type sessionLocks [64]sync.Mutex
+
+func (l *sessionLocks) of(sid string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(sid))
+	return &l[h.Sum32()%uint32(len(l))]
+}
+
+// ErrNoSession is returned by Context.SessionErr when the session holds no
+// data yet, and by Context.SaveSession and SessionUpdate when the request
+// has no session.
+var ErrNoSession = errors.New("gsui: no session")
+
+// SessionErr reports how ctx.Session was loaded: nil when the store held
+// data for this session, ErrNoSession when it held none (a new visitor, or
+// a session swept after being idle), and otherwise the store's error,
+// wrapped. Ctx.Session is empty in the last two cases; only the last one
+// means the data may still exist:
+//
+//	switch err := ctx.SessionErr(); {
+//	case errors.Is(err, ui.ErrNoSession):
+//		return ui.Redirect("/login")
+//	case err != nil:
+//		return ui.Notify("error", "Please try again in a moment")
+//	}
+//
+// After a failed load the session is not saved when the handler returns.
+func (ctx *Context) SessionErr() error {
+	if ctx.sessionErr != nil {
+		return ctx.sessionErr
+	}
+	if !ctx.sessionLoaded {
+		return ErrNoSession
+	}
+	return nil
+}
+
+// SaveSession writes the changes to ctx.Session to the store now and
+// returns the store's error, wrapped, for handlers that must know the data
+// was persisted before they answer. Sessions are otherwise saved, with
+// errors only logged, when the handler returns. It returns ErrNoSession for
+// a request without a session cookie and the load error when the session
+// failed to load.
+func (ctx *Context) SaveSession() error {
+	if ctx.useSession() == "" {
+		return ErrNoSession
+	}
+	if ctx.sessionErr != nil {
+		return ctx.sessionErr
+	}
+	if err := ctx.app.writeSession(ctx); err != nil {
+		return fmt.Errorf("gsui: save session: %w", err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Typed session values
+// ---------------------------------------------------------------------------
+
+// SessionGet reads the session value name into a T. ok is false when the
+// session has no such value; err is set when the value does not fit T, or
+// when the session failed to load (see Context.SessionErr):
+//
+//	cart, ok, err := ui.SessionGet[Cart](ctx, "cart")
+//
+// Values come back from a store as JSON-shaped data (maps, []any,
+// float64), so they are converted through encoding/json unless they are
+// already a T.
+func SessionGet[T any](ctx *Context, name string) (T, bool, error) {
+	var v T
+	if ctx.sessionErr != nil {
+		return v, false, ctx.sessionErr
+	}
+	raw, ok := ctx.Session[name]
+	if !ok {
+		return v, false, nil
+	}
+	v, err := decodeSessionValue[T](name, raw)
+	return v, true, err
+}
+
+// SessionSet stores v as the session value name, in the JSON-shaped form
+// any SessionStore can keep, so SessionGet reads it back the same way from
+// the in-memory store as from Redis or a database. It fails when v cannot
+// be encoded as JSON; the session is then left unchanged.
+func SessionSet[T any](ctx *Context, name string, v T) error {
+	data, err := shapeSessionValue(name, v)
+	if err != nil {
+		return err
+	}
+	if ctx.Session == nil {
+		ctx.Session = make(map[string]any)
+	}
+	ctx.Session[name] = data
+	return nil
+}
+
+// SessionUpdate changes the session value name in one step that concurrent
+// handlers of the same session cannot interleave with: it takes the
+// session's lock, reads the value from the store, passes it to fn and
+// writes the result back before returning. Use it instead of SessionGet
+// and SessionSet for values that several actions change at once, such as
+// counters or a cart edited from two tabs:
+//
+//	err := ui.SessionUpdate(ctx, "cart", func(c *Cart) error {
+//		c.Items = append(c.Items, item)
+//		return nil
+//	})
+//
+// fn gets the zero T when the value is unset. When fn returns an error
+// nothing is written and that error is returned. fn must not call
+// SessionUpdate or SaveSession.
+func SessionUpdate[T any](ctx *Context, name string, fn func(*T) error) error {
+	if ctx.app == nil || ctx.useSession() == "" {
+		return ErrNoSession
+	}
+	if ctx.sessionErr != nil {
+		return ctx.sessionErr
+	}
+	app := ctx.app
+	mu := app.sessLocks.of(ctx.sessionID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	data, err := app.getSession(ctx.Ctx(), ctx.sessionID)
+	if err != nil {
+		return fmt.Errorf("gsui: load session: %w", err)
+	}
+	var v T
+	if raw, ok := data[name]; ok {
+		if v, err = decodeSessionValue[T](name, raw); err != nil {
+			return err
+		}
+	}
+	if err := fn(&v); err != nil {
+		return err
+	}
+	shaped, err := shapeSessionValue(name, v)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = make(map[string]any)
+	}
+	data[name] = shaped
+	if err := app.setSession(ctx.Ctx(), ctx.sessionID, data); err != nil {
+		return fmt.Errorf("gsui: save session: %w", err)
+	}
+	if ctx.Session == nil {
+		ctx.Session = make(map[string]any)
+	}
+	ctx.Session[name] = shaped
+	if ctx.sessionBase == nil {
+		ctx.sessionBase = make(sessionSnapshot)
+	}
+	ctx.sessionBase[name] = encodeSessionValue(shaped)
+	ctx.sessionLoaded = true
+	return nil
+}
+
+// decodeSessionValue converts a stored session value into a T.
+func decodeSessionValue[T any](name string, raw any) (T, error) {
+	if t, ok := raw.(T); ok {
+		return t, nil
+	}
+	var v T
+	b, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(b, &v)
+	}
+	if err != nil {
+		return v, fmt.Errorf("gsui: session value %q: %w", name, err)
+	}
+	return v, nil
+}
+
+// shapeSessionValue returns v in the JSON-shaped form stores keep.
+func shapeSessionValue(name string, v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("gsui: session value %q: %w", name, err)
+	}
+	var data any
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("gsui: session value %q: %w", name, err)
+	}
+	return data, nil
+}
+
+// ---------------------------------------------------------------------------
+// Flash messages: toasts that survive a redirect
+// ---------------------------------------------------------------------------
+
+// flashKey holds queued flash messages in Context.Session.
+const flashKey = "__flash"
+
+// Flash queues a toast for the next page load of this session — the
+// post/redirect/get pattern:
+//
+//	ctx.Flash("success", "Saved")
+//	return ui.Redirect("/orders")
+//
+// kind is a Notify variant ("success", "error", "info"). Messages are shown
+// once, in the order queued.
+func (ctx *Context) Flash(kind, message string) {
+	if ctx.Session == nil {
+		ctx.Session = make(map[string]any)
+	}
+	queued, _ := ctx.Session[flashKey].([]any)
+	ctx.Session[flashKey] = append(queued, map[string]any{"kind": kind, "message": message})
+}
+
+// takeFlashJS removes queued flash messages from the session and returns
+// the JS that displays them.
+func (ctx *Context) takeFlashJS() string {
+	queued, _ := ctx.Session[flashKey].([]any)
+	if queued == nil {
+		return ""
+	}
+	delete(ctx.Session, flashKey)
+	var js strings.Builder
+	for _, item := range queued {
+		m, _ := item.(map[string]any)
+		kind, _ := m["kind"].(string)
+		message, _ := m["message"].(string)
+		if message != "" {
+			js.WriteString(Notify(kind, message))
+		}
+	}
+	return js.String()
+}
@@ -0,0 +1,515 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// testSocket is a test client of the WebSocket endpoint.
+type testSocket struct {
+	conn     *websocket.Conn
+	deadline time.Time
+}
+
+// dialWS connects to path on server as a same-origin browser sending
+// header, which may be nil.
+func dialWS(server *httptest.Server, path string, header http.Header) (*testSocket, error) {
+	h := header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set("Origin", server.URL)
+	opts := &websocket.DialOptions{HTTPHeader: h}
+	// Offered subprotocols go through the dial options, which check the
+	// server's choice.
+	if p := h.Get("Sec-WebSocket-Protocol"); p != "" {
+		h.Del("Sec-WebSocket-Protocol")
+		opts.Subprotocols = []string{p}
+	}
+	conn, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http")+path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &testSocket{conn: conn}, nil
+}
+
+func (ws *testSocket) send(s string) error {
+	return ws.conn.Write(context.Background(), websocket.MessageText, []byte(s))
+}
+
+// receive reads the next message into raw, waiting until the deadline
+// set by SetReadDeadline, if any.
+func (ws *testSocket) receive(raw *string) error {
+	ctx := context.Background()
+	if !ws.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, ws.deadline)
+		defer cancel()
+	}
+	_, b, err := ws.conn.Read(ctx)
+	*raw = string(b)
+	return err
+}
+
+func (ws *testSocket) SetReadDeadline(t time.Time) { ws.deadline = t }
+
+func (ws *testSocket) Close() error { return ws.conn.CloseNow() }
+
+// dialSession opens a WebSocket to server carrying the session cookie sid
+// and waits for one tracked round-trip so the connection is registered.
+func dialSession(t *testing.T, server *httptest.Server, sid string) *testSocket {
+	t.Helper()
+	ws, err := dialWS(server, "/__ws", http.Header{"Cookie": {sessionCookie + "=" + sid}})
+	if err != nil {
+		t.Fatalf("connect WebSocket: %v", err)
+	}
+	if err := ws.send(`{"act":"ping","id":1}`); err != nil {
+		t.Fatalf("send ping: %v", err)
+	}
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatalf("receive ping reply: %v", err)
+	}
+	return ws
+}
+
+func TestRequestSessionIDRejectsMalformedCookie(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Cookie", sessionCookie+"=not-a-session")
+	if got := requestSessionID(r); got != "" {
+		t.Fatalf("requestSessionID() = %q, want empty", got)
+	}
+	rr := httptest.NewRecorder()
+	if sid := ensureSession(rr, r); len(sid) != 32 {
+		t.Fatalf("ensureSession() = %q", sid)
+	}
+	expect(t, rr.Header().Get("Set-Cookie"), "HttpOnly")
+}
+
+func TestSessionCookieIssuedOnlyWhenNeeded(t *testing.T) {
+	app := NewApp()
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	app.Page("/cart", func(ctx *Context) *Node { ctx.Session["cart"] = 1; return Div() })
+	get := func(path string) string {
+		rr := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr.Header().Get("Set-Cookie")
+	}
+
+	if c := get("/"); c != "" {
+		t.Fatalf("plain page set %q", c)
+	}
+	expect(t, get("/cart"), sessionCookie+"=")
+	// Once the app keeps sessions, new visitors get one on any page, so
+	// their actions can save to it.
+	expect(t, get("/"), sessionCookie+"=")
+}
+
+func TestPushSessionReachesOnlyThatSession(t *testing.T) {
+	app := NewApp()
+	var seen string
+	app.Action("ping", func(ctx *Context) string { seen = ctx.SessionID(); return "" })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	sidA, sidB := newSessionID(), newSessionID()
+	a := dialSession(t, server, sidA)
+	defer a.Close()
+	if seen != sidA {
+		t.Fatalf("ctx.SessionID() = %q, want %q", seen, sidA)
+	}
+	b := dialSession(t, server, sidB)
+	defer b.Close()
+
+	if err := app.PushSession(sidA, "onlyA()"); err != nil {
+		t.Fatal(err)
+	}
+	var raw string
+	if err := a.receive(&raw); err != nil || raw != "onlyA()" {
+		t.Fatalf("session A got %q, %v", raw, err)
+	}
+
+	// B's next frame must be its own reply, not A's push.
+	if err := b.send(`{"act":"ping","id":2}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+	notExpect(t, raw, "onlyA()")
+
+	if err := app.PushSession(newSessionID(), "nobody()"); err == nil {
+		t.Fatal("expected error for a session without connections")
+	}
+}
+
+func TestPublishReachesOnlySubscribedSessions(t *testing.T) {
+	app := NewApp()
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("join", func(ctx *Context) string { ctx.Subscribe("room:42"); return "" })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	a := dialSession(t, server, newSessionID())
+	defer a.Close()
+	b := dialSession(t, server, newSessionID())
+	defer b.Close()
+
+	var raw string
+	if err := a.send(`{"act":"join","id":2}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	app.Publish("room:42", "hello()")
+	if err := a.receive(&raw); err != nil || raw != "hello()" {
+		t.Fatalf("subscriber got %q, %v", raw, err)
+	}
+	if err := b.send(`{"act":"ping","id":2}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+	notExpect(t, raw, "hello()")
+}
+
+func TestUnsubscribeRemovesEmptyChannel(t *testing.T) {
+	app := NewApp()
+	app.Subscribe("s1", "room")
+	app.Unsubscribe("s1", "room")
+	if _, ok := app.channels["room"]; ok {
+		t.Fatal("empty channel should be removed")
+	}
+}
+
+func TestMemoryStoreClonesAndSweeps(t *testing.T) {
+	s := NewMemoryStore()
+	data := map[string]any{"user": "ann"}
+	if err := s.Set("a", data); err != nil {
+		t.Fatal(err)
+	}
+	data["user"] = "bob"
+	got, _ := s.Get("a")
+	if got["user"] != "ann" {
+		t.Fatalf("store shares caller map: %v", got)
+	}
+	got["user"] = "eve"
+	if again, _ := s.Get("a"); again["user"] != "ann" {
+		t.Fatalf("Get must return a copy: %v", again)
+	}
+	if missing, err := s.Get("nope"); missing != nil || err != nil {
+		t.Fatalf("Get(unknown) = %v, %v", missing, err)
+	}
+	s.Sweep(time.Hour)
+	if v, _ := s.Get("a"); v == nil {
+		t.Fatal("fresh session swept")
+	}
+	s.Sweep(-time.Second)
+	if v, _ := s.Get("a"); v != nil {
+		t.Fatal("idle session not swept")
+	}
+}
+
+type recordingStore struct {
+	*MemoryStore
+	sets int
+}
+
+func (s *recordingStore) Set(sid string, data map[string]any) error {
+	s.sets++
+	return s.MemoryStore.Set(sid, data)
+}
+
+func TestSessionPersistsAcrossActionAndPage(t *testing.T) {
+	app := NewApp()
+	store := &recordingStore{MemoryStore: NewMemoryStore()}
+	app.SessionStore(store)
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("login", func(ctx *Context) string {
+		ctx.Session["user"] = "ann"
+		return ""
+	})
+	var seen any
+	app.Page("/", func(ctx *Context) *Node {
+		seen = ctx.Session["user"]
+		return Div()
+	})
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	sid := newSessionID()
+	ws := dialSession(t, server, sid)
+	defer ws.Close()
+	if store.sets != 0 {
+		t.Fatalf("empty session written %d times", store.sets)
+	}
+	if err := ws.send(`{"act":"login","id":2}`); err != nil {
+		t.Fatal(err)
+	}
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/", nil)
+	req.Header.Set("Cookie", sessionCookie+"="+sid)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if seen != "ann" {
+		t.Fatalf("page saw session user %v, want ann", seen)
+	}
+}
+
+// contextStore records the contexts the app hands a ContextSessionStore.
+type contextStore struct {
+	*MemoryStore
+	getDeadline bool
+	setErr      error
+}
+
+func (s *contextStore) GetContext(ctx context.Context, sid string) (map[string]any, error) {
+	if _, ok := ctx.Deadline(); ok {
+		s.getDeadline = true
+	}
+	return s.Get(sid)
+}
+
+func (s *contextStore) SetContext(ctx context.Context, sid string, data map[string]any) error {
+	s.setErr = ctx.Err()
+	return s.Set(sid, data)
+}
+
+func TestContextSessionStoreGetsHandlerContext(t *testing.T) {
+	app := NewApp()
+	store := &contextStore{MemoryStore: NewMemoryStore()}
+	app.SessionStore(store)
+	app.HandlerTimeout(time.Millisecond)
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("slow", func(ctx *Context) string {
+		<-ctx.Ctx().Done()
+		ctx.Session["user"] = "ann"
+		return ""
+	})
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	sid := newSessionID()
+	ws := dialSession(t, server, sid)
+	defer ws.Close()
+	if err := ws.send(`{"act":"slow","id":2}`); err != nil {
+		t.Fatal(err)
+	}
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !store.getDeadline {
+		t.Fatal("GetContext got a context without the handler deadline")
+	}
+	if store.setErr != nil {
+		t.Fatalf("SetContext got an ended context: %v", store.setErr)
+	}
+	if data, _ := store.Get(sid); data["user"] != "ann" {
+		t.Fatalf("session after timeout = %v", data)
+	}
+}
+
+// failingStore fails every Get while down is set.
+type failingStore struct {
+	*MemoryStore
+	down bool
+}
+
+func (s *failingStore) Get(sid string) (map[string]any, error) {
+	if s.down {
+		return nil, errors.New("connection refused")
+	}
+	return s.MemoryStore.Get(sid)
+}
+
+func TestSessionErrTellsMissingFromFailed(t *testing.T) {
+	app := NewApp()
+	store := &failingStore{MemoryStore: NewMemoryStore()}
+	app.SessionStore(store)
+	var loadErr, saveErr error
+	app.Page("/", func(ctx *Context) *Node {
+		loadErr = ctx.SessionErr()
+		ctx.Session["user"] = "ann"
+		saveErr = ctx.SaveSession()
+		return Div()
+	})
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	sid := newSessionID()
+	load := func() {
+		req, _ := http.NewRequest("GET", server.URL+"/", nil)
+		req.Header.Set("Cookie", sessionCookie+"="+sid)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	load()
+	if !errors.Is(loadErr, ErrNoSession) || saveErr != nil {
+		t.Fatalf("new session: load %v, save %v", loadErr, saveErr)
+	}
+	load()
+	if loadErr != nil {
+		t.Fatalf("stored session: load %v", loadErr)
+	}
+
+	store.Set(sid, map[string]any{"user": "bob"})
+	store.down = true
+	load()
+	if loadErr == nil || errors.Is(loadErr, ErrNoSession) || !strings.Contains(loadErr.Error(), "connection refused") {
+		t.Fatalf("failed load: %v", loadErr)
+	}
+	if saveErr != loadErr {
+		t.Fatalf("SaveSession after failed load = %v", saveErr)
+	}
+	if data, _ := store.MemoryStore.Get(sid); data["user"] != "bob" {
+		t.Fatalf("session overwritten after failed load: %v", data)
+	}
+}
+
+func TestSessionGetSetRoundTripTypedValues(t *testing.T) {
+	type cart struct {
+		Items []string
+		Total int
+	}
+	ctx := &Context{Session: map[string]any{"n": "x"}}
+	if err := SessionSet(ctx, "cart", cart{Items: []string{"tea"}, Total: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, isMap := ctx.Session["cart"].(map[string]any); !isMap {
+		t.Fatalf("stored %T, want JSON-shaped map", ctx.Session["cart"])
+	}
+	got, ok, err := SessionGet[cart](ctx, "cart")
+	if err != nil || !ok || got.Total != 3 || len(got.Items) != 1 || got.Items[0] != "tea" {
+		t.Fatalf("SessionGet = %+v, %v, %v", got, ok, err)
+	}
+	if _, ok, err := SessionGet[cart](ctx, "missing"); ok || err != nil {
+		t.Fatalf("missing value: %v, %v", ok, err)
+	}
+	if _, ok, err := SessionGet[int](ctx, "n"); !ok || err == nil {
+		t.Fatalf("mismatched value: %v, %v", ok, err)
+	}
+	if err := SessionSet(ctx, "ch", make(chan int)); err == nil {
+		t.Fatal("SessionSet accepted a value JSON cannot encode")
+	}
+}
+
+func TestSessionUpdateAndSaveDoNotClobberConcurrentHandlers(t *testing.T) {
+	app := NewApp()
+	sid := newSessionID()
+	newCtx := func() *Context {
+		ctx := &Context{app: app, sessionID: sid}
+		app.loadSession(ctx)
+		return ctx
+	}
+
+	// Two handlers loaded the same session and each set a different value.
+	a, b := newCtx(), newCtx()
+	a.Session["theme"] = "dark"
+	b.Session["lang"] = "sk"
+	app.saveSession(a)
+	app.saveSession(b)
+	c := newCtx()
+	if c.Session["theme"] != "dark" || c.Session["lang"] != "sk" {
+		t.Fatalf("session after concurrent saves = %v", c.Session)
+	}
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := newCtx()
+			if err := SessionUpdate(ctx, "clicks", func(n *int) error { *n++; return nil }); err != nil {
+				t.Error(err)
+			}
+			app.saveSession(ctx)
+		}()
+	}
+	wg.Wait()
+	n, _, err := SessionGet[int](newCtx(), "clicks")
+	if err != nil || n != 50 {
+		t.Fatalf("clicks = %d, %v; want 50", n, err)
+	}
+
+	refused := errors.New("out of stock")
+	if err := SessionUpdate(newCtx(), "clicks", func(n *int) error { *n = 0; return refused }); err != refused {
+		t.Fatalf("SessionUpdate error = %v", err)
+	}
+	if n, _, _ := SessionGet[int](newCtx(), "clicks"); n != 50 {
+		t.Fatalf("failed update was written: clicks = %d", n)
+	}
+}
+
+func TestFlashShownOnceOnNextPageLoad(t *testing.T) {
+	app := NewApp()
+	app.Action("ping", func(ctx *Context) string { return "" })
+	app.Action("save", func(ctx *Context) string {
+		ctx.Flash("success", "Saved 'order'")
+		return Redirect("/")
+	})
+	app.Page("/", func(ctx *Context) *Node { return Div() })
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	sid := newSessionID()
+	ws := dialSession(t, server, sid)
+	defer ws.Close()
+	ws.send(`{"act":"save","id":2}`)
+	var raw string
+	if err := ws.receive(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func() string {
+		req, _ := http.NewRequest("GET", server.URL+"/", nil)
+		req.Header.Set("Cookie", sessionCookie+"="+sid)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+	if page := load(); !strings.Contains(page, `Saved \'order\'`) {
+		t.Fatal("flash message missing from first page load")
+	}
+	if page := load(); strings.Contains(page, "Saved") {
+		t.Fatal("flash message shown twice")
+	}
+
+	// Back/forward renders over the WebSocket; it consumes the flash too.
+	ws.send(`{"act":"save","id":3}`)
+	ws.receive(&raw)
+	ws.send(`{"act":"__nav","data":{"url":"/"},"id":4}`)
+	if ws.receive(&raw); !strings.Contains(raw, "Saved") {
+		t.Fatalf("flash missing from __nav reply: %s", raw)
+	}
+	if page := load(); strings.Contains(page, "Saved") {
+		t.Fatal("flash shown by __nav came back on the next load")
+	}
+}
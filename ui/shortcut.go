@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ---------------------------------------------------------------------------
+// Shortcuts: keyboard combinations that trigger an element's action
+// ---------------------------------------------------------------------------
+
+// shortcutKeys maps key names accepted by Shortcut to KeyboardEvent.key,
+// lowercased.
+var shortcutKeys = map[string]string{
+	"esc":   "escape",
+	"space": " ",
+	"plus":  "+",
+	"up":    "arrowup",
+	"down":  "arrowdown",
+	"left":  "arrowleft",
+	"right": "arrowright",
+	"del":   "delete",
+}
+
+// Shortcut calls action when keys are pressed anywhere on the page while
+// the node is in the DOM. keys are modifiers and a key joined by "+":
+// "ctrl+s", "mod+k", "shift+?", "/", "esc". mod is cmd on Apple devices and
+// ctrl elsewhere; the key is a character or a KeyboardEvent.key name such
+// as "enter" or "arrowdown". The call goes through the node, as if it were
+// an event on it, so Confirm, Busy, Optimistic and Retry apply and the
+// handler replies with the usual swaps. The browser's own handling of the
+// combination is prevented.
+//
+// Combinations without ctrl, alt, cmd or mod are ignored while the user
+// types in a field, so "/" still types a slash in a search box; "ctrl+s"
+// works in a form as well. The listener is dropped once the node leaves
+// the DOM, and a replacement node brings its own.
+//
+//	ui.Form().ID("edit").Render(fields...).
+//	    Shortcut("mod+s", ctx.Action("doc.save", save).Retry())
+//
+// An unknown modifier or an empty key logs an error and binds nothing.
+func (n *Node) Shortcut(keys string, action *Action) *Node {
+	if action == nil {
+		return n
+	}
+	combo, ok := parseShortcut(keys)
+	if !ok {
+		defaultLogger.Errorf("invalid shortcut %q", keys)
+		return n
+	}
+	event := "gsui-shortcut:" + combo.String()
+	n.On(event, action)
+	js := "var el=this,h=function(e){" +
+		"if(!el.isConnected){document.removeEventListener('keydown',h);return}" +
+		"if(e.defaultPrevented||e.repeat||e.isComposing)return;" +
+		combo.matchJS() +
+		"e.preventDefault();el.dispatchEvent(new CustomEvent('" + escJS(event) + "'))};" +
+		"document.addEventListener('keydown',h);"
+	if n.rawJS != "" {
+		js = n.rawJS + ";" + js
+	}
+	n.rawJS = js
+	return n
+}
+
+// shortcut is a parsed key combination.
+type shortcut struct {
+	ctrl, meta, alt, shift, mod bool
+	key                         string // KeyboardEvent.key, lowercased
+}
+
+// parseShortcut reads keys as described on Node.Shortcut.
+func parseShortcut(keys string) (shortcut, bool) {
+	var s shortcut
+	keys = strings.ToLower(strings.TrimSpace(keys))
+	// A trailing "+" is the plus key itself: "ctrl++".
+	if strings.HasSuffix(keys, "++") || keys == "+" {
+		s.key = "+"
+		keys = strings.TrimSuffix(strings.TrimSuffix(keys, "+"), "+")
+	}
+	parts := strings.Split(keys, "+")
+	if s.key == "" {
+		s.key, parts = parts[len(parts)-1], parts[:len(parts)-1]
+		if k, ok := shortcutKeys[s.key]; ok {
+			s.key = k
+		}
+	} else if keys == "" {
+		parts = nil
+	}
+	if s.key == "" {
+		return s, false
+	}
+	for _, p := range parts {
+		switch p {
+		case "ctrl", "control":
+			s.ctrl = true
+		case "cmd", "meta":
+			s.meta = true
+		case "alt", "option":
+			s.alt = true
+		case "shift":
+			s.shift = true
+		case "mod":
+			s.mod = true
+		default:
+			return s, false
+		}
+	}
+	return s, true
+}
+
+// String returns the combination in a canonical form, used to name its
+// event.
+func (s shortcut) String() string {
+	var parts []string
+	for _, m := range []struct {
+		on   bool
+		name string
+	}{{s.mod, "mod"}, {s.ctrl, "ctrl"}, {s.meta, "meta"}, {s.alt, "alt"}, {s.shift, "shift"}} {
+		if m.on {
+			parts = append(parts, m.name)
+		}
+	}
+	key := s.key
+	if key == " " {
+		key = "space"
+	}
+	return strings.Join(append(parts, key), "+")
+}
+
+// matchJS returns statements that return from a keydown handler unless
+// its event e is the combination. Shift is not compared for other single
+// characters unless asked for, as layouts need it to type "?" or "+".
+func (s shortcut) matchJS() string {
+	ctrl, meta := fmt.Sprint(s.ctrl), fmt.Sprint(s.meta)
+	js := ""
+	if s.mod {
+		js = "var m=/Mac|iPhone|iPad/.test(navigator.platform);"
+		ctrl, meta = "(!m||"+ctrl+")", "(m||"+meta+")"
+	}
+	js += "if((e.key||'').toLowerCase()!=='" + escJS(s.key) + "'||e.ctrlKey!==" + ctrl +
+		"||e.metaKey!==" + meta + "||e.altKey!==" + fmt.Sprint(s.alt)
+	if s.shift || !s.symbol() {
+		js += "||e.shiftKey!==" + fmt.Sprint(s.shift)
+	}
+	js += ")return;"
+	if !s.ctrl && !s.meta && !s.alt && !s.mod {
+		js += "var t=e.target;if(t&&(t.isContentEditable||/^(INPUT|TEXTAREA|SELECT)$/.test(t.tagName)))return;"
+	}
+	return js
+}
+
+// symbol reports whether the key is a single character other than a letter
+// or digit.
+func (s shortcut) symbol() bool {
+	r, size := utf8.DecodeRuneInString(s.key)
+	if size != len(s.key) || s.key == " " {
+		return false
+	}
+	return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Server-Sent Events: one-way push transport
+// ---------------------------------------------------------------------------
+
+// sseHeartbeat is how often an idle stream receives a comment line so
+// proxies do not close it.
+const sseHeartbeat = 25 * time.Second
+
+// sseClient is one open EventSource stream.
+type sseClient struct {
+	sid string
+	ch  chan string
+}
+
+// SSE registers a text/event-stream endpoint at path and makes every page
+// open an EventSource to it. Pushes sent over the stream are the same JS
+// strings actions return, executed the same way the WS client executes
+// them, so page code does not change.
+//
+// Use it as a fallback for server pushes where proxies block WebSockets.
+// Actions still travel over the WebSocket; App.Broadcast reaches both
+// transports and ctx.PushSSE targets the streams of the caller's browser
+// session.
+//
+//	app.SSE("/__sse")
+func (app *App) SSE(path string) {
+	app.mu.Lock()
+	app.ssePath = path
+	app.mu.Unlock()
+	app.mux.HandleFunc("GET "+path, app.serveSSE)
+}
+
+func (app *App) serveSSE(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	sid := ensureSession(w, r)
+
+	// Register before the headers go out so a push sent as soon as the
+	// client sees the response is not lost.
+	c := &sseClient{sid: sid, ch: make(chan string, 16)}
+	app.mu.Lock()
+	app.sseClients[c] = true
+	app.mu.Unlock()
+	defer func() {
+		app.mu.Lock()
+		delete(app.sseClients, c)
+		app.mu.Unlock()
+		app.releaseSession(sid)
+	}()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		app.log().Errorf("sse flush unsupported: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(sseHeartbeat)
+	defer ticker.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case js := <-c.ch:
+			_, err = w.Write(sseEvent(js))
+		case <-ticker.C:
+			_, err = w.Write([]byte(": ping\n\n"))
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// sseEvent frames js as a single "message" event. Every line becomes its own
+// data field; the EventSource joins them back with newlines.
+func sseEvent(js string) []byte {
+	js = strings.ReplaceAll(js, "\r\n", "\n")
+	js = strings.ReplaceAll(js, "\r", "\n")
+	var b strings.Builder
+	for line := range strings.SplitSeq(js, "\n") {
+		b.WriteString("data: ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+// sendSSE queues js on every stream of session sid, or on every stream when
+// sid is empty. It returns the number of streams reached. A stream whose
+// buffer is full is skipped rather than blocking the caller.
+func (app *App) sendSSE(sid, js string) int {
+	lg := app.log()
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	n, dropped := 0, 0
+	for c := range app.sseClients {
+		if sid != "" && c.sid != sid {
+			continue
+		}
+		select {
+		case c.ch <- js:
+			n++
+		default:
+			dropped++
+			lg.Warnf("sse stream buffer full; dropping message")
+		}
+	}
+	if app.metrics != nil && n+dropped > 0 {
+		app.metrics.sseSend(n, dropped)
+	}
+	return n
+}
+
+// PushSSE sends a JS string to the SSE streams opened by the caller's
+// browser session (all of its tabs). It works from page renders and WS
+// actions alike and returns an error when no stream is connected.
+func (ctx *Context) PushSSE(js string) error {
+	if ctx.app == nil {
+		return fmt.Errorf("no app context")
+	}
+	if ctx.sessionID == "" {
+		return fmt.Errorf("no session")
+	}
+	if ctx.app.sendSSE(ctx.sessionID, js) == 0 {
+		return fmt.Errorf("no sse stream for session")
+	}
+	return nil
+}
+
+// sseClientJS opens the EventSource for App.SSE and runs every message.
+func sseClientJS(path string) string {
+	return fmt.Sprintf(`(function(){if(!window.EventSource||window.__gsuiSSEInit)return;window.__gsuiSSEInit=true;`+
+		`var es=new EventSource('%s');`+
+		`es.onmessage=function(e){try{new Function(e.data)()}catch(err){console.error('sse exec error:',err,e.data)}`+
+		`try{window.dispatchEvent(new Event('gsui:updated'))}catch(_){}}})();`, escJS(path))
+}
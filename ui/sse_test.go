@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSSEEventFramesEveryLine(t *testing.T) {
+	got := string(sseEvent("a();\r\nb();"))
+	if want := "data: a();\ndata: b();\n\n"; got != want {
+		t.Fatalf("sseEvent() = %q, want %q", got, want)
+	}
+}
+
+func TestPageShellOpensEventSourceAndIssuesSession(t *testing.T) {
+	app := NewApp()
+	app.SSE("/__sse")
+	app.Page("/", func(ctx *Context) *Node { return Div().Text("home") })
+
+	rr := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "http://example.test/", nil))
+
+	expect(t, rr.Body.String(), "new EventSource('/__sse')")
+	expect(t, rr.Header().Get("Set-Cookie"), sessionCookie+"=")
+}
+
+func TestSSEDeliversBroadcastAndSessionPush(t *testing.T) {
+	app := NewApp()
+	app.SSE("/__sse")
+	srv := httptest.NewServer(app.Handler())
+	defer srv.Close()
+
+	sid := newSessionID()
+	req, _ := http.NewRequest("GET", srv.URL+"/__sse", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: sid})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	app.Broadcast("everyone()")
+	other := &Context{app: app, sessionID: newSessionID()}
+	if err := other.PushSSE("leak()"); err == nil {
+		t.Fatal("expected error pushing to a session without streams")
+	}
+	mine := &Context{app: app, sessionID: sid}
+	if err := mine.PushSSE("mine()"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	var got []string
+	for len(got) < 2 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			got = append(got, data)
+		}
+	}
+	if got[0] != "everyone()" || got[1] != "mine()" {
+		t.Fatalf("events = %q", got)
+	}
+}
@@ -1688,10 +1688,35 @@ func renderSelectFilter(loc *TableLocale, colIdx int, options []string, currentV
 // Rows are auto-wrapped based on numCols.
 type SimpleTable struct {
 	numCols    int
+	id         string
 	cls        string
 	heads      []string
 	rows       [][]*Node
 	currentRow []*Node
+	sortAction string
+	sortFields []string
+	sort       Sort
+}
+
+// Sort is the single-column sort state of a sortable SimpleTable. It is
+// sent as the action payload when a header is clicked, so handlers read it
+// with ctx.Body(&sort). An empty Field means unsorted.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Next returns the state after clicking the header of field. Clicks on the
+// same column cycle ascending → descending → unsorted; clicking another
+// column starts over at ascending.
+func (s Sort) Next(field string) Sort {
+	if s.Field != field {
+		return Sort{Field: field}
+	}
+	if !s.Desc {
+		return Sort{Field: field, Desc: true}
+	}
+	return Sort{}
 }
 
 // NewSimpleTable creates a new SimpleTable with the given number of columns.
@@ -1709,6 +1734,31 @@ func (t *SimpleTable) SimpleHeader(labels ...string) *SimpleTable {
 	return t
 }
 
+// SimpleID sets the id of the rendered <table> so a sort or paging handler
+// can swap it with ToJSReplace.
+func (t *SimpleTable) SimpleID(id string) *SimpleTable {
+	t.id = id
+	return t
+}
+
+// SimpleSortable makes header columns clickable. fields holds one sort key
+// per header ("" leaves that column unsortable) and current is the state the
+// rows were rendered with. A click calls action with current.Next(field) as
+// its payload; the handler re-sorts and returns the rebuilt table.
+func (t *SimpleTable) SimpleSortable(action string, current Sort, fields ...string) *SimpleTable {
+	t.sortAction = action
+	t.sort = current
+	t.sortFields = fields
+	return t
+}
+
+func (t *SimpleTable) sortField(colIdx int) string {
+	if t.sortAction == "" || colIdx >= len(t.sortFields) {
+		return ""
+	}
+	return t.sortFields[colIdx]
+}
+
 // Cell adds a *Node cell to the table. When the current row reaches
 // numCols, it is flushed and a new row starts automatically.
 func (t *SimpleTable) Cell(node *Node) *SimpleTable {
@@ -1746,7 +1796,31 @@ func (t *SimpleTable) Build() *Node {
 		ths := make([]*Node, len(t.heads))
 		for i, label := range t.heads {
 			ths[i] = Th("text-left font-semibold p-2 border-b border-gray-200 dark:border-gray-700 " +
-				"text-gray-700 dark:text-gray-300 text-xs uppercase tracking-wider").Text(label)
+				"text-gray-700 dark:text-gray-300 text-xs uppercase tracking-wider")
+			field := t.sortField(i)
+			if field == "" {
+				ths[i].Text(label)
+				continue
+			}
+			ariaSort := "none"
+			indicator := Span("text-gray-300 dark:text-gray-600 text-lg ml-0.5 select-none").Text("⇅")
+			if t.sort.Field == field {
+				ariaSort = "ascending"
+				indicator = Span("text-lime-500 dark:text-lime-400 text-lg ml-0.5").Text("\u2191")
+				if t.sort.Desc {
+					ariaSort = "descending"
+					indicator = Span("text-lime-500 dark:text-lime-400 text-lg ml-0.5").Text("\u2193")
+				}
+			}
+			next := t.sort.Next(field)
+			// The button makes the sort reachable by keyboard and screen
+			// readers; aria-sort stays on the header cell.
+			ths[i].Attr("aria-sort", ariaSort).Render(
+				Button("inline-flex items-center gap-1 uppercase tracking-wider font-semibold cursor-pointer select-none "+
+					"rounded focus:outline-none focus-visible:ring-2 focus-visible:ring-blue-500").
+					Attr("type", "button").
+					OnClick(&Action{Name: t.sortAction, Data: map[string]any{"Field": next.Field, "Desc": next.Desc}}).
+					Render(Span().Text(label), indicator.Attr("aria-hidden", "true")))
 		}
 		parts = append(parts, Thead("bg-gray-50 dark:bg-gray-800/50").Render(Tr().Render(ths...)))
 	}
@@ -1779,5 +1853,9 @@ func (t *SimpleTable) Build() *Node {
 		parts = append(parts, Tbody().Render(emptyRow))
 	}
 
-	return Table(tableCls).Render(parts...)
+	table := Table(tableCls).Render(parts...)
+	if t.id != "" {
+		table.ID(t.id)
+	}
+	return table
 }
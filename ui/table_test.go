@@ -0,0 +1,38 @@
+package ui
+
+import "testing"
+
+// ---------------------------------------------------------------------------
+// SimpleTable sorting tests
+// ---------------------------------------------------------------------------
+
+func TestSortNextCycles(t *testing.T) {
+	s := Sort{}
+	steps := []Sort{{Field: "name"}, {Field: "name", Desc: true}, {}}
+	for i, want := range steps {
+		s = s.Next("name")
+		if s != want {
+			t.Fatalf("step %d: got %+v, want %+v", i, s, want)
+		}
+	}
+	if got := (Sort{Field: "name", Desc: true}).Next("age"); got != (Sort{Field: "age"}) {
+		t.Fatalf("switching column: got %+v", got)
+	}
+}
+
+func TestSimpleTableSortableHeaders(t *testing.T) {
+	js := NewSimpleTable(2).SimpleID("people").
+		SimpleHeader("Name", "Note").
+		SimpleSortable("people.sort", Sort{Field: "name"}, "name").
+		CellText("Ann").CellText("-").
+		Build().ToJS()
+
+	expect(t, js, ".id='people'")
+	expect(t, js, "setAttribute('aria-sort','ascending')")
+	expect(t, js, "document.createElement('button')")
+	expect(t, js, "setAttribute('type','button')")
+	expect(t, js, "__ws.call('people.sort'")
+	expect(t, js, `"Desc":true`)
+	expect(t, js, `"Field":"name"`)
+	expect(t, js, ".textContent='Note'")
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Theme: brand colors as CSS variables
+// ---------------------------------------------------------------------------
+
+// ThemeConfig sets the colors of the framework's own chrome: the page
+// background and text the dark-mode overrides apply, the boot screen, the
+// loading pill, info toasts and the ThemeSwitcher icon. Values are any CSS
+// color ("#0f766e", "rgb(15 118 110)", "oklch(...)"); empty fields keep the
+// built-in palette. Each field becomes a --gsui-* variable on :root, so
+// your own CSS can use them too (e.g. var(--gsui-primary)).
+type ThemeConfig struct {
+	Primary     string // --gsui-primary: accents, loader start, info toasts
+	Secondary   string // --gsui-secondary: loader gradient end
+	Surface     string // --gsui-surface: page background in light mode
+	SurfaceDark string // --gsui-surface-dark: page background in dark mode
+	Text        string // --gsui-text: body text in light mode
+	TextDark    string // --gsui-text-dark: body text in dark mode
+}
+
+// Theme applies brand colors to every page of the app.
+//
+//	app.Theme(ui.ThemeConfig{Primary: "#0f766e", Secondary: "#84cc16", SurfaceDark: "#0c0a09"})
+func (app *App) Theme(t ThemeConfig) {
+	app.mu.Lock()
+	app.theme = &t
+	app.mu.Unlock()
+}
+
+// themeCSS returns the :root variables and light-mode body rules of the
+// configured theme, or "" without one.
+func (app *App) themeCSS() string {
+	app.mu.RLock()
+	t := app.theme
+	app.mu.RUnlock()
+	if t == nil {
+		return ""
+	}
+	lg := app.log()
+	var vars, css strings.Builder
+	set := func(name, value string) bool {
+		if value = cssColor(lg, name, value); value == "" {
+			return false
+		}
+		fmt.Fprintf(&vars, "--gsui-%s:%s;", name, value)
+		return true
+	}
+	if set("primary", t.Primary) {
+		// Info toasts derive their tints from the primary color
+		vars.WriteString("--gsui-info-bg:color-mix(in srgb,var(--gsui-primary) 10%,#fff);" +
+			"--gsui-info-fg:color-mix(in srgb,var(--gsui-primary) 75%,#000);" +
+			"--gsui-info-border:color-mix(in srgb,var(--gsui-primary) 20%,#fff);" +
+			"--gsui-info-bg-dark:color-mix(in srgb,var(--gsui-primary) 20%,#000);" +
+			"--gsui-info-fg-dark:color-mix(in srgb,var(--gsui-primary) 55%,#fff);" +
+			"--gsui-info-border-dark:color-mix(in srgb,var(--gsui-primary) 45%,#000);")
+	}
+	set("secondary", t.Secondary)
+	if set("surface", t.Surface) {
+		vars.WriteString("--gsui-loading-bg:var(--gsui-surface);")
+		css.WriteString("body{background-color:var(--gsui-surface)}")
+	}
+	if set("surface-dark", t.SurfaceDark) {
+		vars.WriteString("--gsui-loading-bg-dark:var(--gsui-surface-dark);")
+	}
+	if set("text", t.Text) {
+		css.WriteString("body{color:var(--gsui-text)}")
+	}
+	set("text-dark", t.TextDark)
+	if vars.Len() == 0 {
+		return ""
+	}
+	return ":root{" + vars.String() + "}" + css.String()
+}
+
+// cssColor returns value if it is safe to place in a style sheet, or ""
+// (with a log line) if it could break out of its declaration.
+func cssColor(lg Logger, name, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if strings.ContainsAny(value, ";{}<>\\\"'") {
+		lg.Warnf("theme %s: ignoring unsafe value %q", name, value)
+		return ""
+	}
+	return value
+}
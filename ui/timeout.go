@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"context"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Handler context: deadlines and cancellation for page and action handlers
+// ---------------------------------------------------------------------------
+
+// HandlerTimeout bounds how long a page or action handler's Ctx lives;
+// d <= 0 (the default) sets no deadline. The handler is not interrupted:
+// the deadline reaches the database queries and HTTP calls that are given
+// ctx.Ctx(), which then fail with context.DeadlineExceeded.
+//
+//	app.HandlerTimeout(5 * time.Second)
+func (app *App) HandlerTimeout(d time.Duration) {
+	app.mu.Lock()
+	app.timeout = d
+	app.mu.Unlock()
+}
+
+// Ctx returns the context.Context of the current handler call; pass it to
+// database and network calls so they stop when the result is not wanted:
+//
+//	rows, err := db.QueryContext(ctx.Ctx(), "SELECT ...")
+//
+// A page load's Ctx ends when the browser drops the request, an action's
+// when its WebSocket closes, and either when HandlerTimeout passes or the
+// handler returns. Code that outlives the handler (a goroutine started by
+// it) must not rely on it. Outside a handler it is context.Background().
+func (ctx *Context) Ctx() context.Context {
+	if ctx.ctx == nil {
+		return context.Background()
+	}
+	return ctx.ctx
+}
+
+// bindCtx derives ctx's Ctx from parent and the app's HandlerTimeout; call
+// the returned function when the handler is done.
+func (app *App) bindCtx(ctx *Context, parent context.Context) context.CancelFunc {
+	app.mu.RLock()
+	d := app.timeout
+	app.mu.RUnlock()
+	var cancel context.CancelFunc
+	if d > 0 {
+		ctx.ctx, cancel = context.WithTimeout(parent, d)
+	} else {
+		ctx.ctx, cancel = context.WithCancel(parent)
+	}
+	return cancel
+}
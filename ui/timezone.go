@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Time zones: show and read times in the user's zone
+// ---------------------------------------------------------------------------
+
+// timeZoneKey holds the SetTimeZone preference in Context.Session;
+// timeZoneCookie carries the browser's zone, set by the page script.
+const (
+	timeZoneKey    = "__tz"
+	timeZoneCookie = "gsui_tz"
+)
+
+// timeZoneJS stores the browser's IANA time zone in the gsui_tz cookie, so
+// requests after the first one, and every action, know the user's zone.
+const timeZoneJS = `try{var tz=Intl.DateTimeFormat().resolvedOptions().timeZone;` +
+	`if(tz&&document.cookie.split('; ').indexOf('gsui_tz='+encodeURIComponent(tz))<0)` +
+	`document.cookie='gsui_tz='+encodeURIComponent(tz)+';path=/;max-age=31536000;samesite=lax'}catch(_){}`
+
+var zoneCache sync.Map // zone name -> *time.Location
+
+// loadZone returns the location named name, nil when it is unknown. Loaded
+// zones are kept, as time.LoadLocation reads the zone database each time.
+func loadZone(name string) *time.Location {
+	if name == "" || len(name) > 64 || strings.Contains(name, "..") {
+		return nil
+	}
+	if loc, ok := zoneCache.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	zoneCache.Store(name, loc)
+	return loc
+}
+
+// TimeZone returns the user's time zone: the preference saved by
+// SetTimeZone, else the zone the browser reports, else UTC. The browser's
+// zone is known from the second request on, and for every action. Show
+// stored times in it and Body reads submitted date-times in it:
+//
+//	ui.IDatetime().Attr("name", "Start").TimeValue(ev.Start.In(ctx.TimeZone()))
+//
+// Zone names come from the system's zone database; import time/tzdata in
+// programs that run where it is missing.
+func (ctx *Context) TimeZone() *time.Location {
+	if name, _ := ctx.Session[timeZoneKey].(string); name != "" {
+		if loc := loadZone(name); loc != nil {
+			return loc
+		}
+	}
+	if ctx.Request != nil {
+		if c, err := ctx.Request.Cookie(timeZoneCookie); err == nil {
+			if loc := loadZone(c.Value); loc != nil {
+				return loc
+			}
+		}
+	}
+	return time.UTC
+}
+
+// SetTimeZone saves name, an IANA zone such as "Europe/Bratislava", as the
+// session's time zone, ahead of the browser's. Unknown names are ignored;
+// "" clears the preference.
+func (ctx *Context) SetTimeZone(name string) {
+	if name == "" {
+		delete(ctx.Session, timeZoneKey)
+		return
+	}
+	if loadZone(name) == nil {
+		return
+	}
+	if ctx.Session == nil {
+		ctx.Session = make(map[string]any)
+	}
+	ctx.Session[timeZoneKey] = name
+}
+
+// TimeValue sets the input's value to t in the form its type expects:
+// "2006-01-02" for date, "2006-01" for month, "2006-W02" (the ISO week)
+// for week, "15:04" for time, "2006-01-02T15:04" for datetime-local, with
+// seconds when t has them. t is shown as is, so convert it to the user's
+// zone first with In(ctx.TimeZone()). The zero time leaves the input
+// empty.
+func (n *Node) TimeValue(t time.Time) *Node {
+	if t.IsZero() {
+		return n.Attr("value", "")
+	}
+	clock := "15:04"
+	if t.Second() != 0 {
+		clock = "15:04:05"
+	}
+	switch n.attrs["type"] {
+	case "date":
+		return n.Attr("value", t.Format(time.DateOnly))
+	case "time":
+		return n.Attr("value", t.Format(clock))
+	case "month":
+		return n.Attr("value", t.Format("2006-01"))
+	case "week":
+		year, week := t.ISOWeek()
+		return n.Attr("value", fmt.Sprintf("%04d-W%02d", year, week))
+	}
+	return n.Attr("value", t.Format("2006-01-02T"+clock))
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeZoneFromPreferenceThenCookie(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: timeZoneCookie, Value: "America/New_York"})
+	ctx := &Context{Request: req}
+	if got := ctx.TimeZone().String(); got != "America/New_York" {
+		t.Fatalf("cookie zone = %s", got)
+	}
+
+	ctx.SetTimeZone("Nowhere/Atlantis")
+	ctx.SetTimeZone("Europe/Bratislava")
+	if got := ctx.TimeZone().String(); got != "Europe/Bratislava" {
+		t.Fatalf("preferred zone = %s", got)
+	}
+	ctx.SetTimeZone("")
+	if got := ctx.TimeZone().String(); got != "America/New_York" {
+		t.Fatalf("cleared preference = %s", got)
+	}
+
+	bad := httptest.NewRequest("GET", "/", nil)
+	bad.AddCookie(&http.Cookie{Name: timeZoneCookie, Value: "../../etc/passwd"})
+	if got := (&Context{Request: bad}).TimeZone(); got != time.UTC {
+		t.Fatalf("invalid cookie zone = %s", got)
+	}
+	expect(t, wsClientJS, "document.cookie='gsui_tz='+encodeURIComponent(tz)")
+}
+
+func TestBodyReadsDateTimesInUserZone(t *testing.T) {
+	var in struct {
+		Start time.Time `json:"Start"`
+		Day   time.Time `json:"Day"`
+		At    time.Time `json:"At"`
+		Since time.Time `json:"Since" gsui:"layout=02.01.2006 15:04"`
+	}
+	ctx := &Context{Session: map[string]any{}, wsData: map[string]any{
+		"Start": "2024-07-01T09:30", "Day": "2024-07-01", "At": "09:30", "Since": "01.07.2024 09:30",
+	}}
+	ctx.SetTimeZone("Europe/Bratislava") // UTC+2 in summer
+	if err := ctx.Body(&in); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, 7, 1, 7, 30, 0, 0, time.UTC)
+	if !in.Start.Equal(want) || !in.Since.Equal(want) {
+		t.Errorf("date-times = %v, %v, want %v", in.Start, in.Since, want)
+	}
+	if in.Day.Format(time.DateOnly) != "2024-07-01" || in.Day.Hour() != 0 || in.At.Format("15:04") != "09:30" {
+		t.Errorf("dates and clock times must not shift: %v, %v", in.Day, in.At)
+	}
+}
+
+func TestTimeValueFormatsForInputType(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Bratislava")
+	ts := time.Date(2024, 7, 1, 7, 30, 0, 0, time.UTC).In(loc)
+	expect(t, IDatetime().TimeValue(ts).ToJS(), "setAttribute('value','2024-07-01T09:30')")
+	expect(t, IDate().TimeValue(ts).ToJS(), "setAttribute('value','2024-07-01')")
+	expect(t, ITime().TimeValue(ts.Add(5*time.Second)).ToJS(), "setAttribute('value','09:30:05')")
+	expect(t, IMonth().TimeValue(ts).ToJS(), "setAttribute('value','2024-07')")
+	expect(t, IDate().TimeValue(time.Time{}).ToJS(), "setAttribute('value','')")
+	expect(t, ITime().Seconds().ToJS(), "setAttribute('step','1')")
+	expect(t, IWeek().TimeValue(time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)).ToJS(), "setAttribute('value','2020-W53')")
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ---------------------------------------------------------------------------
+// TLS: HTTPS listeners and HSTS
+// ---------------------------------------------------------------------------
+
+// hstsHeader tells browsers to use HTTPS only for a year.
+const hstsHeader = "max-age=31536000"
+
+// tlsServer returns the server the TLS listeners use. Clients get a limited
+// time to send request headers and to keep an idle connection, so slow or
+// stalled ones cannot hold connections open. Whole requests and responses
+// have no deadline: WebSockets, SSE streams and downloads stay open as
+// long as they need.
+func tlsServer(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// ListenTLS is Listen over HTTPS with the given certificate and key files.
+// Session cookies get the Secure flag and responses carry HSTS, as on any
+// TLS connection.
+func (app *App) ListenTLS(addr, certFile, keyFile string) error {
+	app.setup()
+	app.log().Infof("listening on %s (TLS)", addr)
+	return tlsServer(addr, app.handler()).ListenAndServeTLS(certFile, keyFile)
+}
+
+// ListenAutoTLS serves HTTPS on :443 with certificates obtained from Let's
+// Encrypt for domains, and answers ACME challenges on :80 while
+// redirecting all other plain HTTP requests to HTTPS. Certificates are
+// cached in AutoTLSCache (default: "gsui-autocert" under the user cache
+// directory). By calling it you accept the Let's Encrypt terms of service.
+func (app *App) ListenAutoTLS(domains ...string) error {
+	if len(domains) == 0 {
+		return errors.New("gsui: ListenAutoTLS needs at least one domain")
+	}
+	app.setup()
+	dir := app.AutoTLSCache
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			base = "."
+		}
+		dir = filepath.Join(base, "gsui-autocert")
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(dir),
+	}
+	srv := tlsServer(":443", app.handler())
+	srv.TLSConfig = m.TLSConfig()
+	errc := make(chan error, 2)
+	go func() { errc <- tlsServer(":80", m.HTTPHandler(nil)).ListenAndServe() }()
+	go func() { errc <- srv.ListenAndServeTLS("", "") }()
+	app.log().Infof("listening on :443 (TLS for %v) and :80 (redirect)", domains)
+	return <-errc
+}
+
+// hsts adds Strict-Transport-Security to responses served over TLS. Plain
+// HTTP responses never carry it, so local development is unaffected.
+func hsts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", hstsHeader)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,263 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Wizard: a form split into steps, validated one step at a time
+// ---------------------------------------------------------------------------
+
+// WizardLocale holds the button labels of a wizard; empty fields keep the
+// English default.
+type WizardLocale struct {
+	Back   string
+	Next   string
+	Finish string
+}
+
+// WizardBuilder renders a multi-step form. Each step is a FormBuilder with
+// the usual fields and rules; only one is shown at a time. Next validates
+// the step in the browser and then on the server before moving on, Back
+// returns without validating, and Finish sends the fields of every step to
+// the wizard's action in one call.
+type WizardBuilder struct {
+	id     string
+	titles []string
+	steps  []*FormBuilder
+	action string
+	onStep func(ctx *Context, step int) error
+	locale *WizardLocale
+}
+
+// NewWizard creates a wizard with the given container ID.
+func NewWizard(id string) *WizardBuilder {
+	return &WizardBuilder{id: id}
+}
+
+// Step adds a step and returns its form for adding fields:
+//
+//	wiz.Step("Account").
+//		Email("Email", "Email").Required().Render().
+//		Password("Password", "Password").Required().Min(8).Render()
+//
+// Submit buttons added to a step form are not needed; the wizard renders
+// its own.
+func (w *WizardBuilder) Step(title string) *FormBuilder {
+	f := NewForm(w.id + "-" + strconv.Itoa(len(w.steps)))
+	w.titles = append(w.titles, title)
+	w.steps = append(w.steps, f)
+	return f
+}
+
+// Action sets the WS action that Finish calls with the data of all steps.
+func (w *WizardBuilder) Action(name string) *WizardBuilder { w.action = name; return w }
+
+// OnStep sets an extra server-side check run after a step passes its field
+// rules, before the wizard moves on; step counts from 0. Return FormErrors
+// to mark fields, or any other error to show it in the step's summary.
+func (w *WizardBuilder) OnStep(fn func(ctx *Context, step int) error) *WizardBuilder {
+	w.onStep = fn
+	return w
+}
+
+// Locale sets the button labels.
+func (w *WizardBuilder) Locale(l *WizardLocale) *WizardBuilder { w.locale = l; return w }
+
+func (w *WizardBuilder) loc() *WizardLocale {
+	l := WizardLocale{Back: "Back", Next: "Next", Finish: "Finish"}
+	if w.locale != nil {
+		if w.locale.Back != "" {
+			l.Back = w.locale.Back
+		}
+		if w.locale.Next != "" {
+			l.Next = w.locale.Next
+		}
+		if w.locale.Finish != "" {
+			l.Finish = w.locale.Finish
+		}
+	}
+	return &l
+}
+
+// Build renders the wizard and registers its step action on ctx's app.
+// The current step is kept in a hidden input, so a page re-render starts
+// from the first step again.
+func (w *WizardBuilder) Build(ctx *Context) *Node {
+	stepAction := ctx.Action("wizard."+w.id+".step", w.handleStep)
+	l := w.loc()
+
+	indicators := make([]*Node, len(w.steps))
+	for i, title := range w.titles {
+		state := "todo"
+		if i == 0 {
+			state = "current"
+		}
+		li := Li("flex items-center gap-2 text-sm text-gray-500 dark:text-gray-400 data-[state=current]:text-gray-900 dark:data-[state=current]:text-gray-100 data-[state=current]:font-medium").
+			Attr("data-step", strconv.Itoa(i)).Attr("data-state", state).Render(
+			Span("flex items-center justify-center w-7 h-7 rounded-full text-xs font-semibold border border-gray-300 dark:border-gray-600 in-data-[state=current]:bg-blue-600 in-data-[state=current]:border-blue-600 in-data-[state=current]:text-white in-data-[state=done]:bg-blue-100 in-data-[state=done]:border-blue-100 in-data-[state=done]:text-blue-700 dark:in-data-[state=done]:bg-blue-900/40 dark:in-data-[state=done]:border-blue-900/40 dark:in-data-[state=done]:text-blue-300").
+				Text(strconv.Itoa(i+1)),
+			Span().Text(title),
+		)
+		if i == 0 {
+			li.Attr("aria-current", "step")
+		}
+		indicators[i] = li
+	}
+
+	btn := "inline-flex items-center justify-center gap-2 rounded-lg font-medium transition-colors px-4 py-2 text-sm focus:outline-none focus-visible:ring-2 focus-visible:ring-blue-500 focus-visible:ring-offset-2 dark:focus-visible:ring-offset-gray-900 disabled:opacity-50 disabled:cursor-not-allowed cursor-pointer"
+	primary := btn + " bg-blue-600 hover:bg-blue-700 text-white dark:bg-blue-600 dark:hover:bg-blue-500"
+	secondary := btn + " border border-gray-300 dark:border-gray-600 text-gray-700 dark:text-gray-200 hover:bg-gray-50 dark:hover:bg-gray-800"
+
+	panels := make([]*Node, len(w.steps))
+	for i, f := range w.steps {
+		nav := Div("flex gap-2 justify-between")
+		if i > 0 {
+			nav.Render(Button(secondary).Attr("type", "button").Text(l.Back).OnClick(JS(w.goJS(i - 1))))
+		} else {
+			nav.Render(Span())
+		}
+		if i < len(w.steps)-1 {
+			nav.Render(Button(primary).Attr("type", "button").Text(l.Next).OnClick(JS(w.nextJS(i, stepAction.Name))))
+		} else {
+			nav.Render(Button(primary).Attr("type", "button").Text(l.Finish).OnClick(JS(w.finishJS())))
+		}
+		panel := Div("flex flex-col gap-4").ID(w.panelID(i)).Attr("data-panel", strconv.Itoa(i)).
+			Attr("aria-label", w.titles[i]).Attr("role", "group").Render(f.Build(), nav)
+		if i > 0 {
+			panel.Class("hidden")
+		}
+		panels[i] = panel
+	}
+
+	return Div("flex flex-col gap-6").ID(w.id).Render(
+		Input().Attr("type", "hidden").ID(w.stepID()).Attr("value", "0"),
+		Ol("flex flex-wrap items-center gap-4").Render(indicators...),
+		Div().Render(panels...),
+	).JS(wizardJS)
+}
+
+// wizardJS gives the wizard element a __gsuiStep(i) method that shows
+// step i and updates the hidden step input and the indicators.
+const wizardJS = `(function(w){` +
+	`w.__gsuiStep=function(i){` +
+	`var s=w.querySelector('input[type=hidden]');if(s)s.value=String(i);` +
+	`w.querySelectorAll('[data-panel]').forEach(function(p){p.classList.toggle('hidden',+p.getAttribute('data-panel')!==i)});` +
+	`w.querySelectorAll('li[data-step]').forEach(function(li){var k=+li.getAttribute('data-step');` +
+	`li.setAttribute('data-state',k<i?'done':k===i?'current':'todo');` +
+	`if(k===i)li.setAttribute('aria-current','step');else li.removeAttribute('aria-current')});` +
+	`var p=w.querySelector('[data-panel="'+i+'"]'),f=p&&p.querySelector('input:not([type=hidden]),select,textarea');` +
+	`if(f)f.focus()}` +
+	`})(this)`
+
+func (w *WizardBuilder) stepID() string       { return w.id + "-step" }
+func (w *WizardBuilder) panelID(i int) string { return w.id + "-panel-" + strconv.Itoa(i) }
+
+// goJS returns JS that shows step i.
+func (w *WizardBuilder) goJS(i int) string {
+	return fmt.Sprintf("var w=document.getElementById('%s');if(w&&w.__gsuiStep)w.__gsuiStep(%d);", escJS(w.id), i)
+}
+
+// nextJS validates step i in the browser and sends its fields to the step
+// action, which validates them again and moves on.
+func (w *WizardBuilder) nextJS(i int, action string) string {
+	var b strings.Builder
+	b.WriteString("(function(){")
+	w.steps[i].writeChecks(&b)
+	fmt.Fprintf(&b, "var d={Action:'next',__step:%d};", i)
+	w.steps[i].writeCollect(&b)
+	b.WriteString(busyButtonJS)
+	fmt.Fprintf(&b, "__ws.call('%s',d);", escJS(action))
+	b.WriteString("})()")
+	return b.String()
+}
+
+// finishJS validates the last step and sends the fields of all steps to
+// the wizard's action.
+func (w *WizardBuilder) finishJS() string {
+	last := w.steps[len(w.steps)-1]
+	var b strings.Builder
+	b.WriteString("(function(){")
+	last.writeChecks(&b)
+	b.WriteString("var d={Action:'finish'};")
+	for _, f := range w.steps {
+		f.writeCollect(&b)
+	}
+	b.WriteString(busyButtonJS)
+	fmt.Fprintf(&b, "__ws.call('%s',d);", escJS(w.action))
+	b.WriteString("})()")
+	return b.String()
+}
+
+// handleStep is the step action: it validates the fields of the step the
+// client is on and answers with their errors or with the next step.
+func (w *WizardBuilder) handleStep(ctx *Context) string {
+	data := ctx.WsData()
+	n, ok := data["__step"].(float64)
+	i := int(n)
+	if !ok || float64(i) != n || i < 0 || i >= len(w.steps)-1 {
+		return ""
+	}
+	f := w.steps[i]
+	if errs := f.Validate(data); errs.HasErrors() {
+		return f.ShowError(errs)
+	}
+	if w.onStep != nil {
+		if err := w.onStep(ctx, i); err != nil {
+			return f.ShowError(err)
+		}
+	}
+	return f.ShowError(nil) + w.goJS(i+1)
+}
+
+// Validate checks the data sent by Finish against the fields of every
+// step, as FormBuilder.Validate does for one form.
+//
+//	app.Action("signup", func(ctx *ui.Context) string {
+//		if errs := wiz.Validate(ctx.WsData()); errs.HasErrors() {
+//			return wiz.ShowError(errs)
+//		}
+//		...
+//	})
+func (w *WizardBuilder) Validate(data map[string]any) FormErrors {
+	errs := make(FormErrors)
+	for _, f := range w.steps {
+		for name, msg := range f.Validate(data) {
+			errs[name] = msg
+		}
+	}
+	return errs
+}
+
+// ShowError returns JS that shows err in the Finish action's reply. With
+// FormErrors the wizard goes back to the first step holding a failed field
+// and marks its fields; any other error is shown on the last step.
+func (w *WizardBuilder) ShowError(err error) string {
+	if len(w.steps) == 0 {
+		return ""
+	}
+	at := len(w.steps) - 1
+	var fe FormErrors
+	if errors.As(err, &fe) {
+		for i, f := range w.steps {
+			if f.hasFieldIn(fe) {
+				at = i
+				break
+			}
+		}
+	}
+	return w.goJS(at) + w.steps[at].ShowError(err)
+}
+
+// hasFieldIn reports whether any field of f has an entry in errs.
+func (f *FormBuilder) hasFieldIn(errs FormErrors) bool {
+	for i := range f.fields {
+		if _, ok := errs[f.fields[i].Name]; ok {
+			return true
+		}
+	}
+	return false
+}